@@ -2,6 +2,8 @@ package tts
 
 import (
 	"bytes"
+	"context"
+	"discord-bot/health"
 	"discord-bot/utils"
 	"encoding/binary"
 	"fmt"
@@ -11,6 +13,20 @@ import (
 	"github.com/jonas747/ogg"
 )
 
+func init() {
+	health.Register(health.Check{
+		Name: "ffmpeg",
+		Run: func(ctx context.Context) (string, error) {
+			// used to encode the TTS audio
+			path, err := exec.LookPath("ffmpeg")
+			if err != nil {
+				return "", err
+			}
+			return path, nil
+		},
+	})
+}
+
 type dca struct {
 	pipReader  io.Reader
 	framesChan chan []byte
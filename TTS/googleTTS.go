@@ -1,6 +1,9 @@
 package tts
 
 import (
+	"context"
+	"discord-bot/metrics"
+	"discord-bot/ratelimit"
 	"discord-bot/utils"
 	"errors"
 	"fmt"
@@ -30,7 +33,9 @@ func GenerateAndSaveToFile(text string, options ...TTSOptions) error {
 
 	url := getAudioUrl(text, option)
 
+	start := time.Now()
 	audioData, err := downloadAudioData(url)
+	metrics.ObserveTTS(start, err)
 	if err != nil {
 		return err
 	}
@@ -53,12 +58,21 @@ func GenerateAndSendToVoiceChannel(text string, voiceConnection *discordgo.Voice
 
 	url := getAudioUrl(text, option)
 
+	start := time.Now()
 	audioData, err := downloadAudioData(url)
+	metrics.ObserveTTS(start, err)
 	if err != nil {
 		return err
 	}
 	defer (audioData).Close()
 
+	// the TTS runs outside the handlers too, so it doesn't wait for the shutdown
+	err = ratelimit.FFmpeg.Wait(context.Background())
+	if err != nil {
+		return err
+	}
+	defer ratelimit.FFmpeg.Release()
+
 	dca := encodeMem(audioData)
 
 	for {
@@ -1,6 +1,10 @@
 package common
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
 
 type VoiceState = int
 
@@ -11,15 +15,38 @@ const (
 	VoiceSwitched
 )
 
-type DmsCommand struct {
-	Name        string
-	Subcommands []string
-	Handler     func(s *discordgo.Session, m *discordgo.MessageCreate, subcommand string, args *[]string)
+type SlashCommand struct {
+	Command  discordgo.ApplicationCommand
+	Feature  Feature       // the feature the command belongs to, empty for the commands that are always enabled
+	Cooldown time.Duration // the time a user waits to use the command again, the guilds can change it with /cooldown
+	Handler  func(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData)
 }
 
-type SlashCommand struct {
-	Command discordgo.ApplicationCommand
-	Handler func(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData)
+// Feature is a group of commands that can be disabled or enabled in some guilds only with the commands.features config
+type Feature = string
+
+const (
+	FeatureBotActivity         Feature = "botActivity"
+	FeatureCustomCommands      Feature = "customCommands"
+	FeatureMemeMe              Feature = "memeMe"
+	FeaturePrefixCommand       Feature = "prefixCommand"
+	FeatureSay                 Feature = "say"
+	FeatureSchedule            Feature = "schedule"
+	FeatureTorrent             Feature = "torrent"
+	FeatureWelcomeVoiceMessage Feature = "welcomeVoiceMessage"
+	FeatureYts                 Feature = "yts"
+)
+
+var Features = []Feature{
+	FeatureBotActivity,
+	FeatureCustomCommands,
+	FeatureMemeMe,
+	FeaturePrefixCommand,
+	FeatureSay,
+	FeatureSchedule,
+	FeatureTorrent,
+	FeatureWelcomeVoiceMessage,
+	FeatureYts,
 }
 
 type Torrent struct {
@@ -98,27 +125,102 @@ type MemeResponse struct {
 }
 
 type Config struct {
-	Log struct {
-		Enabled bool   `json:"enabled"`
-		Path    string `json:"path"`
-	} `json:"log"`
+	Log LogConfig `json:"log"`
 
 	Torrent struct {
-		DownloadDir string `json:"downloadDir"`
-		ZipDir      string `json:"zipDir"`
+		DownloadDir  string            `json:"downloadDir"`
+		ZipDir       string            `json:"zipDir"`
+		MinFreeSpace int               `json:"minFreeSpace"` // megabytes, "/readyz" fails below it, default 1024
+		SpeedLimit   SpeedLimit        `json:"speedLimit"`
+		Seeding      SeedingPolicy     `json:"seeding"`
+		Hooks        PostDownloadHooks `json:"hooks"`
 	} `json:"torrent"`
 
+	Commands struct {
+		DevGuild string                   `json:"devGuild"` // register the commands to this guild only, they update instantly, the global commands are left as they are
+		Features map[Feature]FeatureScope `json:"features"` // a missing feature is enabled in all the guilds
+	} `json:"commands"`
+
+	CustomCommands struct {
+		FilesDir string `json:"filesDir"` // where the files of the custom commands are stored
+	} `json:"customCommands"`
+
+	Limits LimitsConfig `json:"limits"`
+
 	Http struct {
 		Domain string `json:"domain"`
 		Host   string `json:"host"`
 		Port   int    `json:"port"`
 		Routes struct {
-			Video string `json:"video"`
-			Zip   string `json:"zip"`
+			Video   string `json:"video"`
+			Zip     string `json:"zip"`
+			Metrics string `json:"metrics"`
 		} `json:"routes"`
 	} `json:"http"`
 }
 
+type SeedingMode = string
+
+const (
+	SeedingNone  SeedingMode = "none"  // stop after download
+	SeedingRatio SeedingMode = "ratio" // seed until the upload ratio is reached
+	SeedingTime  SeedingMode = "time"  // seed for a number of hours
+)
+
+type LogConfig struct {
+	Enabled    bool   `json:"enabled"`    // write the log file
+	Path       string `json:"path"`       // the log file path
+	Level      string `json:"level"`      // "debug", "info", "warning" or "error", default "info"
+	Format     string `json:"format"`     // the log file format, "text" or "json", default "text"
+	Console    string `json:"console"`    // the console format, "pretty" or "json", default "pretty"
+	MaxSize    int    `json:"maxSize"`    // megabytes before the log file is rotated, default 50
+	MaxAge     int    `json:"maxAge"`     // days to keep the rotated files, 0 keeps them
+	MaxBackups int    `json:"maxBackups"` // the number of rotated files to keep, 0 keeps them
+	Compress   bool   `json:"compress"`   // gzip the rotated files
+}
+
+type LimitsConfig struct {
+	GuildCommandsPerMinute int `json:"guildCommandsPerMinute"` // the commands a guild can use in a minute, default 60, -1 for no limit
+	Concurrency            struct {
+		Scraping int `json:"scraping"` // the torrent, YTS and meme searches, default 2
+		FFmpeg   int `json:"ffmpeg"`   // the TTS conversions, default 2
+		Images   int `json:"images"`   // the rendered images, default 2
+	} `json:"concurrency"` // the operations of each kind that can run at the same time
+}
+
+type FeatureScope struct {
+	Disabled bool     `json:"disabled"`
+	Guilds   []string `json:"guilds"` // the guild IDs where the feature is enabled, empty for all the guilds
+}
+
+type SeedingPolicy struct {
+	Mode  SeedingMode `json:"mode"`
+	Ratio float64     `json:"ratio"` // required for "ratio" mode
+	Hours float64     `json:"hours"` // required for "time" mode
+}
+
+// SpeedLimit is in KB/s, 0 for no limit
+type SpeedLimit struct {
+	Download int64 `json:"download"`
+	Upload   int64 `json:"upload"`
+}
+
+type LibraryMode = string
+
+const (
+	LibraryHardlink LibraryMode = "hardlink" // keep the downloaded files, required for seeding
+	LibraryMove     LibraryMode = "move"     // move the downloaded files into the library
+)
+
+// PostDownloadHooks runs after a torrent download is completed, an empty value disables a hook
+type PostDownloadHooks struct {
+	LibraryDir   string      `json:"libraryDir"`   // organise the files into Movies/Title (Year) and TV/Show/Season N
+	LibraryMode  LibraryMode `json:"libraryMode"`  // "hardlink" or "move", defaults to "hardlink"
+	Command      string      `json:"command"`      // run with "sh -c", or "cmd /C" on Windows, the torrent info is passed as environment variables
+	RescanURL    string      `json:"rescanUrl"`    // a library rescan webhook, e.g. Jellyfin or Plex
+	RescanMethod string      `json:"rescanMethod"` // defaults to "POST"
+}
+
 type X1337xCategory int
 
 func (x X1337xCategory) String() string {
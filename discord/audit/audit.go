@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"discord-bot/discord/components"
+	"discord-bot/firebase"
+	"discord-bot/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var Log = &utils.Log
+
+type Action = string
+
+const (
+	ActionBotActivitySet      Action = "botActivity.set"
+	ActionCooldownSet         Action = "cooldown.set"
+	ActionCooldownReset       Action = "cooldown.reset"
+	ActionCustomCommandAdd    Action = "customCommand.add"
+	ActionCustomCommandEdit   Action = "customCommand.edit"
+	ActionCustomCommandRemove Action = "customCommand.remove"
+	ActionCustomCommandImport Action = "customCommand.import"
+	ActionModLogSet           Action = "modLog.set"
+	ActionPrefixSet           Action = "prefix.set"
+	ActionTorrentAdd          Action = "torrent.add"
+	ActionTorrentRemove       Action = "torrent.remove"
+	ActionTorrentResume       Action = "torrent.resume"
+	ActionWelcomeSet          Action = "welcome.set"
+	ActionWelcomeRemove       Action = "welcome.remove"
+)
+
+var Actions = []Action{
+	ActionBotActivitySet,
+	ActionCooldownSet,
+	ActionCooldownReset,
+	ActionCustomCommandAdd,
+	ActionCustomCommandEdit,
+	ActionCustomCommandRemove,
+	ActionCustomCommandImport,
+	ActionModLogSet,
+	ActionPrefixSet,
+	ActionTorrentAdd,
+	ActionTorrentRemove,
+	ActionTorrentResume,
+	ActionWelcomeSet,
+	ActionWelcomeRemove,
+}
+
+// the max length of the before and after values, so the log fits in the guild document
+const maxValueLength = 500
+
+// Change is an administrative action to record
+type Change struct {
+	GuildID string
+	UserID  string
+	Action  Action
+	Target  string // what was changed, like the trigger of a custom command, empty when there's only one
+	Before  string // empty when it's added
+	After   string // empty when it's removed
+}
+
+func newID() string {
+	return strconv.FormatInt(int64(utils.RandomInt(36*36*36, 36*36*36*36)), 36)
+}
+
+func truncate(value string) string {
+	runes := []rune(value)
+	if len(runes) <= maxValueLength {
+		return value
+	}
+	return string(runes[:maxValueLength-1]) + "…"
+}
+
+// Record saves the change to the audit log of the guild and mirrors it to the mod-log channel, the changes outside
+// a guild are only logged
+func Record(s *discordgo.Session, change Change) {
+	Log.With(utils.FieldGuild, change.GuildID, utils.FieldUser, change.UserID).
+		Info("audit", "action", change.Action, "target", change.Target, "before", change.Before, "after", change.After)
+
+	if change.GuildID == "" {
+		return
+	}
+
+	entry := firebase.AuditEntry{
+		ID:     newID(),
+		UserID: change.UserID,
+		Action: change.Action,
+		Target: truncate(change.Target),
+		Before: truncate(change.Before),
+		After:  truncate(change.After),
+		At:     time.Now().Unix(),
+	}
+
+	err := firebase.AddAuditEntry(change.GuildID, entry)
+	if err != nil {
+		Log.Error("\nAudit:", err.Error())
+		Log.Debug(Log.Level.Error, "adding an audit log entry:", err.Error())
+	}
+
+	guildData, err := firebase.GetGuildData(change.GuildID)
+	if err != nil || guildData.ModLogChannel == "" {
+		return
+	}
+
+	_, err = s.ChannelMessageSendEmbed(guildData.ModLogChannel, EntryEmbed(entry))
+	if err != nil {
+		Log.Error("\nAudit:", err.Error())
+		Log.Debug(Log.Level.Error, "mirroring an audit log entry to the mod-log channel", guildData.ModLogChannel+":", err.Error())
+	}
+}
+
+// FormatEntry formats an entry as one line for the audit list
+func FormatEntry(entry firebase.AuditEntry) string {
+	line := fmt.Sprintf("<t:%d:f> <@%s> `%s`", entry.At, entry.UserID, entry.Action)
+	if entry.Target != "" {
+		line += fmt.Sprintf(" **%s**", shorten(entry.Target, 50))
+	}
+
+	switch {
+	case entry.Before != "" && entry.After != "":
+		line += fmt.Sprintf(": `%s` → `%s`", shorten(entry.Before, 40), shorten(entry.After, 40))
+	case entry.After != "":
+		line += fmt.Sprintf(": `%s`", shorten(entry.After, 80))
+	}
+
+	return line
+}
+
+// EntryEmbed formats an entry as an embed for the mod-log channel
+func EntryEmbed(entry firebase.AuditEntry) *discordgo.MessageEmbed {
+	embed := components.NewEmbed().
+		SetTitle(entry.Action).
+		SetColor(0x0099ff).
+		AddField("By", "<@"+entry.UserID+">", true).
+		SetFooter("ID: " + entry.ID).
+		SetTimestamp(time.Unix(entry.At, 0).Format(time.RFC3339))
+
+	if entry.Target != "" {
+		embed.AddField("Target", entry.Target, true)
+	}
+	if entry.Before != "" {
+		embed.AddField("Before", codeBlock(entry.Before), false)
+	}
+	if entry.After != "" {
+		embed.AddField("After", codeBlock(entry.After), false)
+	}
+
+	return embed.Truncate().Into()
+}
+
+func codeBlock(value string) string {
+	return "```\n" + strings.ReplaceAll(value, "```", "'''") + "\n```"
+}
+
+// shorten cuts a value for one line, the code quotes can't be escaped so they are removed
+func shorten(value string, length int) string {
+	value = strings.ReplaceAll(strings.ReplaceAll(value, "`", "'"), "\n", " ")
+	if len([]rune(value)) <= length {
+		return value
+	}
+	return string([]rune(value)[:length-1]) + "…"
+}
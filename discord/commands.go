@@ -0,0 +1,224 @@
+package discord
+
+import (
+	"discord-bot/discord/events"
+	"discord-bot/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// the guilds whose commands are already synced, GuildCreate is sent again after a reconnect
+var syncedGuilds sync.Map
+
+// registerCommands registers the global commands, or the commands of the dev guild in dev mode, the guild commands of
+// the features that are enabled in some guilds only are registered when the guilds are received
+func registerCommands(s *discordgo.Session) error {
+	devGuild := utils.GetAppConfig().Commands.DevGuild
+	if devGuild != "" {
+		Log.Warning("\nDev mode:", "the commands are registered to the guild", devGuild, "only")
+		Log.Debug(Log.Level.Warning, "Dev mode: the commands are registered to the guild", devGuild, "only")
+
+		syncedGuilds.Store(devGuild, true)
+		return syncCommands(s, devGuild, events.DevCommands())
+	}
+
+	return syncCommands(s, "", events.CommandsFor(""))
+}
+
+// onGuildCreate syncs the guild commands, a guild that has no guild only features gets its old guild commands removed
+func onGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	defer events.RecoverEvent("guildCreate")
+
+	if utils.GetAppConfig().Commands.DevGuild != "" {
+		return
+	}
+
+	if _, synced := syncedGuilds.LoadOrStore(g.ID, true); synced {
+		return
+	}
+
+	err := syncCommands(s, g.ID, events.CommandsFor(g.ID))
+	if err != nil {
+		syncedGuilds.Delete(g.ID)
+
+		Log.Error("\nOnGuildCreate:", err.Error())
+		Log.Debug(Log.Level.Error, "OnGuildCreate:", err.Error())
+	}
+}
+
+// syncCommands overwrites the registered commands of the scope, an empty guildID for the global commands,
+// nothing is uploaded when they didn't change
+func syncCommands(s *discordgo.Session, guildID string, commands []*discordgo.ApplicationCommand) error {
+	scope := "global"
+	if guildID != "" {
+		scope = "guild " + guildID
+	}
+
+	registered, err := s.ApplicationCommands(os.Getenv("APP_ID"), guildID)
+	if err != nil {
+		return fmt.Errorf("getting the %s commands: %w", scope, err)
+	}
+
+	if sameCommands(registered, commands) {
+		Log.Debug(Log.Level.Info, "The", scope, "commands didn't change, skipping the registration")
+		return nil
+	}
+
+	_, err = s.ApplicationCommandBulkOverwrite(os.Getenv("APP_ID"), guildID, commands)
+	if err != nil {
+		return fmt.Errorf("registering the %s commands: %w", scope, err)
+	}
+
+	Log.Info("\nRegistered", fmt.Sprint(len(commands)), "commands for", scope)
+	Log.Debug(Log.Level.Info, "Registered", fmt.Sprint(len(commands)), "commands for", scope)
+	return nil
+}
+
+// commandShape holds the fields of a command that discord stores, the defaults are filled so the registered commands
+// can be compared with the local ones
+type commandShape struct {
+	Type                     discordgo.ApplicationCommandType
+	Name                     string
+	NameLocalizations        map[discordgo.Locale]string
+	Description              string
+	DescriptionLocalizations map[discordgo.Locale]string
+	DefaultMemberPermissions int64 // -1 for everyone
+	DMPermission             bool
+	NSFW                     bool
+	Options                  []optionShape
+}
+
+type optionShape struct {
+	Type                     discordgo.ApplicationCommandOptionType
+	Name                     string
+	NameLocalizations        map[discordgo.Locale]string
+	Description              string
+	DescriptionLocalizations map[discordgo.Locale]string
+	ChannelTypes             []discordgo.ChannelType
+	Required                 bool
+	Autocomplete             bool
+	Choices                  []choiceShape
+	MinValue                 *float64
+	MaxValue                 float64
+	MinLength                *int
+	MaxLength                int
+	Options                  []optionShape
+}
+
+type choiceShape struct {
+	Name              string
+	NameLocalizations map[discordgo.Locale]string
+	Value             string
+}
+
+func localizations(value map[discordgo.Locale]string) map[discordgo.Locale]string {
+	if len(value) == 0 {
+		return nil
+	}
+	return value
+}
+
+func toCommandShape(c *discordgo.ApplicationCommand) commandShape {
+	shape := commandShape{
+		Type:                     c.Type,
+		Name:                     c.Name,
+		Description:              c.Description,
+		DefaultMemberPermissions: -1,
+		DMPermission:             true,
+		Options:                  toOptionShapes(c.Options),
+	}
+
+	if shape.Type == 0 {
+		shape.Type = discordgo.ChatApplicationCommand
+	}
+	if c.NameLocalizations != nil {
+		shape.NameLocalizations = localizations(*c.NameLocalizations)
+	}
+	if c.DescriptionLocalizations != nil {
+		shape.DescriptionLocalizations = localizations(*c.DescriptionLocalizations)
+	}
+	if c.DefaultMemberPermissions != nil {
+		shape.DefaultMemberPermissions = *c.DefaultMemberPermissions
+	}
+	if c.DMPermission != nil {
+		shape.DMPermission = *c.DMPermission
+	}
+	if c.NSFW != nil {
+		shape.NSFW = *c.NSFW
+	}
+
+	return shape
+}
+
+func toOptionShapes(options []*discordgo.ApplicationCommandOption) []optionShape {
+	if len(options) == 0 {
+		return nil
+	}
+
+	shapes := make([]optionShape, 0, len(options))
+	for _, o := range options {
+		shape := optionShape{
+			Type:                     o.Type,
+			Name:                     o.Name,
+			NameLocalizations:        localizations(o.NameLocalizations),
+			Description:              o.Description,
+			DescriptionLocalizations: localizations(o.DescriptionLocalizations),
+			Required:                 o.Required,
+			Autocomplete:             o.Autocomplete,
+			MinValue:                 o.MinValue,
+			MaxValue:                 o.MaxValue,
+			MinLength:                o.MinLength,
+			MaxLength:                o.MaxLength,
+			Options:                  toOptionShapes(o.Options),
+		}
+
+		if len(o.ChannelTypes) > 0 {
+			shape.ChannelTypes = slices.Clone(o.ChannelTypes)
+			slices.Sort(shape.ChannelTypes)
+		}
+
+		// compared as json, the registered numbers are float64 and the local ones can be any type
+		for _, choice := range o.Choices {
+			value, _ := json.Marshal(choice.Value)
+			shape.Choices = append(shape.Choices, choiceShape{
+				Name:              choice.Name,
+				NameLocalizations: localizations(choice.NameLocalizations),
+				Value:             string(value),
+			})
+		}
+
+		shapes = append(shapes, shape)
+	}
+
+	return shapes
+}
+
+// sameCommands checks if the registered commands are the same as the local ones, in any order
+func sameCommands(registered []*discordgo.ApplicationCommand, commands []*discordgo.ApplicationCommand) bool {
+	if len(registered) != len(commands) {
+		return false
+	}
+
+	toShapes := func(list []*discordgo.ApplicationCommand) []commandShape {
+		shapes := make([]commandShape, 0, len(list))
+		for _, c := range list {
+			shapes = append(shapes, toCommandShape(c))
+		}
+		slices.SortFunc(shapes, func(a, b commandShape) int {
+			if a.Type != b.Type {
+				return int(a.Type) - int(b.Type)
+			}
+			return strings.Compare(a.Name, b.Name)
+		})
+		return shapes
+	}
+
+	return reflect.DeepEqual(toShapes(registered), toShapes(commands))
+}
@@ -3,7 +3,7 @@ package components
 import "github.com/bwmarrin/discordgo"
 
 type textInput struct {
-	*discordgo.TextInput
+	discordgo.TextInput
 }
 
 // NOTE: modal interaction only.
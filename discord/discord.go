@@ -1,38 +1,70 @@
 package discord
 
 import (
+	"context"
 	"discord-bot/discord/dmsCommands"
 	"discord-bot/discord/events"
 	_ "discord-bot/discord/slashCommands" // to initialize slash commands
+	"discord-bot/health"
+	"discord-bot/metrics"
 	"discord-bot/utils"
+	"fmt"
 	"os"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-var Log = utils.Log
+var Log = &utils.Log
+
+var session *discordgo.Session
+
+// Start opens the Discord session, ctx is used by the handlers for their background work
+func Start(ctx context.Context) error {
+	events.SetContext(ctx)
 
-// StartDiscordBotSession starts the Discord session
-func StartDiscordBotSession() *discordgo.Session {
 	// Create a new Discord session using the provided bot token.
 	dg, err := discordgo.New("Bot " + os.Getenv("TOKEN"))
 	if err != nil {
-		Log.Fatal("\nerror creating Discord session:", err.Error())
-		Log.Debug(Log.Level.Fatal, err.Error())
+		return fmt.Errorf("creating Discord session: %w", err)
 	}
 
+	// count the failed API requests
+	dg.Client.Transport = metrics.NewDiscordTransport(dg.Client.Transport)
+
+	health.Register(health.Check{
+		Name: "discord",
+		// a gateway reconnect is normal, it only fails the readiness
+		Run: func(ctx context.Context) (string, error) {
+			dg.RLock()
+			defer dg.RUnlock()
+
+			if !dg.DataReady {
+				return "", fmt.Errorf("the gateway is not connected")
+			}
+			return fmt.Sprintf("%d guilds", len(dg.State.Guilds)), nil
+		},
+	})
+
+	metrics.RegisterGaugeFunc("voice_connections", "The number of open voice connections.", func() float64 {
+		dg.RLock()
+		defer dg.RUnlock()
+		return float64(len(dg.VoiceConnections))
+	})
+
 	// * Register Handlers.
 
 	// On Ready
 	dg.AddHandler(events.OnReady)
 
 	// Register Slash Commands
-	_, err = dg.ApplicationCommandBulkOverwrite(os.Getenv("APP_ID"), "", events.SlashCommands)
+	err = registerCommands(dg)
 	if err != nil {
-		Log.Fatal("\ncould not register commands:", err.Error())
-		Log.Debug(Log.Level.Fatal, err.Error())
+		return err
 	}
 
+	// Register the guild only commands
+	dg.AddHandler(onGuildCreate)
+
 	// On Interaction
 	dg.AddHandler(events.OnInteraction)
 
@@ -46,9 +78,17 @@ func StartDiscordBotSession() *discordgo.Session {
 	// * Begin listening.
 	err = dg.Open()
 	if err != nil {
-		Log.Fatal("\nerror opening Discord session:", err.Error())
-		Log.Debug(Log.Level.Fatal, err.Error())
+		return fmt.Errorf("opening Discord session: %w", err)
 	}
 
-	return dg
+	session = dg
+	return nil
+}
+
+// Stop closes the Discord session
+func Stop(ctx context.Context) error {
+	if session == nil {
+		return nil
+	}
+	return session.Close()
 }
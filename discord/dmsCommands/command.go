@@ -0,0 +1,174 @@
+package dmsCommands
+
+import (
+	"discord-bot/common"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type ArgType int
+
+const (
+	ArgString  ArgType = iota // a single word, or a quoted string
+	ArgText                   // the rest of the message, should be the last argument
+	ArgInt                    // a whole number
+	ArgBool                   // true/false, yes/no or on/off
+	ArgUser                   // a user mention or ID
+	ArgChannel                // a channel mention or ID
+)
+
+// Arg describes an argument of a command
+type Arg struct {
+	Name        string
+	Description string
+	Type        ArgType
+	Required    bool
+	Flag        bool     // passed as "--name value" instead of by position, "--name" alone for bool flags
+	Choices     []string // the allowed values, optional
+}
+
+// Command is a shared definition used to build a prefix command and optionally a slash command
+type Command struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Args        []Arg
+	Subcommands []*Command
+	Slash       bool // also register it as a slash command, only for commands that don't need a message
+	Feature     common.Feature
+	Cooldown    time.Duration // the time a user waits to use the command again, shared with the slash command
+	Handler     func(ctx *Context)
+}
+
+var commands = []*Command{}
+
+func registerCommand(c *Command) {
+	commands = append(commands, c)
+
+	if c.Slash {
+		registerSlashCommand(c)
+	}
+}
+
+// is checks if a name is the command name or one of its aliases
+func (c *Command) is(name string) bool {
+	return strings.EqualFold(c.Name, name) || slices.ContainsFunc(c.Aliases, func(alias string) bool {
+		return strings.EqualFold(alias, name)
+	})
+}
+
+func findCommand(name string) *Command {
+	for _, command := range commands {
+		if command.is(name) {
+			return command
+		}
+	}
+	return nil
+}
+
+func (c *Command) findSubcommand(name string) *Command {
+	for _, subcommand := range c.Subcommands {
+		if subcommand.is(name) {
+			return subcommand
+		}
+	}
+	return nil
+}
+
+func (c *Command) findArg(name string) *Arg {
+	for index := range c.Args {
+		if strings.EqualFold(c.Args[index].Name, name) {
+			return &c.Args[index]
+		}
+	}
+	return nil
+}
+
+func (c *Command) subcommandNames() []string {
+	names := []string{}
+	for _, subcommand := range c.Subcommands {
+		names = append(names, subcommand.Name)
+	}
+	return names
+}
+
+// Usage returns the usage text of a command, e.g. "!list save <name> [--tag <tag>]"
+func (c *Command) Usage(prefix string, parents ...*Command) string {
+	parts := []string{}
+	for _, parent := range parents {
+		parts = append(parts, parent.Name)
+	}
+	parts = append(parts, c.Name)
+
+	if len(c.Subcommands) > 0 && c.Handler == nil {
+		parts = append(parts, "<"+strings.Join(c.subcommandNames(), "|")+">")
+	}
+
+	for _, arg := range c.Args {
+		parts = append(parts, arg.usage())
+	}
+
+	return prefix + strings.Join(parts, " ")
+}
+
+func (a Arg) usage() string {
+	value := a.Name
+	if len(a.Choices) > 0 {
+		value = strings.Join(a.Choices, "|")
+	}
+	if a.Type == ArgText {
+		value += "..."
+	}
+
+	if a.Flag {
+		if a.Type == ArgBool {
+			return "[--" + a.Name + "]"
+		}
+		value = "--" + a.Name + " <" + value + ">"
+		if !a.Required {
+			return "[" + value + "]"
+		}
+		return value
+	}
+
+	if a.Required {
+		return "<" + value + ">"
+	}
+	return "[" + value + "]"
+}
+
+// Help returns the detailed help text of a command
+func (c *Command) Help(prefix string) string {
+	text := fmt.Sprintf("**%s%s** - %s\n", prefix, c.Name, c.Description)
+	if len(c.Aliases) > 0 {
+		text += fmt.Sprintf("Aliases: `%s`\n", strings.Join(c.Aliases, "`, `"))
+	}
+
+	if c.Handler != nil || len(c.Subcommands) == 0 {
+		text += fmt.Sprintf("Usage: `%s`\n", c.Usage(prefix))
+		text += argsHelp(c.Args)
+	}
+
+	for _, subcommand := range c.Subcommands {
+		text += fmt.Sprintf("\n`%s`\n", subcommand.Usage(prefix, c))
+		if subcommand.Description != "" {
+			text += subcommand.Description + "\n"
+		}
+		if len(subcommand.Aliases) > 0 {
+			text += fmt.Sprintf("Aliases: `%s`\n", strings.Join(subcommand.Aliases, "`, `"))
+		}
+		text += argsHelp(subcommand.Args)
+	}
+
+	return text
+}
+
+func argsHelp(args []Arg) string {
+	text := ""
+	for _, arg := range args {
+		text += fmt.Sprintf("🔹 `%s` %s\n", arg.Name, arg.Description)
+	}
+	return text
+}
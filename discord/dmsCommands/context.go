@@ -0,0 +1,104 @@
+package dmsCommands
+
+import (
+	"discord-bot/discord/interaction"
+	"discord-bot/utils"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Context is passed to the command handlers, it's the same for prefix and slash commands
+type Context struct {
+	Session     *discordgo.Session
+	Message     *discordgo.MessageCreate     // nil for slash commands
+	Interaction *discordgo.InteractionCreate // nil for prefix commands
+	GuildID     string                       // the selected guild of the user in DMs
+	ChannelID   string
+	Author      *discordgo.User
+	Prefix      string   // the message commands prefix of the guild
+	Command     *Command // the executed command or subcommand
+	Parents     []*Command
+
+	values    map[string]any
+	responded bool
+}
+
+// IsDM checks if the command is sent in a direct message
+func (c *Context) IsDM() bool {
+	return c.Message != nil && c.Message.GuildID == ""
+}
+
+func (c *Context) Has(name string) bool {
+	_, ok := c.values[name]
+	return ok
+}
+
+func (c *Context) String(name string) string {
+	value, _ := c.values[name].(string)
+	return value
+}
+
+func (c *Context) Int(name string) int64 {
+	value, _ := c.values[name].(int64)
+	return value
+}
+
+func (c *Context) Bool(name string) bool {
+	value, _ := c.values[name].(bool)
+	return value
+}
+
+// User returns the user of a user argument, nil if it's missing or not found
+func (c *Context) User(name string) *discordgo.User {
+	id := c.String(name)
+	if id == "" {
+		return nil
+	}
+
+	if member, err := c.Session.State.Member(c.GuildID, id); err == nil {
+		return member.User
+	}
+
+	user, err := c.Session.User(id)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// Channel returns the ID of a channel argument
+func (c *Context) Channel(name string) string {
+	return c.String(name)
+}
+
+// Reply replies to the message, or responds to the interaction
+func (c *Context) Reply(text string) {
+	// discord message limit
+	text = utils.Truncate(text, 2000)
+
+	var sendError error
+	switch {
+	case c.Message != nil:
+		_, sendError = c.Session.ChannelMessageSendReply(c.ChannelID, text, c.Message.Reference())
+	case c.responded:
+		_, sendError = c.Session.FollowupMessageCreate(c.Interaction.Interaction, true, &discordgo.WebhookParams{Content: text})
+	default:
+		sendError = interaction.RespondWithText(c.Session, c.Interaction, text, false)
+		c.responded = true
+	}
+
+	if sendError != nil {
+		Log.Error("\nDmsCommands:", sendError.Error())
+		Log.Debug(Log.Level.Error, "sending a reply for", c.Command.Name, "command:", sendError.Error())
+	}
+}
+
+// ReplyUsage replies with an usage error and the usage text of the command
+func (c *Context) ReplyUsage(err error) {
+	root := c.Command
+	if len(c.Parents) > 0 {
+		root = c.Parents[0]
+	}
+
+	c.Reply("❗ " + err.Error() + "\nUsage: `" + c.Command.Usage(c.Prefix, c.Parents...) + "`\nType `" + c.Prefix + "help " + root.Name + "` for more.")
+}
@@ -0,0 +1,254 @@
+package dmsCommands
+
+import (
+	"discord-bot/discord/components"
+	"discord-bot/discord/events"
+	"discord-bot/discord/interaction"
+	"discord-bot/firebase"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// how long a membership that is not in the state cache is remembered, so every DM doesn't request all the guilds
+const membershipTTL = 10 * time.Minute
+
+type membership struct {
+	member    bool
+	checkedAt time.Time
+}
+
+var (
+	// the guild used by the commands of each user in DMs, the key is the user ID
+	selectedGuilds = map[string]string{}
+
+	// the DM waiting for its user to select a guild, the key is the user ID
+	pendingDms = map[string]*discordgo.MessageCreate{}
+
+	// the requested memberships, the key is [guildID:userID]
+	memberships = map[string]membership{}
+
+	// guards selectedGuilds, pendingDms and memberships
+	dmMutex sync.Mutex
+)
+
+var guildCommand = Command{
+	Name:        "guild",
+	Aliases:     []string{"server"},
+	Description: "Select the server used by your commands in DMs",
+	Handler:     guildHandler,
+}
+
+var guildSelectRoute = events.NewRoute[events.NoPayload]("dm_guild_select", 1)
+
+func init() {
+	registerCommand(&guildCommand)
+	events.HandleComponent(guildSelectRoute, onGuildSelect)
+}
+
+// isMember checks if a user is in a guild, using the state cache first, then the remembered memberships
+func isMember(s *discordgo.Session, guildID string, userID string) bool {
+	if _, err := s.State.Member(guildID, userID); err == nil {
+		return true
+	}
+
+	key := guildID + ":" + userID
+
+	dmMutex.Lock()
+	cached, ok := memberships[key]
+	dmMutex.Unlock()
+
+	if ok && time.Since(cached.checkedAt) < membershipTTL {
+		return cached.member
+	}
+
+	_, err := s.GuildMember(guildID, userID)
+	member := err == nil
+
+	// only remember that the user is not a member when discord says so, not on a failed request
+	var restErr *discordgo.RESTError
+	if !member && (!errors.As(err, &restErr) || restErr.Response == nil || restErr.Response.StatusCode != http.StatusNotFound) {
+		return false
+	}
+
+	dmMutex.Lock()
+	defer dmMutex.Unlock()
+
+	now := time.Now()
+	for cachedKey, cached := range memberships {
+		if now.Sub(cached.checkedAt) >= membershipTTL {
+			delete(memberships, cachedKey)
+		}
+	}
+	memberships[key] = membership{member: member, checkedAt: now}
+
+	return member
+}
+
+func getSelectedGuild(userID string) (string, bool) {
+	dmMutex.Lock()
+	defer dmMutex.Unlock()
+
+	guildID, ok := selectedGuilds[userID]
+	return guildID, ok
+}
+
+// setSelectedGuild selects the guild of a user in DMs, an empty guild ID removes the selection
+func setSelectedGuild(userID string, guildID string) {
+	dmMutex.Lock()
+	defer dmMutex.Unlock()
+
+	if guildID == "" {
+		delete(selectedGuilds, userID)
+		return
+	}
+	selectedGuilds[userID] = guildID
+}
+
+// sharedGuilds returns the guilds of the bot that the user is in
+func sharedGuilds(s *discordgo.Session, userID string) []*discordgo.Guild {
+	guilds := []*discordgo.Guild{}
+	for _, guild := range s.State.Guilds {
+		if isMember(s, guild.ID, userID) {
+			guilds = append(guilds, guild)
+		}
+	}
+	return guilds
+}
+
+// resolveDmGuild returns the guild selected by the author of a DM, if the user shares several guilds
+// with the bot and didn't select one yet, it asks them to select one and returns an empty string
+func resolveDmGuild(s *discordgo.Session, m *discordgo.MessageCreate) string {
+	if guildID, ok := getSelectedGuild(m.Author.ID); ok {
+		if _, err := s.State.Guild(guildID); err == nil {
+			return guildID
+		}
+		setSelectedGuild(m.Author.ID, "") // the bot left the guild
+	}
+
+	guilds := sharedGuilds(s, m.Author.ID)
+
+	if len(guilds) == 0 {
+		_, sendError := s.ChannelMessageSendReply(m.ChannelID, "❗ You need to be in a server with me to use my commands.", m.Reference())
+		if sendError != nil {
+			Log.Error("\nOnDM:", sendError.Error())
+			Log.Debug(Log.Level.Error, sendError.Error())
+		}
+		return ""
+	}
+
+	if len(guilds) == 1 {
+		setSelectedGuild(m.Author.ID, guilds[0].ID)
+		return guilds[0].ID
+	}
+
+	dmMutex.Lock()
+	pendingDms[m.Author.ID] = m
+	dmMutex.Unlock()
+
+	sendGuildSelect(s, m.ChannelID, guilds, "We share several servers, which one do you want to use?")
+
+	return ""
+}
+
+func sendGuildSelect(s *discordgo.Session, channelID string, guilds []*discordgo.Guild, content string) {
+	menuOptions := []*components.SelectMenuOption{}
+	for index, guild := range guilds {
+		menuOptions = append(menuOptions, components.NewMenuOption().SetLabel(guild.Name).SetValue(guild.ID))
+
+		// ! max 25 options
+		if index >= 24 {
+			break
+		}
+	}
+
+	_, sendError := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: content,
+		Components: *components.AddMessageComponents(
+			components.NewRow(
+				components.NewSelectMenu().SetCustomID(guildSelectRoute.CustomID(events.NoPayload{})).SetPlaceholder("Select a server").SetOptions(menuOptions...),
+			),
+		),
+	})
+	if sendError != nil {
+		Log.Error("\nOnDM:", sendError.Error())
+		Log.Debug(Log.Level.Error, sendError.Error())
+	}
+}
+
+// interactionGuildID returns the guild of an interaction, or the selected guild of the user for DMs
+func interactionGuildID(i *discordgo.InteractionCreate) string {
+	if i.GuildID != "" {
+		return i.GuildID
+	}
+	guildID, _ := getSelectedGuild(i.User.ID)
+	return guildID
+}
+
+func guildHandler(ctx *Context) {
+	if !ctx.IsDM() {
+		ctx.Reply("❗ This command only works in DMs.")
+		return
+	}
+
+	guilds := sharedGuilds(ctx.Session, ctx.Author.ID)
+	if len(guilds) < 2 {
+		ctx.Reply("❗ We only share one server.")
+		return
+	}
+
+	sendGuildSelect(ctx.Session, ctx.ChannelID, guilds, "Select the server used by your commands.")
+}
+
+func onGuildSelect(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.MessageComponentInteractionData, _ events.NoPayload) {
+	// the menu is only sent in DMs
+	if i.User == nil {
+		return
+	}
+
+	guildID := data.Values[0]
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		sendError := interaction.RespondWithText(s, i, "❗ I'm not in this server anymore.", false)
+		if sendError != nil {
+			Log.Error("\nOnDM:", sendError.Error())
+			Log.Debug(Log.Level.Error, sendError.Error())
+		}
+		return
+	}
+
+	setSelectedGuild(i.User.ID, guildID)
+
+	prefix := "!"
+	if guildData, err := firebase.GetGuildData(guildID); err == nil {
+		prefix = guildData.Prefix
+	}
+
+	// replace the select menu with the selected guild
+	content := fmt.Sprintf("✅ Using **%s**, type `%sguild` to change it.", guild.Name, prefix)
+	sendError := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if sendError != nil {
+		Log.Error("\nOnDM:", sendError.Error())
+		Log.Debug(Log.Level.Error, sendError.Error())
+	}
+
+	// run the message that was waiting for the selection
+	dmMutex.Lock()
+	pending, ok := pendingDms[i.User.ID]
+	delete(pendingDms, i.User.ID)
+	dmMutex.Unlock()
+
+	if ok {
+		ExecuteDmsCommands(s, pending)
+	}
+}
@@ -0,0 +1,45 @@
+package dmsCommands
+
+import (
+	"discord-bot/discord/events"
+	"fmt"
+)
+
+var helpCommand = Command{
+	Name:        "help",
+	Aliases:     []string{"commands"},
+	Description: "Show the message commands and how to use them",
+	Args: []Arg{
+		{Name: "command", Description: "Show the details of a command", Type: ArgString},
+	},
+	Slash:   true,
+	Handler: helpHandler,
+}
+
+func init() {
+	registerCommand(&helpCommand)
+}
+
+func helpHandler(ctx *Context) {
+	if ctx.Has("command") {
+		command := findCommand(ctx.String("command"))
+		if command == nil || !events.FeatureEnabled(command.Feature, ctx.GuildID) {
+			ctx.Reply(fmt.Sprintf("❗ Unknown command `%s`, type `%shelp` to see all the commands.", ctx.String("command"), ctx.Prefix))
+			return
+		}
+
+		ctx.Reply(command.Help(ctx.Prefix))
+		return
+	}
+
+	text := "**Message commands:**\n"
+	for _, command := range commands {
+		if !events.FeatureEnabled(command.Feature, ctx.GuildID) {
+			continue
+		}
+		text += fmt.Sprintf("🔹 `%s` %s\n", command.Usage(ctx.Prefix), command.Description)
+	}
+	text += fmt.Sprintf("\nType `%shelp <command>` for more details, use quotes for arguments with spaces.", ctx.Prefix)
+
+	ctx.Reply(text)
+}
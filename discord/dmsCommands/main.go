@@ -2,23 +2,33 @@ package dmsCommands
 
 import (
 	"discord-bot/common"
+	"discord-bot/discord/events"
+	"discord-bot/discord/slashCommands/customCommands"
 	"discord-bot/firebase"
+	"discord-bot/ratelimit"
 	"discord-bot/utils"
+	"fmt"
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 var Log = &utils.Log
-var dms = []*common.DmsCommand{}
-
-func registerDmsCommands(c *common.DmsCommand) {
-	dms = append(dms, c)
-}
 
 func ExecuteDmsCommands(s *discordgo.Session, m *discordgo.MessageCreate) {
+	guildID := m.GuildID
+
+	// in DMs the commands use the guild selected by the user
+	isDM := guildID == ""
+	if isDM {
+		guildID = resolveDmGuild(s, m)
+		if guildID == "" {
+			return
+		}
+	}
+
 	// get guild data for command prefix and custom commands
-	guildData, err := firebase.GetGuildData(m.GuildID)
+	guildData, err := firebase.GetGuildData(guildID)
 	if err != nil {
 		_, sendError := s.ChannelMessageSendReply(m.ChannelID, "❗ Something went wrong while getting the guild data.", m.Reference())
 		if sendError != nil {
@@ -31,31 +41,81 @@ func ExecuteDmsCommands(s *discordgo.Session, m *discordgo.MessageCreate) {
 
 	content := strings.Trim(m.Content, " ")
 
-	for _, command := range dms {
-		withoutCommand, isCommand := strings.CutPrefix(content, guildData.Prefix+command.Name)
-		if isCommand {
-			args := strings.Split(withoutCommand, " ")
-			args = args[1:] // remove empty space
-			subcommand := ""
-
-			if len(args) != 0 && utils.Contains(&command.Subcommands, args[0]) {
-				subcommand = args[0]
-				args = args[1:] // remove subcommand
+	withoutPrefix, hasPrefix := strings.CutPrefix(content, guildData.Prefix)
+	if hasPrefix {
+		name, input := cutWord(withoutPrefix)
+		command := findCommand(name)
+		if command != nil && events.FeatureEnabled(command.Feature, guildID) {
+			cooldown := events.CommandCooldown(guildID, command.Name, command.Cooldown)
+			if wait, ok := ratelimit.Allow(guildID, m.Author.ID, command.Name, cooldown); !ok {
+				_, sendError := s.ChannelMessageSendReply(m.ChannelID, events.TryAgainText(wait), m.Reference())
+				if sendError != nil {
+					Log.Error("\nOnDM:", sendError.Error())
+					Log.Debug(Log.Level.Error, sendError.Error())
+				}
+				return
 			}
 
-			command.Handler(s, m, subcommand, &args)
+			executeCommand(s, m, guildID, guildData.Prefix, command, input)
 			return
 		}
 	}
 
+	if isDM {
+		_, sendError := s.ChannelMessageSendReply(m.ChannelID, fmt.Sprintf("Type `%shelp` to see my commands.", guildData.Prefix), m.Reference())
+		if sendError != nil {
+			Log.Error("\nOnDM:", sendError.Error())
+			Log.Debug(Log.Level.Error, sendError.Error())
+		}
+		return
+	}
+
 	// try custom commands
-	for _, command := range guildData.CustomCommands {
-		if content == command.When {
-			_, err := s.ChannelMessageSend(m.ChannelID, command.Say)
-			if err != nil {
-				Log.Error("\nCustomCommands:", err.Error())
-			}
-			return
+	if events.FeatureEnabled(common.FeatureCustomCommands, guildID) {
+		customCommands.Execute(s, m, guildData, content)
+	}
+}
+
+// executeCommand resolves the subcommand, parses the arguments and calls the handler
+func executeCommand(s *discordgo.Session, m *discordgo.MessageCreate, guildID string, prefix string, command *Command, input string) {
+	ctx := &Context{
+		Session:   s,
+		Message:   m,
+		GuildID:   guildID,
+		ChannelID: m.ChannelID,
+		Author:    m.Author,
+		Prefix:    prefix,
+		Command:   command,
+	}
+
+	tokens, err := tokenize(input)
+	if err != nil {
+		ctx.ReplyUsage(err)
+		return
+	}
+
+	if len(tokens) > 0 && !tokens[0].quoted {
+		if subcommand := command.findSubcommand(tokens[0].value); subcommand != nil {
+			ctx.Parents = []*Command{command}
+			ctx.Command = subcommand
+			_, input = cutWord(input)
+			tokens, _ = tokenize(input) // the positions should be relative to the new input
 		}
 	}
+
+	Log.With(utils.FieldGuild, guildID, utils.FieldChannel, m.ChannelID, utils.FieldUser, m.Author.ID, utils.FieldCommand, command.Name).
+		Info("prefix command", "subcommand", ctx.Command.Name, "userName", m.Author.Username, "content", m.Content)
+
+	if ctx.Command.Handler == nil {
+		ctx.ReplyUsage(fmt.Errorf("you need to specify a subcommand: %s", strings.Join(command.subcommandNames(), ", ")))
+		return
+	}
+
+	ctx.values, err = parseArgs(ctx.Command, input, tokens)
+	if err != nil {
+		ctx.ReplyUsage(err)
+		return
+	}
+
+	ctx.Command.Handler(ctx)
 }
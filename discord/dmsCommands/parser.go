@@ -0,0 +1,213 @@
+package dmsCommands
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	userMentionRegex    = regexp.MustCompile(`^<@!?(\d+)>$`)
+	channelMentionRegex = regexp.MustCompile(`^<#(\d+)>$`)
+	idRegex             = regexp.MustCompile(`^\d+$`)
+)
+
+type token struct {
+	value  string
+	start  int  // the position of the token in the input, used by text arguments
+	quoted bool // quoted tokens are never flags
+}
+
+// tokenize splits the input on spaces, text in double or single quotes is kept as one token
+// and a backslash escapes the next character
+func tokenize(input string) ([]token, error) {
+	tokens := []token{}
+
+	var current strings.Builder
+	var quote rune
+	inToken := false
+	quoted := false
+	escaped := false
+	start := 0
+
+	for index, char := range input {
+		switch {
+		case escaped:
+			current.WriteRune(char)
+			escaped = false
+
+		case char == '\\':
+			escaped = true
+			if !inToken {
+				inToken, start = true, index
+			}
+
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else {
+				current.WriteRune(char)
+			}
+
+		// quotes only count at the start of a token, so words like "it's" are kept as is
+		case (char == '"' || char == '\'') && !inToken:
+			quote = char
+			quoted = true
+			inToken, start = true, index
+
+		case unicode.IsSpace(char):
+			if inToken {
+				tokens = append(tokens, token{value: current.String(), start: start, quoted: quoted})
+				current.Reset()
+				inToken, quoted = false, false
+			}
+
+		default:
+			current.WriteRune(char)
+			if !inToken {
+				inToken, start = true, index
+			}
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("missing a closing quote (%c)", quote)
+	}
+
+	if inToken {
+		tokens = append(tokens, token{value: current.String(), start: start, quoted: quoted})
+	}
+
+	return tokens, nil
+}
+
+// cutWord returns the first word of the input and the rest of it
+func cutWord(input string) (string, string) {
+	input = strings.TrimLeftFunc(input, unicode.IsSpace)
+	index := strings.IndexFunc(input, unicode.IsSpace)
+	if index == -1 {
+		return input, ""
+	}
+	return input[:index], input[index:]
+}
+
+// parseArgs converts the tokens to the arguments of a command
+func parseArgs(command *Command, input string, tokens []token) (map[string]any, error) {
+	values := map[string]any{}
+
+	positional := []*Arg{}
+	for index := range command.Args {
+		if !command.Args[index].Flag {
+			positional = append(positional, &command.Args[index])
+		}
+	}
+
+	for index := 0; index < len(tokens); index++ {
+		tok := tokens[index]
+
+		// flags
+		if name, isFlag := strings.CutPrefix(tok.value, "--"); isFlag && !tok.quoted && name != "" {
+			name, value, hasValue := strings.Cut(name, "=")
+
+			arg := command.findArg(name)
+			if arg == nil || !arg.Flag {
+				return nil, fmt.Errorf("unknown flag --%s", name)
+			}
+
+			if !hasValue {
+				if arg.Type == ArgBool {
+					value = "true"
+				} else {
+					if index+1 >= len(tokens) {
+						return nil, fmt.Errorf("missing a value for --%s", name)
+					}
+					index++
+					value = tokens[index].value
+				}
+			}
+
+			converted, err := convertArg(arg, value)
+			if err != nil {
+				return nil, err
+			}
+			values[arg.Name] = converted
+			continue
+		}
+
+		if len(positional) == 0 {
+			return nil, fmt.Errorf("too many arguments, unexpected \"%s\"", tok.value)
+		}
+
+		arg := positional[0]
+		positional = positional[1:]
+
+		value := tok.value
+		if arg.Type == ArgText && index < len(tokens)-1 {
+			// keep the text as it was written
+			value = strings.TrimSpace(input[tok.start:])
+			index = len(tokens)
+		}
+
+		converted, err := convertArg(arg, value)
+		if err != nil {
+			return nil, err
+		}
+		values[arg.Name] = converted
+	}
+
+	for _, arg := range command.Args {
+		if _, ok := values[arg.Name]; !ok && arg.Required {
+			return nil, fmt.Errorf("missing the required argument \"%s\"", arg.Name)
+		}
+	}
+
+	return values, nil
+}
+
+// convertArg converts the text value of an argument to its type
+func convertArg(arg *Arg, value string) (any, error) {
+	if len(arg.Choices) > 0 && !slices.Contains(arg.Choices, value) {
+		return nil, fmt.Errorf("\"%s\" should be one of: %s", arg.Name, strings.Join(arg.Choices, ", "))
+	}
+
+	switch arg.Type {
+	case ArgInt:
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("\"%s\" should be a number", arg.Name)
+		}
+		return number, nil
+
+	case ArgBool:
+		switch strings.ToLower(value) {
+		case "true", "yes", "on", "1":
+			return true, nil
+		case "false", "no", "off", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("\"%s\" should be true or false", arg.Name)
+
+	case ArgUser:
+		if match := userMentionRegex.FindStringSubmatch(value); match != nil {
+			return match[1], nil
+		}
+		if idRegex.MatchString(value) {
+			return value, nil
+		}
+		return nil, fmt.Errorf("\"%s\" should be a user mention", arg.Name)
+
+	case ArgChannel:
+		if match := channelMentionRegex.FindStringSubmatch(value); match != nil {
+			return match[1], nil
+		}
+		if idRegex.MatchString(value) {
+			return value, nil
+		}
+		return nil, fmt.Errorf("\"%s\" should be a channel mention", arg.Name)
+	}
+
+	return value, nil
+}
@@ -1,70 +1,381 @@
 package dmsCommands
 
 import (
-	"discord-bot/common"
 	"discord-bot/discord/components"
 	"discord-bot/discord/events"
+	"discord-bot/discord/interaction"
 	"discord-bot/firebase"
 	"discord-bot/utils"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-var saveToListCommand = common.DmsCommand{
+var saveToListCommand = Command{
 	Name:        "list",
-	Subcommands: []string{saveToListSubcommands.Save, saveToListSubcommands.Get, saveToListSubcommands.Remove},
-	Handler:     saveToListHandler,
+	Description: "Save messages to the guild list or to your personal list",
+	Subcommands: []*Command{
+		{
+			Name:        "save",
+			Description: "Save the message you are replying to",
+			Args: []Arg{
+				{Name: "personal", Description: "Save it to your personal list", Type: ArgBool, Flag: true},
+				{Name: "tags", Description: "Tags to find it later, separated by spaces or commas", Type: ArgText},
+			},
+			Handler: saveSubCommand,
+		},
+		{
+			Name:        "get",
+			Aliases:     []string{"show", "search"},
+			Description: "Show the saved messages, optionally only the ones matching a search",
+			Args: []Arg{
+				{Name: "personal", Description: "Show your personal list", Type: ArgBool, Flag: true},
+				{Name: "tag", Description: "Only show the messages with this tag", Type: ArgString, Flag: true},
+				{Name: "page", Description: "The page to show", Type: ArgInt, Flag: true},
+				{Name: "search", Description: "Only show the messages containing this text", Type: ArgText},
+			},
+			Handler: getSubCommand,
+		},
+		{
+			Name:        "remove",
+			Aliases:     []string{"rm"},
+			Description: "Remove a saved message by its number, or select it from a menu",
+			Args: []Arg{
+				{Name: "personal", Description: "Remove from your personal list", Type: ArgBool, Flag: true},
+				{Name: "number", Description: "The number of the message shown by get", Type: ArgInt},
+			},
+			Handler: removeSubCommand,
+		},
+	},
 }
 
+// removeMenu is the payload of the remove menu, the short json names keep the custom ID small
+type removeMenu struct {
+	Personal bool   `json:"p"`
+	UserID   string `json:"u"` // the user who ran the command, only they can use the menu
+}
+
+var (
+	removeSelectRoute  = events.NewRoute[removeMenu]("saveToList_remove", 2)
+	savedListPageRoute = events.NewRoute[int]("saved_list_page", 1) // the payload is the page step, -1 or 1
+)
+
 func init() {
-	registerDmsCommands(&saveToListCommand)
-	events.RegisterComponentReactionEvent(saveListOnSelect)
+	registerCommand(&saveToListCommand)
+	events.HandleComponent(removeSelectRoute, saveListOnSelect)
+	events.HandleComponent(savedListPageRoute, savedListPageButton)
 }
 
-var saveToListSubcommands = struct {
-	Save   string
-	Get    string
-	Remove string
-}{"save", "get", "remove"}
+// messages per page of "list get"
+const savedListPageSize = 10
 
-func saveToListHandler(s *discordgo.Session, m *discordgo.MessageCreate, subcommand string, args *[]string) {
-	utils.Log.Debug(Log.Level.Info, "Command: list, subcommand:", subcommand, "GuildID:", m.GuildID, "ChannelID:", m.ChannelID, "UserID:", m.Author.ID, "UserName:", m.Author.Username, "Content:", m.Content)
+var (
+	// the searches of the "list get" messages, used by the page buttons, the key is the message ID
+	listSearches      = map[string]*listSearch{}
+	listSearchesMutex sync.Mutex
+)
 
-	// save
-	if subcommand == saveToListSubcommands.Save {
-		saveSubCommand(s, m)
-		return
+// discord doesn't allow interacting with old messages after a while anyway
+const listSearchTimeout = 15 * time.Minute
+
+type listSearch struct {
+	Personal bool
+	GuildID  string
+	UserID   string
+	Search   string
+	Tag      string
+	Page     int
+}
+
+// savedList is the guild list or the personal list of a user
+type savedList struct {
+	personal bool
+	ownerID  string // the guild ID, or the user ID for personal lists
+	items    *[]firebase.SavedItem
+}
+
+var (
+	// the lists are changed one at a time, the key is [user:userID] or [guild:guildID]
+	savedListLocks      = map[string]*sync.Mutex{}
+	savedListLocksMutex sync.Mutex
+)
+
+func getSavedList(guildID string, userID string, personal bool) (*savedList, error) {
+	if personal {
+		userData, err := firebase.GetUserData(userID)
+		if err != nil {
+			return nil, err
+		}
+		return &savedList{personal: true, ownerID: userID, items: &userData.SavedList}, nil
+	}
+
+	guildData, err := firebase.GetGuildData(guildID)
+	if err != nil {
+		return nil, err
+	}
+	return &savedList{personal: false, ownerID: guildID, items: &guildData.SavedList}, nil
+}
+
+// lock waits until the list can be read or changed, the returned function must be called when it's done
+func (l *savedList) lock() func() {
+	key := "guild:" + l.ownerID
+	if l.personal {
+		key = "user:" + l.ownerID
+	}
+
+	savedListLocksMutex.Lock()
+	lock, ok := savedListLocks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		savedListLocks[key] = lock
+	}
+	savedListLocksMutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// getItems returns the items of the list, the changes replace the slice so it can be read after the lock is released
+func (l *savedList) getItems() []firebase.SavedItem {
+	unlock := l.lock()
+	defer unlock()
+
+	return *l.items
+}
+
+// save uploads the list to firebase, the caller must hold the lock of the list
+func (l *savedList) save() error {
+	savedListMap := firebase.SavedListToMap(*l.items)
+	if l.personal {
+		return firebase.SetUserSavedList(l.ownerID, &savedListMap)
+	}
+	return firebase.SetSavedList(l.ownerID, &savedListMap)
+}
+
+func (l *savedList) name() string {
+	if l.personal {
+		return "your personal list"
+	}
+	return "the guild list"
+}
+
+// parseTags splits the tags on spaces and commas, they are saved in lower case without duplicates
+func parseTags(text string) []string {
+	tags := []string{}
+	for _, tag := range strings.FieldsFunc(text, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }) {
+		tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// messageLink returns the link to a message, guild ID is empty for DMs
+func messageLink(guildID string, channelID string, messageID string) string {
+	if guildID == "" {
+		guildID = "@me"
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID)
+}
+
+// newSavedItem converts a message to a saved item
+func newSavedItem(message *discordgo.Message, guildID string, savedBy string, tags []string) firebase.SavedItem {
+	item := firebase.SavedItem{
+		ID:          message.ID,
+		Content:     message.Content,
+		Link:        messageLink(guildID, message.ChannelID, message.ID),
+		Attachments: []string{},
+		Embeds:      []string{},
+		SavedBy:     savedBy,
+		SavedAt:     time.Now().Unix(),
+		Tags:        tags,
+	}
+
+	if message.Author != nil {
+		item.AuthorID = message.Author.ID
+		item.AuthorName = message.Author.Username
+	}
+
+	for _, attachment := range message.Attachments {
+		item.Attachments = append(item.Attachments, attachment.URL)
+	}
+
+	for _, embed := range message.Embeds {
+		text := strings.TrimSpace(embed.Title + "\n" + embed.Description)
+		if text != "" {
+			item.Embeds = append(item.Embeds, text)
+		}
 	}
 
-	// get
-	if subcommand == saveToListSubcommands.Get {
-		getSubCommand(s, m)
+	return item
+}
+
+// matches checks if an item matches the search text and the tag
+func (search *listSearch) matches(item firebase.SavedItem) bool {
+	if search.Tag != "" && !slices.Contains(item.Tags, strings.ToLower(strings.TrimPrefix(search.Tag, "#"))) {
+		return false
+	}
+
+	if search.Search == "" {
+		return true
+	}
+
+	text := strings.ToLower(search.Search)
+	return strings.Contains(strings.ToLower(item.Content), text) ||
+		strings.Contains(strings.ToLower(item.AuthorName), text) ||
+		strings.Contains(strings.ToLower(strings.Join(item.Embeds, "\n")), text) ||
+		slices.Contains(item.Tags, text)
+}
+
+// formatSavedItem formats an item of the "list get" message, number starts from 1
+func formatSavedItem(number int, item firebase.SavedItem) string {
+	content := item.Content
+	if content == "" && len(item.Embeds) > 0 {
+		content = item.Embeds[0]
+	}
+	content = utils.Truncate(strings.ReplaceAll(content, "\n", " "), 150)
+
+	details := []string{}
+	if item.AuthorName != "" {
+		details = append(details, "by "+item.AuthorName)
+	}
+	if item.SavedAt != 0 {
+		details = append(details, fmt.Sprintf("<t:%d:d>", item.SavedAt))
+	}
+	if len(item.Attachments) > 0 {
+		details = append(details, fmt.Sprintf("📎 %d", len(item.Attachments)))
+	}
+	if item.Link != "" {
+		details = append(details, "[jump]("+item.Link+")")
+	}
+
+	text := fmt.Sprintf("**#%d** %s\n", number, content)
+	if len(details) > 0 {
+		text += "┗ " + strings.Join(details, " · ")
+	}
+	if len(item.Tags) > 0 {
+		text += " `#" + strings.Join(item.Tags, "` `#") + "`"
+	}
+
+	return text + "\n"
+}
+
+// createSavedListPage returns the embed and the page buttons of a "list get" message
+func createSavedListPage(search *listSearch) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	list, err := getSavedList(search.GuildID, search.UserID, search.Personal)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines := []string{}
+	for index, item := range list.getItems() {
+		if search.matches(item) {
+			lines = append(lines, formatSavedItem(index+1, item))
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, nil, nil
+	}
+
+	pagesCount := (len(lines) + savedListPageSize - 1) / savedListPageSize
+	search.Page = max(0, min(search.Page, pagesCount-1))
+
+	start := search.Page * savedListPageSize
+	end := min(start+savedListPageSize, len(lines))
+
+	title := "Saved Messages"
+	if search.Personal {
+		title = "Your Saved Messages"
+	}
+
+	embed := components.NewEmbed().
+		SetTitle(title).
+		SetColor(0x0099ff).
+		SetDescription(strings.Join(lines[start:end], "\n")).
+		SetFooter(fmt.Sprintf("Page %d/%d · %d messages", search.Page+1, pagesCount, len(lines))).
+		Truncate().
+		Into()
+
+	buttons := []discordgo.MessageComponent{}
+	if pagesCount > 1 {
+		buttons = *components.AddMessageComponents(
+			components.NewRow(
+				components.NewButton().SetLabel("Previous Page").SetCustomID(savedListPageRoute.CustomID(-1)).SetStyleSecondary().SetDisabled(search.Page == 0),
+				components.NewButton().SetLabel("Next Page").SetCustomID(savedListPageRoute.CustomID(1)).SetStyleSecondary().SetDisabled(search.Page >= pagesCount-1),
+			),
+		)
+	}
+
+	return embed, buttons, nil
+}
+
+func savedListPageButton(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.MessageComponentInteractionData, step int) {
+	listSearchesMutex.Lock()
+	var search listSearch
+	saved, ok := listSearches[i.Message.ID]
+	if ok {
+		saved.Page += step
+		search = *saved
+	}
+	listSearchesMutex.Unlock()
+
+	if !ok {
+		events.RespondExpired(s, i)
 		return
 	}
 
-	// remove
-	if subcommand == saveToListSubcommands.Remove {
-		removeSubCommand(s, m)
+	embed, buttons, err := createSavedListPage(&search)
+
+	// keep the page in range
+	listSearchesMutex.Lock()
+	saved.Page = search.Page
+	listSearchesMutex.Unlock()
+	if err != nil || embed == nil {
+		if err == nil {
+			err = fmt.Errorf("no saved messages found")
+		}
+		sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while getting the saved messages:\n`%s`", err.Error()), true)
+		if sendError != nil {
+			Log.Error("\nSaveToList:", sendError.Error())
+			Log.Debug(Log.Level.Error, sendError.Error())
+		}
 		return
 	}
 
-	// not a valid subcommand
-	_, sendError := s.ChannelMessageSendReply(
-		m.ChannelID,
-		"You need to specify a subcommand: "+saveToListSubcommands.Save+", "+saveToListSubcommands.Get+", "+saveToListSubcommands.Remove,
-		m.Reference(),
-	)
+	sendError := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: buttons,
+		},
+	})
 	if sendError != nil {
 		Log.Error("\nSaveToList:", sendError.Error())
 		Log.Debug(Log.Level.Error, sendError.Error())
 	}
 }
 
-func saveListOnSelect(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.MessageComponentInteractionData) {
-	if data.CustomID != "saveToList_remove" {
+func saveListOnSelect(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.MessageComponentInteractionData, menu removeMenu) {
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+
+	// the menu is sent in the channel, the others would remove from their own list
+	if user.ID != menu.UserID {
+		sendError := interaction.RespondWithText(s, i, "❗ This menu is not yours, run the command to get your own.", true)
+		if sendError != nil {
+			Log.Error("\nSaveToList:", sendError.Error())
+			Log.Debug(Log.Level.Error, sendError.Error())
+		}
 		return
 	}
 
@@ -102,171 +413,200 @@ func saveListOnSelect(s *discordgo.Session, i *discordgo.InteractionCreate, data
 		return
 	}
 
-	guildData, err := firebase.GetGuildData(i.GuildID)
+	list, err := getSavedList(interactionGuildID(i), user.ID, menu.Personal)
 	if err != nil { // shouldn't happen at this point
 		Log.Error("\nSaveToList:", err.Error())
 		Log.Debug(Log.Level.Error, err.Error())
 		return
 	}
 
-	// check if index is valid
-	if messageIndex < 0 || messageIndex >= len(guildData.SavedList) {
-		_, sendError := s.ChannelMessageSend(i.ChannelID, "❗ Invalid message index.")
+	reply := func(text string) {
+		_, sendError := s.ChannelMessageSend(i.ChannelID, text)
 		if sendError != nil {
 			Log.Error("\nSaveToList:", sendError.Error())
 			Log.Debug(Log.Level.Error, sendError.Error())
 		}
-		return
 	}
 
-	// get the message
-	message := guildData.SavedList[messageIndex]
+	reply(removeSavedItem(list, messageIndex))
+}
+
+// addSavedItem adds an item to a list and returns the reply
+func addSavedItem(list *savedList, item firebase.SavedItem) string {
+	if item.Content == "" && len(item.Attachments) == 0 && len(item.Embeds) == 0 {
+		return "❗ This message has nothing to save."
+	}
+
+	unlock := list.lock()
+	defer unlock()
+
+	// already saved
+	if firebase.SavedListIndexOf(*list.items, item) != -1 {
+		return "❗ Message already saved."
+	}
 
-	// remove it from guild data
-	guildData.SavedListRemoveItem(message)
+	previousItems := *list.items
+	*list.items = append(append([]firebase.SavedItem{}, previousItems...), item)
+
+	// upload new saved list data to firebase
+	err := list.save()
+	if err != nil {
+		// remove the message from the list on error
+		*list.items = previousItems
+
+		Log.Error("\nSaveToList:", err.Error())
+		Log.Debug(Log.Level.Error, err.Error())
+		return "❗ Something went wrong while saving the message."
+	}
+
+	return fmt.Sprintf("✅ Message saved to %s.", list.name())
+}
+
+// removeSavedItem removes an item from a list and returns the reply
+func removeSavedItem(list *savedList, index int) string {
+	unlock := list.lock()
+	defer unlock()
+
+	// check if index is valid
+	if index < 0 || index >= len(*list.items) {
+		return "❗ Invalid message number."
+	}
+
+	previousItems := *list.items
+	*list.items = append(append([]firebase.SavedItem{}, previousItems[:index]...), previousItems[index+1:]...)
 
 	// save it to firebase
-	err = firebase.SetSavedList(i.GuildID, guildData.SavedList)
+	err := list.save()
 	if err != nil {
 		// re-add the message on error
-		guildData.SavedListAddItem(message)
+		*list.items = previousItems
 
-		_, sendError := s.ChannelMessageSend(i.ChannelID, "❗ Something went wrong while removing the message.")
-		if sendError != nil {
-			Log.Error("\nSaveToList:", sendError.Error())
-			Log.Debug(Log.Level.Error, sendError.Error())
-		}
 		Log.Error("\nSaveToList:", err.Error())
-		Log.Debug(Log.Level.Error, sendError.Error())
-		return
-	}
-	
-	_, sendError = s.ChannelMessageSend(i.ChannelID, "✅ Message removed.")
-	if sendError != nil {
-		Log.Error("\nSaveToList:", sendError.Error())
-		Log.Debug(Log.Level.Error, sendError.Error())
+		Log.Debug(Log.Level.Error, err.Error())
+		return "❗ Something went wrong while removing the message."
 	}
+
+	return "✅ Message removed."
 }
 
 // * Subcommands
 
-func saveSubCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+func saveSubCommand(ctx *Context) {
+	s, m := ctx.Session, ctx.Message
+
 	// check if the message is a reply
-	if m.Message.Type != discordgo.MessageTypeReply {
-		_, sendError := s.ChannelMessageSendReply(m.ChannelID, "Please reference a message to save by replying to it.", m.Reference())
-		if sendError != nil {
-			Log.Error("\nSaveToList:", sendError.Error())
-			Log.Debug(Log.Level.Error, sendError.Error())
-		}
+	if m.Message.Type != discordgo.MessageTypeReply || m.MessageReference == nil {
+		ctx.Reply("Please reference a message to save by replying to it.")
 		return
 	}
 
 	// get the replied message
 	repliedMessage, err := s.ChannelMessage(m.ChannelID, m.MessageReference.MessageID)
 	if err != nil {
-		_, sendError := s.ChannelMessageSendReply(m.ChannelID, "❗ Something went wrong while getting the message to save.", m.Reference())
-		if sendError != nil {
-			Log.Error("\nSaveToList:", sendError.Error())
-			Log.Debug(Log.Level.Error, sendError.Error())
-		}
+		ctx.Reply("❗ Something went wrong while getting the message to save.")
 		Log.Error("\nSaveToList:", err.Error())
 		Log.Debug(Log.Level.Error, err.Error())
 		return
 	}
 
-	// save the message
-	guildData, err := firebase.GetGuildData(m.GuildID)
+	list, err := getSavedList(ctx.GuildID, ctx.Author.ID, ctx.Bool("personal"))
 	if err != nil { // shouldn't happen at this point
 		Log.Error("\nSaveToList:", err.Error())
 		Log.Debug(Log.Level.Error, err.Error())
 		return
 	}
 
-	// already saved
-	isExist := guildData.SavedListIsInList(repliedMessage.Content)
-	if isExist {
-		_, sendError := s.ChannelMessageSendReply(m.ChannelID, "❗ Message already saved.", m.Reference())
-		if sendError != nil {
-			Log.Error("\nSaveToList:", sendError.Error())
-			Log.Debug(Log.Level.Error, sendError.Error())
-		}
-		return
-	}
-
-	// add to guild data
-	guildData.SavedListAddItem(repliedMessage.Content)
+	item := newSavedItem(repliedMessage, m.GuildID, ctx.Author.ID, parseTags(ctx.String("tags")))
+	ctx.Reply(addSavedItem(list, item))
+}
 
-	// upload new saved list data to firebase
-	err = firebase.SetSavedList(m.GuildID, guildData.SavedList)
-	if err != nil {
-		// remove the message from guild data on error
-		guildData.SavedListRemoveItem(repliedMessage.Content)
+func getSubCommand(ctx *Context) {
+	s, m := ctx.Session, ctx.Message
 
-		_, sendError := s.ChannelMessageSendReply(m.ChannelID, "❗ Something went wrong while saving the message.", m.Reference())
-		if sendError != nil {
-			Log.Error("\nSaveToList:", sendError.Error())
-			Log.Debug(Log.Level.Error, sendError.Error())
-		}
-		Log.Error("\nSaveToList:", err.Error())
-		Log.Debug(Log.Level.Error, err.Error())
-		return
-	}
-
-	_, sendError := s.ChannelMessageSendReply(m.ChannelID, "✅ Message saved.", m.Reference())
-	if sendError != nil {
-		Log.Error("\nSaveToList:", sendError.Error())
-		Log.Debug(Log.Level.Error, sendError.Error())
+	search := &listSearch{
+		Personal: ctx.Bool("personal"),
+		GuildID:  ctx.GuildID,
+		UserID:   ctx.Author.ID,
+		Search:   ctx.String("search"),
+		Tag:      ctx.String("tag"),
+		Page:     int(ctx.Int("page")) - 1,
 	}
-}
 
-func getSubCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	guildData, err := firebase.GetGuildData(m.GuildID)
+	embed, buttons, err := createSavedListPage(search)
 	if err != nil { // shouldn't happen at this point
 		Log.Error("\nSaveToList:", err.Error())
 		Log.Debug(Log.Level.Error, err.Error())
 		return
 	}
 
-	if len(guildData.SavedList) == 0 {
-		_, sendError := s.ChannelMessageSendReply(m.ChannelID, "❗ No saved messages found.", m.Reference())
-		if sendError != nil {
-			Log.Error("\nSaveToList:", sendError.Error())
-			Log.Debug(Log.Level.Error, sendError.Error())
-		}
+	if embed == nil {
+		ctx.Reply("❗ No saved messages found.")
 		return
 	}
 
-	// print the saved messages
-	_, sendError := s.ChannelMessageSendReply(m.ChannelID,
-		fmt.Sprint("Saved Messages:\n🔹  ", strings.Join(guildData.SavedList, "\n🔹  ")),
-		m.Reference(),
-	)
+	message, sendError := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: buttons,
+		Reference:  m.Reference(),
+	})
 	if sendError != nil {
 		Log.Error("\nSaveToList:", sendError.Error())
 		Log.Debug(Log.Level.Error, sendError.Error())
+		return
+	}
+
+	if len(buttons) == 0 {
+		return
 	}
+
+	listSearchesMutex.Lock()
+	listSearches[message.ID] = search
+	listSearchesMutex.Unlock()
+
+	go func() {
+		defer events.RecoverEvent("list:searchExpiry")
+
+		if !events.Sleep(listSearchTimeout) {
+			return
+		}
+		listSearchesMutex.Lock()
+		delete(listSearches, message.ID)
+		listSearchesMutex.Unlock()
+	}()
 }
 
-func removeSubCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	guildData, err := firebase.GetGuildData(m.GuildID)
+func removeSubCommand(ctx *Context) {
+	s, m := ctx.Session, ctx.Message
+
+	list, err := getSavedList(ctx.GuildID, ctx.Author.ID, ctx.Bool("personal"))
 	if err != nil { // shouldn't happen at this point
 		Log.Error("\nSaveToList:", err.Error())
 		Log.Debug(Log.Level.Error, err.Error())
 		return
 	}
 
-	if len(guildData.SavedList) == 0 {
-		_, sendError := s.ChannelMessageSendReply(m.ChannelID, "❗ No saved messages found.", m.Reference())
-		if sendError != nil {
-			Log.Error("\nSaveToList:", sendError.Error())
-			Log.Debug(Log.Level.Error, sendError.Error())
-		}
+	items := list.getItems()
+	if len(items) == 0 {
+		ctx.Reply("❗ No saved messages found.")
+		return
+	}
+
+	if ctx.Has("number") {
+		ctx.Reply(removeSavedItem(list, int(ctx.Int("number"))-1))
 		return
 	}
 
 	menuOptions := []*components.SelectMenuOption{}
-	for i, message := range guildData.SavedList {
-		menuOptions = append(menuOptions, components.NewMenuOption().SetLabel(message).SetValue(fmt.Sprintf("%d", i)))
+	for i, item := range items {
+		label := fmt.Sprintf("#%d %s", i+1, item.Content)
+		if item.Content == "" && len(item.Attachments) > 0 {
+			label = fmt.Sprintf("#%d 📎 %d attachments", i+1, len(item.Attachments))
+		}
+
+		// ! max 100 characters
+		label = utils.Truncate(label, 100)
+
+		menuOptions = append(menuOptions, components.NewMenuOption().SetLabel(label).SetValue(fmt.Sprintf("%d", i)))
 
 		// ! max 25 options
 		if i >= 24 {
@@ -274,11 +614,16 @@ func removeSubCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 		}
 	}
 
+	content := "Select a message to remove"
+	if len(items) > 25 {
+		content += fmt.Sprintf(", only the first 25 are shown, use `%slist remove <number>` for the others", ctx.Prefix)
+	}
+
 	_, sendError := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
-		Content: "Select a message to remove",
+		Content: content,
 		Components: *components.AddMessageComponents(
 			components.NewRow(
-				components.NewSelectMenu().SetCustomID("saveToList_remove").SetPlaceholder("Select a message to remove").SetOptions(menuOptions...),
+				components.NewSelectMenu().SetCustomID(removeSelectRoute.CustomID(removeMenu{Personal: list.personal, UserID: ctx.Author.ID})).SetPlaceholder("Select a message to remove").SetOptions(menuOptions...),
 			),
 		),
 	})
@@ -0,0 +1,70 @@
+package dmsCommands
+
+import (
+	"discord-bot/common"
+	"discord-bot/discord/events"
+	"discord-bot/discord/interaction"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// message context menus, shown under "Apps" when right clicking a message
+var saveToListMenu = common.SlashCommand{
+	Command: discordgo.ApplicationCommand{
+		Name: "Save to list",
+		Type: discordgo.MessageApplicationCommand,
+	},
+	Handler: func(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData) {
+		saveToListMenuHandler(s, i, appData, false)
+	},
+}
+
+var saveToMyListMenu = common.SlashCommand{
+	Command: discordgo.ApplicationCommand{
+		Name: "Save to my list",
+		Type: discordgo.MessageApplicationCommand,
+	},
+	Handler: func(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData) {
+		saveToListMenuHandler(s, i, appData, true)
+	},
+}
+
+func init() {
+	events.RegisterSlashCommand(&saveToListMenu)
+	events.RegisterSlashCommand(&saveToMyListMenu)
+}
+
+func saveToListMenuHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData, personal bool) {
+	respond := func(text string) {
+		sendError := interaction.RespondWithText(s, i, text, true)
+		if sendError != nil {
+			Log.Error("\nSaveToList:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "Save to list" command:`, sendError.Error())
+		}
+	}
+
+	message, ok := appData.Resolved.Messages[appData.TargetID]
+	if !ok {
+		respond("❗ Something went wrong while getting the message to save.")
+		return
+	}
+
+	user := i.Member.User
+
+	Log.Debug(Log.Level.Info, "Command:", appData.Name, "GuildID:", i.GuildID, "ChannelID:", i.ChannelID, "UserID:", user.ID, "UserName:", user.Username)
+
+	list, err := getSavedList(i.GuildID, user.ID, personal)
+	if err != nil {
+		Log.Error("\nSaveToList:", err.Error())
+		Log.Debug(Log.Level.Error, err.Error())
+		respond("❗ Something went wrong while getting the saved list.")
+		return
+	}
+
+	// the resolved message doesn't have the channel ID in some cases
+	if message.ChannelID == "" {
+		message.ChannelID = i.ChannelID
+	}
+
+	respond(addSavedItem(list, newSavedItem(message, i.GuildID, user.ID, []string{})))
+}
@@ -0,0 +1,131 @@
+package dmsCommands
+
+import (
+	"discord-bot/common"
+	"discord-bot/discord/events"
+	"discord-bot/firebase"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var argOptionTypes = map[ArgType]discordgo.ApplicationCommandOptionType{
+	ArgString:  discordgo.ApplicationCommandOptionString,
+	ArgText:    discordgo.ApplicationCommandOptionString,
+	ArgInt:     discordgo.ApplicationCommandOptionInteger,
+	ArgBool:    discordgo.ApplicationCommandOptionBoolean,
+	ArgUser:    discordgo.ApplicationCommandOptionUser,
+	ArgChannel: discordgo.ApplicationCommandOptionChannel,
+}
+
+func registerSlashCommand(c *Command) {
+	events.RegisterSlashCommand(&common.SlashCommand{
+		Command:  c.ApplicationCommand(),
+		Feature:  c.Feature,
+		Cooldown: c.Cooldown,
+		Handler: func(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData) {
+			executeSlashCommand(c, s, i, appData)
+		},
+	})
+}
+
+// ApplicationCommand converts the command to a slash command
+func (c *Command) ApplicationCommand() discordgo.ApplicationCommand {
+	options := argsToOptions(c.Args)
+	for _, subcommand := range c.Subcommands {
+		options = append(options, &discordgo.ApplicationCommandOption{
+			Name:        subcommand.Name,
+			Description: subcommand.Description,
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options:     argsToOptions(subcommand.Args),
+		})
+	}
+
+	return discordgo.ApplicationCommand{
+		Name:        c.Name,
+		Description: c.Description,
+		Options:     options,
+	}
+}
+
+func argsToOptions(args []Arg) []*discordgo.ApplicationCommandOption {
+	options := []*discordgo.ApplicationCommandOption{}
+	for _, arg := range args {
+		option := &discordgo.ApplicationCommandOption{
+			Name:        arg.Name,
+			Description: arg.Description,
+			Type:        argOptionTypes[arg.Type],
+			Required:    arg.Required,
+		}
+		for _, choice := range arg.Choices {
+			option.Choices = append(option.Choices, &discordgo.ApplicationCommandOptionChoice{Name: choice, Value: choice})
+		}
+		options = append(options, option)
+	}
+
+	// discord wants the required options first
+	slices.SortStableFunc(options, func(a, b *discordgo.ApplicationCommandOption) int {
+		if a.Required == b.Required {
+			return 0
+		}
+		if a.Required {
+			return -1
+		}
+		return 1
+	})
+
+	return options
+}
+
+func executeSlashCommand(c *Command, s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData) {
+	ctx := &Context{
+		Session:     s,
+		Interaction: i,
+		GuildID:     i.GuildID,
+		ChannelID:   i.ChannelID,
+		Command:     c,
+		Prefix:      "!",
+		values:      map[string]any{},
+	}
+
+	if i.Member != nil {
+		ctx.Author = i.Member.User
+	} else {
+		ctx.Author = i.User
+	}
+
+	if guildData, err := firebase.GetGuildData(i.GuildID); err == nil {
+		ctx.Prefix = guildData.Prefix
+	}
+
+	options := appData.Options
+	if len(options) > 0 && options[0].Type == discordgo.ApplicationCommandOptionSubCommand {
+		subcommand := c.findSubcommand(options[0].Name)
+		if subcommand == nil {
+			return
+		}
+		ctx.Parents = []*Command{c}
+		ctx.Command = subcommand
+		options = options[0].Options
+	}
+
+	for _, option := range options {
+		arg := ctx.Command.findArg(option.Name)
+		if arg == nil {
+			continue
+		}
+
+		switch arg.Type {
+		case ArgInt:
+			ctx.values[arg.Name] = option.IntValue()
+		case ArgBool:
+			ctx.values[arg.Name] = option.BoolValue()
+		default: // strings, user and channel IDs
+			ctx.values[arg.Name], _ = option.Value.(string)
+		}
+	}
+
+	Log.Debug(Log.Level.Info, "Command:", c.Name, "subcommand:", ctx.Command.Name, "GuildID:", ctx.GuildID, "ChannelID:", ctx.ChannelID, "UserID:", ctx.Author.ID, "UserName:", ctx.Author.Username)
+
+	ctx.Command.Handler(ctx)
+}
@@ -0,0 +1,56 @@
+package dmsCommands
+
+import (
+	"discord-bot/common"
+	torrentCommand "discord-bot/discord/slashCommands/torrent"
+	"discord-bot/torrentClient"
+	"fmt"
+)
+
+var torrentCommandDms = Command{
+	Name:        "torrent",
+	Description: "Download a torrent, also used when a `.torrent` file is dropped with no subcommand",
+	Feature:     common.FeatureTorrent,
+	Handler:     torrentHandler,
+	Subcommands: []*Command{
+		{
+			Name:        "add",
+			Description: "Download the attached `.torrent` files",
+			Handler:     torrentHandler,
+		},
+		{
+			Name:        "status",
+			Aliases:     []string{"mine"},
+			Description: "Show the status of the torrents you added",
+			Handler:     torrentStatusHandler,
+		},
+	},
+}
+
+func init() {
+	registerCommand(&torrentCommandDms)
+}
+
+func torrentHandler(ctx *Context) {
+	if len(ctx.Message.Attachments) == 0 {
+		ctx.ReplyUsage(fmt.Errorf("please attach a `.torrent` file"))
+		return
+	}
+
+	torrentCommand.AddTorrentFileFromMessage(ctx.Session, ctx.Message)
+}
+
+func torrentStatusHandler(ctx *Context) {
+	torrents := torrentClient.FilterTorrentsByOwner(ctx.Author.ID)
+	if len(torrents) == 0 {
+		ctx.Reply("❗ You didn't add any torrents.")
+		return
+	}
+
+	text := "Your torrents:\n"
+	for _, tor := range torrents {
+		text += fmt.Sprintf("🔹 **%s** - %s `%.2f%%`\n", tor.Name(), torrentClient.GetTorrentState(tor), torrentClient.GetProgress(tor))
+	}
+
+	ctx.Reply(text)
+}
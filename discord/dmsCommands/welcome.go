@@ -0,0 +1,158 @@
+package dmsCommands
+
+import (
+	"discord-bot/discord/audit"
+	"discord-bot/discord/slashCommands/welcomeVoiceMessage"
+	"discord-bot/firebase"
+	"fmt"
+	"strings"
+)
+
+var welcomeCommand = Command{
+	Name:        "welcome",
+	Description: "Manage your welcome voice message",
+	Subcommands: []*Command{
+		{
+			Name:        "get",
+			Aliases:     []string{"show"},
+			Description: "Show your welcome message",
+			Handler:     welcomeGetHandler,
+		},
+		{
+			Name:        "set",
+			Description: "Set your welcome message",
+			Args: []Arg{
+				{Name: "message", Description: "The message to say when you join a voice channel", Type: ArgText, Required: true},
+				{Name: "lang", Description: "The language code, e.g. en, de, ar", Type: ArgString, Flag: true},
+			},
+			Handler: welcomeSetHandler,
+		},
+		{
+			Name:        "remove",
+			Aliases:     []string{"rm"},
+			Description: "Remove your welcome message",
+			Handler:     welcomeRemoveHandler,
+		},
+	},
+}
+
+func init() {
+	registerCommand(&welcomeCommand)
+}
+
+func welcomeGetHandler(ctx *Context) {
+	guildData, err := firebase.GetGuildData(ctx.GuildID)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `getting guild firebase data for "welcome" command:`, err.Error())
+		ctx.Reply(fmt.Sprintf("**Error:** while getting the guild data from firebase:\n`%s`", err.Error()))
+		return
+	}
+
+	item, err := guildData.VoiceMessagesGetItem(ctx.Author.ID)
+	if err != nil {
+		ctx.Reply(fmt.Sprintf("❗ You don't have a welcome message, use `%swelcome set <message>` to set one.", ctx.Prefix))
+		return
+	}
+
+	ctx.Reply(fmt.Sprintf("Your welcome message _(%s)_:\n%s", item.Lang, item.Message))
+}
+
+func welcomeSetHandler(ctx *Context) {
+	newItem := firebase.VoiceWelcomeMessage{
+		Id:      ctx.Author.ID,
+		Message: ctx.String("message"),
+		Lang:    strings.ToLower(ctx.String("lang")),
+	}
+
+	if newItem.Lang == "" {
+		newItem.Lang = "en"
+	}
+
+	err := welcomeVoiceMessage.ValidateMessage(newItem)
+	if err != nil {
+		ctx.ReplyUsage(err)
+		return
+	}
+
+	guildData, err := firebase.GetGuildData(ctx.GuildID)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `getting guild firebase data for "welcome" command:`, err.Error())
+		ctx.Reply(fmt.Sprintf("**Error:** while getting the guild data from firebase:\n`%s`", err.Error()))
+		return
+	}
+
+	currentItem, notFoundErr := guildData.VoiceMessagesGetItem(newItem.Id)
+	exists := notFoundErr == nil
+	if exists {
+		guildData.VoiceMessagesUpdateItem(newItem)
+	} else {
+		guildData.VoiceMessagesAddItem(newItem)
+	}
+
+	voiceMessagesMap := guildData.VoiceMessagesToMap()
+	err = firebase.SetVoiceMessages(ctx.GuildID, &voiceMessagesMap)
+	if err != nil {
+		// revoke changes on error
+		if exists {
+			guildData.VoiceMessagesUpdateItem(*currentItem)
+		} else {
+			guildData.VoiceMessagesRemoveItem(newItem.Id)
+		}
+
+		Log.Debug(Log.Level.Error, `uploading "welcome (set)" data to firebase:`, err.Error())
+		ctx.Reply(fmt.Sprintf("**Error:** while uploading **welcome (set)** data to firebase:\n`%s`", err.Error()))
+		return
+	}
+
+	ctx.Reply("✅ Welcome message saved.")
+
+	change := audit.Change{
+		GuildID: ctx.GuildID,
+		UserID:  ctx.Author.ID,
+		Action:  audit.ActionWelcomeSet,
+		Target:  "<@" + ctx.Author.ID + ">",
+		After:   welcomeVoiceMessage.DescribeMessage(newItem),
+	}
+	if exists {
+		change.Before = welcomeVoiceMessage.DescribeMessage(*currentItem)
+	}
+	audit.Record(ctx.Session, change)
+}
+
+func welcomeRemoveHandler(ctx *Context) {
+	guildData, err := firebase.GetGuildData(ctx.GuildID)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `getting guild firebase data for "welcome" command:`, err.Error())
+		ctx.Reply(fmt.Sprintf("**Error:** while getting the guild data from firebase:\n`%s`", err.Error()))
+		return
+	}
+
+	currentItem, err := guildData.VoiceMessagesGetItem(ctx.Author.ID)
+	if err != nil {
+		ctx.Reply("❗ You don't have a welcome message.")
+		return
+	}
+
+	guildData.VoiceMessagesRemoveItem(currentItem.Id)
+
+	voiceMessagesMap := guildData.VoiceMessagesToMap()
+	err = firebase.SetVoiceMessages(ctx.GuildID, &voiceMessagesMap)
+	if err != nil {
+		// revoke changes on error
+		guildData.VoiceMessagesAddItem(*currentItem)
+
+		Log.Debug(Log.Level.Error, `uploading "welcome (remove)" data to firebase:`, err.Error())
+		ctx.Reply(fmt.Sprintf("**Error:** while uploading **welcome (remove)** data to firebase:\n`%s`", err.Error()))
+		return
+	}
+
+	ctx.Reply("✅ Welcome message removed.")
+
+	audit.Record(ctx.Session, audit.Change{
+		GuildID: ctx.GuildID,
+		UserID:  ctx.Author.ID,
+		Action:  audit.ActionWelcomeRemove,
+		Target:  "<@" + ctx.Author.ID + ">",
+		Before:  welcomeVoiceMessage.DescribeMessage(*currentItem),
+	})
+}
@@ -0,0 +1,33 @@
+package events
+
+import (
+	"context"
+	"time"
+)
+
+// the context of the Discord session, canceled when the bot is shutting down
+var ctx = context.Background()
+
+// SetContext sets the context used by the background work of the handlers
+func SetContext(c context.Context) {
+	ctx = c
+}
+
+// Context returns a context that is canceled when the bot is shutting down, background work like the progress
+// loops and the cleanup timers of the handlers should stop with it
+func Context() context.Context {
+	return ctx
+}
+
+// Sleep waits for the duration, it returns false if the bot started shutting down before it ended
+func Sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
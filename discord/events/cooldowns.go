@@ -0,0 +1,56 @@
+package events
+
+import (
+	"discord-bot/discord/interaction"
+	"discord-bot/firebase"
+	"discord-bot/ratelimit"
+	"discord-bot/utils"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// CommandCooldown returns the cooldown of a command in a guild, the guild can override the default cooldown
+func CommandCooldown(guildID string, command string, defaultCooldown time.Duration) time.Duration {
+	if guildID == "" {
+		return defaultCooldown
+	}
+
+	guildData, err := firebase.GetGuildData(guildID)
+	if err != nil {
+		return defaultCooldown
+	}
+
+	if seconds, ok := guildData.Cooldowns[command]; ok {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultCooldown
+}
+
+// TryAgainText is the response of the commands that are used too often
+func TryAgainText(wait time.Duration) string {
+	return fmt.Sprintf("⏳ Slow down, try again in %s.", ratelimit.FormatWait(wait))
+}
+
+// allowCommand checks the cooldown of the user and the rate limit of the guild, it responds when it's not allowed
+func allowCommand(s *discordgo.Session, i *discordgo.InteractionCreate, command string, cooldown time.Duration) bool {
+	userID := ""
+	if i.Member != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	}
+
+	wait, ok := ratelimit.Allow(i.GuildID, userID, command, CommandCooldown(i.GuildID, command, cooldown))
+	if ok {
+		return true
+	}
+
+	err := interaction.RespondWithText(s, i, TryAgainText(wait), true)
+	if err != nil {
+		utils.Log.Error("\nSlashCommand:", err.Error())
+		utils.Log.Debug(utils.Log.Level.Error, "sending the rate limited respond:", err.Error())
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"crypto/rand"
+	"discord-bot/metrics"
+	"discord-bot/utils"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// newErrorID returns a short random ID, it's shown to the user and logged with the error so they can be matched
+func newErrorID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RespondError logs the error with a new error ID and responds to the interaction with it ephemerally, action is
+// what failed like "getting the guild data", it works before and after the interaction is acknowledged
+func RespondError(s *discordgo.Session, i *discordgo.InteractionCreate, action string, err error) string {
+	errorID := newErrorID()
+
+	utils.Log.Error("\n"+action+":", err.Error(), "(error ID: "+errorID+")")
+	utils.Log.With(utils.FieldErrorID, errorID, utils.FieldGuild, i.GuildID, utils.FieldChannel, i.ChannelID).
+		Error(action, utils.FieldError, err.Error())
+
+	respondWithErrorID(s, i, fmt.Sprintf("**Error:** while %s:\n`%s`", action, err.Error()), errorID)
+	return errorID
+}
+
+// respondWithErrorID sends an ephemeral response, or a followup if the interaction is already acknowledged
+func respondWithErrorID(s *discordgo.Session, i *discordgo.InteractionCreate, text string, errorID string) {
+	text += fmt.Sprintf("\n-# Error ID: `%s`", errorID)
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: text,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err == nil {
+		return
+	}
+
+	// after a deferred response the first followup replaces the "thinking" message
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: text,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		utils.Log.Error("\nRespondError:", err.Error())
+		utils.Log.Debug(utils.Log.Level.Error, "sending the error respond:", err.Error(), "error ID:", errorID)
+	}
+}
+
+// logPanic logs a recovered panic with its stack trace and returns its error ID
+func logPanic(handler string, recovered any) string {
+	errorID := newErrorID()
+	metrics.HandlerPanic(handler)
+
+	utils.Log.Error("\nPanic in "+handler+":", fmt.Sprint(recovered), "(error ID: "+errorID+")")
+	utils.Log.With(utils.FieldErrorID, errorID).
+		Error("handler panic", "handler", handler, utils.FieldError, fmt.Sprint(recovered), "stack", string(debug.Stack()))
+
+	return errorID
+}
+
+// recoverInteraction is deferred by the interaction handlers, it replies to the interaction with the error ID
+func recoverInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, handler string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	errorID := logPanic(handler, recovered)
+	respondWithErrorID(s, i, "**Error:** something went wrong.", errorID)
+}
+
+// recoverMessage is deferred by the message handlers, it replies to the message with the error ID
+func recoverMessage(s *discordgo.Session, m *discordgo.MessageCreate, handler string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	errorID := logPanic(handler, recovered)
+	_, err := s.ChannelMessageSendReply(m.ChannelID, fmt.Sprintf("❗ Something went wrong.\n-# Error ID: `%s`", errorID), m.Reference())
+	if err != nil {
+		utils.Log.Error("\nOnDM:", err.Error())
+		utils.Log.Debug(utils.Log.Level.Error, "sending the error reply:", err.Error(), "error ID:", errorID)
+	}
+}
+
+// RecoverEvent is deferred by the handlers that have no one to reply to, like the voice state updates and the
+// background work started by the handlers
+func RecoverEvent(handler string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	logPanic(handler, recovered)
+}
@@ -2,36 +2,28 @@ package events
 
 import (
 	"discord-bot/common"
+	"discord-bot/discord/interaction"
 	"discord-bot/utils"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 type (
-	OnComponentReactionEvent = func(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.MessageComponentInteractionData)
-	OnModalReactionEvent     = func(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.ModalSubmitInteractionData)
-	OnReadyEvent             = func(s *discordgo.Session, e *discordgo.Ready)
-	OnDmMessageEvent         = func(s *discordgo.Session, m *discordgo.MessageCreate)
-	OnSlashCommandEvent      = func(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.ApplicationCommandInteractionData)
-	OnVoiceStateUpdateEvent  = func(s *discordgo.Session, v *discordgo.VoiceStateUpdate, state common.VoiceState)
+	OnReadyEvent            = func(s *discordgo.Session, e *discordgo.Ready)
+	OnDmMessageEvent        = func(s *discordgo.Session, m *discordgo.MessageCreate)
+	OnSlashCommandEvent     = func(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.ApplicationCommandInteractionData)
+	OnVoiceStateUpdateEvent = func(s *discordgo.Session, v *discordgo.VoiceStateUpdate, state common.VoiceState)
 )
 
 var (
-	OnComponentReactionEvents []OnComponentReactionEvent
-	OnModalReactionEvents     []OnModalReactionEvent
-	OnReadyEvents             []OnReadyEvent
-	OnDmMessageEvents         []OnDmMessageEvent
-	OnSlashCommandEvents      []OnSlashCommandEvent
-	OnVoiceStateUpdateEvents  []OnVoiceStateUpdateEvent
+	OnReadyEvents            []OnReadyEvent
+	OnDmMessageEvents        []OnDmMessageEvent
+	OnSlashCommandEvents     []OnSlashCommandEvent
+	OnVoiceStateUpdateEvents []OnVoiceStateUpdateEvent
 )
 
 // * Register events
-func RegisterComponentReactionEvent(event OnComponentReactionEvent) {
-	OnComponentReactionEvents = append(OnComponentReactionEvents, event)
-}
-func RegisterModalReactionEvent(event OnModalReactionEvent) {
-	OnModalReactionEvents = append(OnModalReactionEvents, event)
-}
+// the components and the modals are registered with HandleComponent and HandleModal
 func RegisterOnReadyEvent(event OnReadyEvent) {
 	OnReadyEvents = append(OnReadyEvents, event)
 }
@@ -52,36 +44,47 @@ func OnReady(s *discordgo.Session, e *discordgo.Ready) {
 	utils.Log.Success("\nBot is up and running!")
 
 	for _, event := range OnReadyEvents {
-		event(s, e)
+		func() {
+			defer RecoverEvent("ready")
+			event(s, e)
+		}()
 	}
 }
 
-// OnDM is called when the bot receives a DM
+// OnDM is called when the bot receives a message, in a guild or in a DM
 func OnDM(s *discordgo.Session, m *discordgo.MessageCreate) {
 	if m.Author.Bot {
 		return
 	}
 
-	if m.GuildID == "" {
-		return
-	}
-
 	for _, event := range OnDmMessageEvents {
-		event(s, m)
+		func() {
+			defer recoverMessage(s, m, "message")
+			event(s, m)
+		}()
 	}
 }
 
 // OnInteraction is called when the bot receives an interaction
 func OnInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if i.GuildID == "" {
-		return
-	}
-
 	// Commands
 	if i.Type == discordgo.InteractionApplicationCommand {
+		// the commands in DMs are the prefix commands, they use the server selected by the user
+		if i.GuildID == "" {
+			respondGuildOnly(s, i)
+			return
+		}
+
 		data := i.ApplicationCommandData()
+		user := utils.GetInteractionAuthor(i.Interaction)
+		utils.Log.With(utils.FieldGuild, i.GuildID, utils.FieldChannel, i.ChannelID, utils.FieldUser, user.ID, utils.FieldCommand, data.Name).
+			Info("application command")
+
 		for _, event := range OnSlashCommandEvents {
-			event(s, i, &data)
+			func() {
+				defer recoverInteraction(s, i, "slash:"+data.Name)
+				event(s, i, &data)
+			}()
 		}
 		return
 	}
@@ -89,18 +92,23 @@ func OnInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	// Component Interactions
 	if i.Type == discordgo.InteractionMessageComponent {
 		data := i.MessageComponentData()
-		for _, event := range OnComponentReactionEvents {
-			event(s, i, &data)
-		}
+		routeComponent(s, i, &data)
 		return
 	}
 
 	// Modal Submissions
 	if i.Type == discordgo.InteractionModalSubmit {
 		data := i.ModalSubmitData()
-		for _, event := range OnModalReactionEvents {
-			event(s, i, &data)
-		}
+		routeModal(s, i, &data)
+	}
+}
+
+// respondGuildOnly tells the user that the application commands only work in a server
+func respondGuildOnly(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := interaction.RespondWithText(s, i, "❗ This command only works in a server, send me a message here to use my DM commands.", true)
+	if err != nil {
+		utils.Log.Error("\nOnInteraction:", err.Error())
+		utils.Log.Debug(utils.Log.Level.Error, "sending the guild only respond:", err.Error())
 	}
 }
 
@@ -128,7 +136,10 @@ func VoiceStateUpdate(s *discordgo.Session, vs *discordgo.VoiceStateUpdate) {
 
 	if status != common.VoiceStateNone {
 		for _, event := range OnVoiceStateUpdateEvents {
-			event(s, vs, status)
+			func() {
+				defer RecoverEvent("voiceStateUpdate")
+				event(s, vs, status)
+			}()
 		}
 	}
 }
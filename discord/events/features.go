@@ -0,0 +1,45 @@
+package events
+
+import (
+	"discord-bot/common"
+	"discord-bot/utils"
+	"slices"
+)
+
+// FeatureEnabled checks the commands.features config, the commands without a feature are always enabled
+func FeatureEnabled(feature common.Feature, guildID string) bool {
+	if feature == "" {
+		return true
+	}
+
+	config := utils.GetAppConfig()
+	if config == nil {
+		return true
+	}
+
+	scope, ok := config.Commands.Features[feature]
+	if !ok {
+		return true
+	}
+
+	if scope.Disabled {
+		return false
+	}
+
+	return len(scope.Guilds) == 0 || slices.Contains(scope.Guilds, guildID)
+}
+
+// FeatureIsGlobal checks if a feature is enabled in all the guilds, so its commands can be registered globally
+func FeatureIsGlobal(feature common.Feature) bool {
+	if feature == "" {
+		return true
+	}
+
+	config := utils.GetAppConfig()
+	if config == nil {
+		return true
+	}
+
+	scope, ok := config.Commands.Features[feature]
+	return !ok || (!scope.Disabled && len(scope.Guilds) == 0)
+}
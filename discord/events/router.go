@@ -0,0 +1,201 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"discord-bot/discord/interaction"
+	"discord-bot/utils"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// the max length of a custom ID allowed by discord
+const maxCustomIDLength = 100
+
+// NoPayload is the payload of the routes that don't need one
+type NoPayload = struct{}
+
+// Route is a namespaced custom ID with a typed payload, the custom IDs look like "name:version:signature:payload",
+// the payload is json. Bump the version when the payload changes so the buttons of the old messages are expired.
+type Route[T any] struct {
+	Name    string
+	Version int
+}
+
+type (
+	componentRoute = func(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.MessageComponentInteractionData, version string, payload string) error
+	modalRoute     = func(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.ModalSubmitInteractionData, version string, payload string) error
+)
+
+var (
+	routesMutex     sync.RWMutex
+	componentRoutes = map[string]componentRoute{}
+	modalRoutes     = map[string]modalRoute{}
+)
+
+// the signing key is derived from the bot token, so the custom IDs stay valid after a restart
+var signingKey = sync.OnceValue(func() []byte {
+	key := sha256.Sum256([]byte("custom-id:" + os.Getenv("TOKEN")))
+	return key[:]
+})
+
+func NewRoute[T any](name string, version int) Route[T] {
+	return Route[T]{Name: name, Version: version}
+}
+
+func sign(name string, version string, payload string) string {
+	mac := hmac.New(sha256.New, signingKey())
+	mac.Write([]byte(name + ":" + version + ":" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:6])
+}
+
+// CustomID encodes the payload into a signed custom ID of the route
+func (r Route[T]) CustomID(payload T) string {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		utils.Log.Error("\nRouter:", "encoding the payload of", r.Name+":", err.Error())
+		utils.Log.Debug(utils.Log.Level.Error, "Router: encoding the payload of", r.Name+":", err.Error())
+	}
+
+	version := strconv.Itoa(r.Version)
+	customID := r.Name + ":" + version + ":" + sign(r.Name, version, string(data)) + ":" + string(data)
+
+	if len(customID) > maxCustomIDLength {
+		utils.Log.Error("\nRouter:", "the custom ID of", r.Name, "is longer than", strconv.Itoa(maxCustomIDLength), "characters")
+		utils.Log.Debug(utils.Log.Level.Error, "Router: the custom ID is too long:", customID)
+	}
+
+	return customID
+}
+
+func (r Route[T]) decode(version string, payload string) (T, error) {
+	var value T
+	if version != strconv.Itoa(r.Version) {
+		return value, fmt.Errorf("version %s of %s is outdated", version, r.Name)
+	}
+	err := json.Unmarshal([]byte(payload), &value)
+	return value, err
+}
+
+// HandleComponent registers the handler of the buttons and the select menus of the route
+func HandleComponent[T any](r Route[T], handler func(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.MessageComponentInteractionData, payload T)) {
+	routesMutex.Lock()
+	defer routesMutex.Unlock()
+
+	if _, ok := componentRoutes[r.Name]; ok {
+		panic(fmt.Sprintf("the component route \"%s\" is registered twice", r.Name))
+	}
+
+	componentRoutes[r.Name] = func(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.MessageComponentInteractionData, version string, payload string) error {
+		value, err := r.decode(version, payload)
+		if err != nil {
+			return err
+		}
+		handler(s, i, d, value)
+		return nil
+	}
+}
+
+// HandleModal registers the handler of the modals of the route
+func HandleModal[T any](r Route[T], handler func(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.ModalSubmitInteractionData, payload T)) {
+	routesMutex.Lock()
+	defer routesMutex.Unlock()
+
+	if _, ok := modalRoutes[r.Name]; ok {
+		panic(fmt.Sprintf("the modal route \"%s\" is registered twice", r.Name))
+	}
+
+	modalRoutes[r.Name] = func(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.ModalSubmitInteractionData, version string, payload string) error {
+		value, err := r.decode(version, payload)
+		if err != nil {
+			return err
+		}
+		handler(s, i, d, value)
+		return nil
+	}
+}
+
+// parseCustomID checks the signature and returns the route name, the version and the payload
+func parseCustomID(customID string) (string, string, string, error) {
+	parts := strings.SplitN(customID, ":", 4)
+	if len(parts) != 4 {
+		return "", "", "", fmt.Errorf("unknown custom ID \"%s\"", customID)
+	}
+
+	name, version, signature, payload := parts[0], parts[1], parts[2], parts[3]
+	if !hmac.Equal([]byte(signature), []byte(sign(name, version, payload))) {
+		return "", "", "", fmt.Errorf("invalid signature of the custom ID \"%s\"", customID)
+	}
+
+	return name, version, payload, nil
+}
+
+func routeComponent(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.MessageComponentInteractionData) {
+	name, version, payload, err := parseCustomID(d.CustomID)
+	if err == nil {
+		routesMutex.RLock()
+		handler, ok := componentRoutes[name]
+		routesMutex.RUnlock()
+
+		if !ok {
+			err = fmt.Errorf("no route for the custom ID \"%s\"", d.CustomID)
+		} else {
+			err = func() error {
+				defer recoverInteraction(s, i, "route:"+name)
+				return handler(s, i, d, version, payload)
+			}()
+		}
+	}
+
+	if err != nil {
+		utils.Log.Debug(utils.Log.Level.Warning, "Router:", err.Error())
+		RespondExpired(s, i)
+	}
+}
+
+func routeModal(s *discordgo.Session, i *discordgo.InteractionCreate, d *discordgo.ModalSubmitInteractionData) {
+	name, version, payload, err := parseCustomID(d.CustomID)
+	if err == nil {
+		routesMutex.RLock()
+		handler, ok := modalRoutes[name]
+		routesMutex.RUnlock()
+
+		if !ok {
+			err = fmt.Errorf("no route for the custom ID \"%s\"", d.CustomID)
+		} else {
+			err = func() error {
+				defer recoverInteraction(s, i, "route:"+name)
+				return handler(s, i, d, version, payload)
+			}()
+		}
+	}
+
+	if err != nil {
+		utils.Log.Debug(utils.Log.Level.Warning, "Router:", err.Error())
+		RespondExpired(s, i)
+	}
+}
+
+// RespondExpired tells the user that the button, the menu or the form is no longer valid, it's also used by the
+// handlers when the data the message was made for is gone
+func RespondExpired(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	what := "button"
+	if i.Type == discordgo.InteractionModalSubmit {
+		what = "form"
+	} else if i.MessageComponentData().ComponentType != discordgo.ButtonComponent {
+		what = "menu"
+	}
+
+	err := interaction.RespondWithText(s, i, "❗ This "+what+" has expired, please run the command again.", true)
+	if err != nil {
+		utils.Log.Error("\nRouter:", err.Error())
+		utils.Log.Debug(utils.Log.Level.Error, "sending the expired respond:", err.Error())
+	}
+}
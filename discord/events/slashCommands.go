@@ -2,6 +2,10 @@ package events
 
 import (
 	"discord-bot/common"
+	"discord-bot/discord/interaction"
+	"discord-bot/metrics"
+	"discord-bot/utils"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -23,8 +27,55 @@ func RegisterSlashCommand(c *common.SlashCommand) {
 func ExecuteSlashCommands(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.ApplicationCommandInteractionData) {
 	for _, interaction := range Interactions {
 		if data.Name == interaction.Command.Name {
+			// the command can still be registered before the config changed
+			if !FeatureEnabled(interaction.Feature, i.GuildID) {
+				respondFeatureDisabled(s, i)
+				break
+			}
+
+			if !allowCommand(s, i, data.Name, interaction.Cooldown) {
+				break
+			}
+
+			start := time.Now()
 			interaction.Handler(s, i, data)
+			metrics.ObserveCommand(data.Name, start)
 			break
 		}
 	}
 }
+
+func respondFeatureDisabled(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := interaction.RespondWithText(s, i, "❗ This command is not enabled in this server.", true)
+	if err != nil {
+		utils.Log.Error("\nSlashCommand:", err.Error())
+		utils.Log.Debug(utils.Log.Level.Error, "sending the disabled feature respond:", err.Error())
+	}
+}
+
+// CommandsFor returns the commands that should be registered for the given scope, an empty guildID for the global
+// commands, the commands of the features that are enabled in some guilds only are registered to these guilds
+func CommandsFor(guildID string) []*discordgo.ApplicationCommand {
+	commands := []*discordgo.ApplicationCommand{}
+	for _, c := range Interactions {
+		global := FeatureIsGlobal(c.Feature)
+		if guildID == "" && global {
+			commands = append(commands, &c.Command)
+		}
+		if guildID != "" && !global && FeatureEnabled(c.Feature, guildID) {
+			commands = append(commands, &c.Command)
+		}
+	}
+	return commands
+}
+
+// DevCommands returns all the enabled commands, in dev mode they are registered to the dev guild only
+func DevCommands() []*discordgo.ApplicationCommand {
+	commands := []*discordgo.ApplicationCommand{}
+	for _, c := range Interactions {
+		if FeatureIsGlobal(c.Feature) || FeatureEnabled(c.Feature, utils.GetAppConfig().Commands.DevGuild) {
+			commands = append(commands, &c.Command)
+		}
+	}
+	return commands
+}
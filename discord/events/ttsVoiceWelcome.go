@@ -16,7 +16,7 @@ func init() {
 
 // ttsVoiceWelcomeMessage speaks out when a custom TTS voice message when a user joins the voice channel
 func ttsVoiceWelcomeMessage(s *discordgo.Session, vs *discordgo.VoiceStateUpdate, state common.VoiceState) {
-	if state != common.VoiceJoined {
+	if state != common.VoiceJoined || !FeatureEnabled(common.FeatureWelcomeVoiceMessage, vs.GuildID) {
 		return
 	}
 
@@ -49,6 +49,9 @@ func ttsVoiceWelcomeMessage(s *discordgo.Session, vs *discordgo.VoiceStateUpdate
 		return
 	}
 
+	unlock := LockVoice(guildID)
+	defer unlock()
+
 	//  join the user's voice channel
 	vc, err := s.ChannelVoiceJoin(guildID, vs.ChannelID, false, true)
 	if err != nil {
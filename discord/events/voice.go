@@ -0,0 +1,24 @@
+package events
+
+import "sync"
+
+var (
+	// the bot can only be in one voice channel per guild, the key is the guild ID
+	voiceLocks      = map[string]*sync.Mutex{}
+	voiceLocksMutex sync.Mutex
+)
+
+// LockVoice waits until the bot is free to join a voice channel in a guild, the returned function must be called
+// after leaving the voice channel
+func LockVoice(guildID string) func() {
+	voiceLocksMutex.Lock()
+	lock, ok := voiceLocks[guildID]
+	if !ok {
+		lock = &sync.Mutex{}
+		voiceLocks[guildID] = lock
+	}
+	voiceLocksMutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
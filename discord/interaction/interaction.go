@@ -61,6 +61,40 @@ func RespondEditWithComponents(s *discordgo.Session, i *discordgo.InteractionCre
 	return err
 }
 
+// the max length of a modal title in characters
+const maxModalTitleLength = 45
+
+// RespondWithModal opens a modal, the text inputs should be wrapped in rows, a long title is cut
+func RespondWithModal(s *discordgo.Session, i *discordgo.InteractionCreate, customID string, title string, rows ...discordgo.MessageComponent) error {
+	if runes := []rune(title); len(runes) > maxModalTitleLength {
+		title = string(runes[:maxModalTitleLength-3]) + "..."
+	}
+
+	return s.InteractionRespond(i.Interaction,
+		NewInteractionResponse().SetType(discordgo.InteractionResponseModal).SetData(
+			NewResponseData().SetCustomID(customID).SetTitle(title).SetComponents(rows...).Into(),
+		),
+	)
+}
+
+// GetModalValue returns the value of a text input in a submitted modal
+func GetModalValue(data *discordgo.ModalSubmitInteractionData, customID string) string {
+	for _, row := range data.Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+
+		for _, component := range actionsRow.Components {
+			if input, ok := component.(*discordgo.TextInput); ok && input.CustomID == customID {
+				return input.Value
+			}
+		}
+	}
+
+	return ""
+}
+
 func findUserVoiceState(session *discordgo.Session, userID string) (*discordgo.VoiceState, error) {
 	for _, guild := range session.State.Guilds {
 		for _, vs := range guild.VoiceStates {
@@ -0,0 +1,287 @@
+package auditCommand
+
+import (
+	"discord-bot/common"
+	"discord-bot/discord/audit"
+	"discord-bot/discord/components"
+	"discord-bot/discord/events"
+	"discord-bot/discord/interaction"
+	"discord-bot/firebase"
+	"discord-bot/utils"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var Log = &utils.Log
+
+// the number of entries in a page of the audit list
+const auditPageSize = 10
+
+// only members that can manage the server can see the audit log by default
+var auditPermissions int64 = discordgo.PermissionManageServer
+
+var dmPermission = false
+
+var command = common.SlashCommand{
+	Command: discordgo.ApplicationCommand{
+		Name:                     "audit",
+		Description:              "Show the changes made to the bot settings in this server",
+		DefaultMemberPermissions: &auditPermissions,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "list",
+				Description: "Show the audit log, the newest first",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "user",
+						Description: "Only the changes made by this user",
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Required:    false,
+					},
+					{
+						Name:        "action",
+						Description: "Only this action",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices:     actionChoices(),
+						Required:    false,
+					},
+				},
+			},
+			{
+				Name:        "channel",
+				Description: "Mirror the audit log to a mod-log channel",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:         "channel",
+						Description:  "The mod-log channel, leave it empty to stop mirroring",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						Required:     false,
+					},
+				},
+			},
+		},
+	},
+
+	Handler: cmdHandler,
+}
+
+// auditQuery is the filters and the page of an audit list, the short json names keep the custom ID small
+type auditQuery struct {
+	UserID string `json:"u,omitempty"`
+	Action string `json:"a,omitempty"`
+	Page   int    `json:"p"`
+}
+
+var pageRoute = events.NewRoute[auditQuery]("audit_page", 1)
+
+func init() {
+	events.RegisterSlashCommand(&command)
+	events.HandleComponent(pageRoute, auditPageButton)
+}
+
+func actionChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, action := range audit.Actions {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: action, Value: action})
+	}
+	return choices
+}
+
+type cmdOptions struct {
+	subcommand string // "list" or "channel"
+	userID     string // optional for "list"
+	action     string // optional for "list"
+	channelID  string // optional for "channel", empty to stop mirroring
+}
+
+func parseCmdOptions(options []*discordgo.ApplicationCommandInteractionDataOption) cmdOptions {
+	results := cmdOptions{}
+
+	results.subcommand = options[0].Name
+
+	for _, opt := range options[0].Options {
+		switch opt.Name {
+		case "user":
+			results.userID, _ = opt.Value.(string)
+		case "action":
+			results.action, _ = utils.CheckOptionStringValue(opt)
+		case "channel":
+			results.channelID, _ = opt.Value.(string)
+		}
+	}
+
+	return results
+}
+
+func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData) {
+	user := utils.GetInteractionAuthor(i.Interaction)
+
+	Log.Debug(Log.Level.Info, `SlashCommand: "audit", GuildID:`, i.GuildID, "ChannelID:", i.ChannelID, "UserID:", user.ID, "UserName:", user.Username)
+
+	options := parseCmdOptions(appData.Options)
+
+	guildData, err := firebase.GetGuildData(i.GuildID)
+	if err != nil {
+		events.RespondError(s, i, "getting this guild data from firebase", err)
+		return
+	}
+
+	// * LIST
+	if options.subcommand == "list" {
+		auditLog, err := firebase.GetAuditLog(i.GuildID)
+		if err != nil {
+			events.RespondError(s, i, "getting the audit log from firebase", err)
+			return
+		}
+
+		embed, buttons := createAuditPage(auditLog, auditQuery{UserID: options.userID, Action: options.action})
+
+		sendError := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{embed},
+				Components: buttons,
+				Flags:      discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if sendError != nil {
+			Log.Error("\nAudit:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "audit" command:`, sendError.Error())
+		}
+		return
+	}
+
+	// * CHANNEL
+	if options.subcommand == "channel" {
+		oldChannel := guildData.ModLogChannel
+
+		// check the bot can send messages there before saving it
+		if options.channelID != "" {
+			_, err := s.ChannelMessageSend(options.channelID, fmt.Sprintf("📋 The audit log of the bot will be mirrored to this channel, set by <@%s>.", user.ID))
+			if err != nil {
+				events.RespondError(s, i, "sending a message to the mod-log channel", err)
+				return
+			}
+		}
+
+		err := firebase.SetModLogChannel(i.GuildID, options.channelID)
+		if err != nil {
+			events.RespondError(s, i, "uploading the mod-log channel to firebase", err)
+			return
+		}
+
+		text := "**Success:** The audit log is not mirrored anymore."
+		if options.channelID != "" {
+			text = fmt.Sprintf("**Success:** The audit log is mirrored to <#%s>.", options.channelID)
+		}
+		sendError := interaction.RespondWithText(s, i, text, true)
+		if sendError != nil {
+			Log.Error("\nAudit:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "audit" command:`, sendError.Error())
+		}
+
+		audit.Record(s, audit.Change{
+			GuildID: i.GuildID,
+			UserID:  user.ID,
+			Action:  audit.ActionModLogSet,
+			Before:  formatChannel(oldChannel),
+			After:   formatChannel(options.channelID),
+		})
+	}
+}
+
+func formatChannel(channelID string) string {
+	if channelID == "" {
+		return "none"
+	}
+	return "<#" + channelID + ">"
+}
+
+// createAuditPage filters the audit log of the guild and returns a page of it with the page buttons
+func createAuditPage(auditLog []firebase.AuditEntry, query auditQuery) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	lines := []string{}
+	for index := len(auditLog) - 1; index >= 0; index-- {
+		entry := auditLog[index]
+		if query.UserID != "" && entry.UserID != query.UserID {
+			continue
+		}
+		if query.Action != "" && entry.Action != query.Action {
+			continue
+		}
+		lines = append(lines, audit.FormatEntry(entry))
+	}
+
+	pagesCount := max((len(lines)+auditPageSize-1)/auditPageSize, 1)
+	query.Page = min(max(query.Page, 0), pagesCount-1)
+
+	start := query.Page * auditPageSize
+	end := min(start+auditPageSize, len(lines))
+
+	description := strings.Join(lines[start:end], "\n")
+	if len(lines) == 0 {
+		description = "No changes found."
+	}
+
+	filters := []string{}
+	if query.UserID != "" {
+		filters = append(filters, "<@"+query.UserID+">")
+	}
+	if query.Action != "" {
+		filters = append(filters, "`"+query.Action+"`")
+	}
+	if len(filters) > 0 {
+		description = "Filters: " + strings.Join(filters, ", ") + "\n\u200b\n" + description
+	}
+
+	embed := components.NewEmbed().
+		SetTitle("Audit Log").
+		SetColor(0x0099ff).
+		SetDescription(description).
+		SetFooter(fmt.Sprintf("Page %d/%d · %d changes", query.Page+1, pagesCount, len(lines))).
+		Truncate().
+		Into()
+
+	buttons := []discordgo.MessageComponent{}
+	if pagesCount > 1 {
+		previous, next := query, query
+		previous.Page--
+		next.Page++
+
+		buttons = *components.AddMessageComponents(
+			components.NewRow(
+				components.NewButton().SetLabel("Previous Page").SetCustomID(pageRoute.CustomID(previous)).SetStyleSecondary().SetDisabled(query.Page == 0),
+				components.NewButton().SetLabel("Next Page").SetCustomID(pageRoute.CustomID(next)).SetStyleSecondary().SetDisabled(query.Page >= pagesCount-1),
+			),
+		)
+	}
+
+	return embed, buttons
+}
+
+func auditPageButton(s *discordgo.Session, i *discordgo.InteractionCreate, _ *discordgo.MessageComponentInteractionData, query auditQuery) {
+	auditLog, err := firebase.GetAuditLog(i.GuildID)
+	if err != nil {
+		events.RespondError(s, i, "getting the audit log from firebase", err)
+		return
+	}
+
+	embed, buttons := createAuditPage(auditLog, query)
+
+	sendError := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: buttons,
+		},
+	})
+	if sendError != nil {
+		Log.Error("\nAudit:", sendError.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "audit" command:`, sendError.Error())
+	}
+}
@@ -2,6 +2,7 @@ package botActivity
 
 import (
 	"discord-bot/common"
+	"discord-bot/discord/audit"
 	"discord-bot/discord/events"
 	"discord-bot/discord/interaction"
 	"discord-bot/firebase"
@@ -13,6 +14,15 @@ import (
 
 var Log = &utils.Log
 
+var activityChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "Playing", Value: discordgo.ActivityTypeGame},
+	{Name: "Streaming", Value: discordgo.ActivityTypeStreaming},
+	{Name: "Listening", Value: discordgo.ActivityTypeListening},
+	{Name: "Watching", Value: discordgo.ActivityTypeWatching},
+	{Name: "Competing", Value: discordgo.ActivityTypeCompeting},
+	{Name: "Custom", Value: discordgo.ActivityTypeCustom},
+}
+
 var command = common.SlashCommand{
 	Command: discordgo.ApplicationCommand{
 		Name:        "bot-activity",
@@ -28,19 +38,13 @@ var command = common.SlashCommand{
 				Name:        "activity",
 				Description: "Current activity",
 				Type:        discordgo.ApplicationCommandOptionInteger,
-				Choices: []*discordgo.ApplicationCommandOptionChoice{
-					{Name: "Playing", Value: discordgo.ActivityTypeGame},
-					{Name: "Streaming", Value: discordgo.ActivityTypeStreaming},
-					{Name: "Listening", Value: discordgo.ActivityTypeListening},
-					{Name: "Watching", Value: discordgo.ActivityTypeWatching},
-					{Name: "Competing", Value: discordgo.ActivityTypeCompeting},
-					{Name: "Custom", Value: discordgo.ActivityTypeCustom},
-				},
-				Required: true,
+				Choices:     activityChoices,
+				Required:    true,
 			},
 		},
 	},
 
+	Feature: common.FeatureBotActivity,
 	Handler: cmdHandler,
 }
 
@@ -93,10 +97,15 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 		return
 	}
 
-	err = firebase.SetBotActivity(firebase.BotActivity{
+	// only used for the audit log
+	oldActivity, _ := firebase.GetBotActivity()
+
+	newActivity := firebase.BotActivity{
 		Activity:     options.status,
 		ActivityType: options.activity,
-	})
+	}
+
+	err = firebase.SetBotActivity(newActivity)
 	if err != nil {
 		sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while uploading bot activity data to firebase::\n`%s`", err.Error()), true)
 		Log.Debug(Log.Level.Error, `uploading "bot-activity" data to firebase:`, err.Error())
@@ -125,4 +134,22 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 		Log.Error("\nbotActivity:", sendError.Error())
 		Log.Debug(Log.Level.Error, `sending a respond for "bot-activity" command:`, sendError.Error())
 	}
+
+	audit.Record(s, audit.Change{
+		GuildID: i.GuildID,
+		UserID:  user.ID,
+		Action:  audit.ActionBotActivitySet,
+		Before:  formatActivity(oldActivity),
+		After:   formatActivity(newActivity),
+	})
+}
+
+// formatActivity formats an activity like "Listening /"
+func formatActivity(activity firebase.BotActivity) string {
+	for _, choice := range activityChoices {
+		if choice.Value == activity.ActivityType {
+			return choice.Name + " " + activity.Activity
+		}
+	}
+	return activity.Activity
 }
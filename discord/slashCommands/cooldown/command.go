@@ -0,0 +1,238 @@
+package cooldown
+
+import (
+	"discord-bot/common"
+	"discord-bot/discord/audit"
+	"discord-bot/discord/events"
+	"discord-bot/discord/interaction"
+	"discord-bot/firebase"
+	"discord-bot/utils"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var Log = &utils.Log
+
+// the max cooldown a guild can set, one hour
+const maxCooldownSeconds = 3600
+
+// only members that can manage the server can change the cooldowns by default
+var cooldownPermissions int64 = discordgo.PermissionManageServer
+
+var dmPermission = false
+
+var minSeconds float64 = 0
+
+var command = common.SlashCommand{
+	Command: discordgo.ApplicationCommand{
+		Name:                     "cooldown",
+		Description:              "Change the time members have to wait between uses of a command",
+		DefaultMemberPermissions: &cooldownPermissions,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "set",
+				Description: "Set the cooldown of a command in this server",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "command",
+						Description: "The command name, without the slash",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+					{
+						Name:        "seconds",
+						Description: "The cooldown in seconds, 0 for no cooldown",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Required:    true,
+						MinValue:    &minSeconds,
+						MaxValue:    maxCooldownSeconds,
+					},
+				},
+			},
+			{
+				Name:        "reset",
+				Description: "Reset the cooldown of a command to the default",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "command",
+						Description: "The command name, without the slash",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "list",
+				Description: "Show the cooldowns of the commands in this server",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+		},
+	},
+
+	Handler: cmdHandler,
+}
+
+func init() {
+	events.RegisterSlashCommand(&command)
+}
+
+type cmdOptions struct {
+	subcommand string // "set", "reset" or "list"
+	command    string // required for "set" and "reset"
+	seconds    int    // required for "set"
+}
+
+func parseCmdOptions(options []*discordgo.ApplicationCommandInteractionDataOption) (cmdOptions, error) {
+	results := cmdOptions{}
+
+	results.subcommand = options[0].Name
+
+	for _, opt := range options[0].Options {
+		switch opt.Name {
+		case "command":
+			val, err := utils.CheckOptionStringValue(opt)
+			if err != nil {
+				return results, fmt.Errorf("please enter a command name")
+			}
+			results.command = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(val), "/"))
+
+		case "seconds":
+			results.seconds = int(opt.IntValue())
+		}
+	}
+
+	if results.command != "" && findCommand(results.command) == nil {
+		return results, fmt.Errorf("there is no \"%s\" command", results.command)
+	}
+	if results.seconds < 0 || results.seconds > maxCooldownSeconds {
+		return results, fmt.Errorf("the cooldown should be between 0 and %d seconds", maxCooldownSeconds)
+	}
+
+	return results, nil
+}
+
+func findCommand(name string) *common.SlashCommand {
+	index := slices.IndexFunc(events.Interactions, func(c *common.SlashCommand) bool {
+		return c.Command.Name == name
+	})
+	if index == -1 {
+		return nil
+	}
+	return events.Interactions[index]
+}
+
+func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData) {
+	user := utils.GetInteractionAuthor(i.Interaction)
+
+	Log.Debug(Log.Level.Info, `SlashCommand: "cooldown", GuildID:`, i.GuildID, "ChannelID:", i.ChannelID, "UserID:", user.ID, "UserName:", user.Username)
+
+	options, err := parseCmdOptions(appData.Options)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `parsing "cooldown" command options:`, err.Error())
+		sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while parsing **cooldown** command options:\n`%s`", err.Error()), true)
+		if sendError != nil {
+			Log.Error("\nCooldown:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "cooldown" command:`, sendError.Error())
+		}
+		return
+	}
+
+	// the cooldown before the change, for the audit log
+	var oldCooldown time.Duration
+	if options.command != "" {
+		oldCooldown = events.CommandCooldown(i.GuildID, options.command, findCommand(options.command).Cooldown)
+	}
+
+	switch options.subcommand {
+	// * SET
+	case "set":
+		err := firebase.SetCommandCooldown(i.GuildID, options.command, &options.seconds)
+		if err != nil {
+			events.RespondError(s, i, "uploading the cooldown to firebase", err)
+			return
+		}
+
+		newCooldown := time.Duration(options.seconds) * time.Second
+		respond(s, i, fmt.Sprintf("**Success:** The cooldown of `/%s` is set to %s.", options.command, formatCooldown(newCooldown)))
+
+		audit.Record(s, audit.Change{
+			GuildID: i.GuildID,
+			UserID:  user.ID,
+			Action:  audit.ActionCooldownSet,
+			Target:  "/" + options.command,
+			Before:  formatCooldown(oldCooldown),
+			After:   formatCooldown(newCooldown),
+		})
+
+	// * RESET
+	case "reset":
+		err := firebase.SetCommandCooldown(i.GuildID, options.command, nil)
+		if err != nil {
+			events.RespondError(s, i, "removing the cooldown from firebase", err)
+			return
+		}
+
+		defaultCooldown := findCommand(options.command).Cooldown
+		respond(s, i, fmt.Sprintf("**Success:** The cooldown of `/%s` is reset to %s.", options.command, formatCooldown(defaultCooldown)))
+
+		audit.Record(s, audit.Change{
+			GuildID: i.GuildID,
+			UserID:  user.ID,
+			Action:  audit.ActionCooldownReset,
+			Target:  "/" + options.command,
+			Before:  formatCooldown(oldCooldown),
+			After:   formatCooldown(defaultCooldown),
+		})
+
+	// * LIST
+	case "list":
+		guildData, err := firebase.GetGuildData(i.GuildID)
+		if err != nil {
+			events.RespondError(s, i, "getting this guild data from firebase", err)
+			return
+		}
+
+		text := "**Cooldowns:**\n"
+		for _, c := range events.Interactions {
+			seconds, overridden := guildData.Cooldowns[c.Command.Name]
+			if !overridden && c.Cooldown == 0 {
+				continue
+			}
+
+			cooldown := c.Cooldown
+			if overridden {
+				cooldown = time.Duration(seconds) * time.Second
+			}
+
+			text += fmt.Sprintf("🔹 `/%s` %s", c.Command.Name, formatCooldown(cooldown))
+			if overridden {
+				text += fmt.Sprintf(" (default %s)", formatCooldown(c.Cooldown))
+			}
+			text += "\n"
+		}
+
+		respond(s, i, text+"\nThe other commands have no cooldown.")
+	}
+}
+
+func formatCooldown(cooldown time.Duration) string {
+	if cooldown == 0 {
+		return "no cooldown"
+	}
+	return cooldown.String()
+}
+
+func respond(s *discordgo.Session, i *discordgo.InteractionCreate, text string) {
+	sendError := interaction.RespondWithText(s, i, text, true)
+	if sendError != nil {
+		Log.Error("\nCooldown:", sendError.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "cooldown" command:`, sendError.Error())
+	}
+}
@@ -2,17 +2,23 @@ package customCommands
 
 import (
 	"discord-bot/common"
+	"discord-bot/discord/audit"
 	"discord-bot/discord/events"
 	"discord-bot/discord/interaction"
 	"discord-bot/firebase"
 	"discord-bot/utils"
 	"fmt"
+	"regexp"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 var Log = &utils.Log
 
+var minCooldown float64 = 0
+
+var channelMentionRegex = regexp.MustCompile(`<#(\d+)>`)
+
 var command = common.SlashCommand{
 	Command: discordgo.ApplicationCommand{
 		Name:        "custom-command",
@@ -31,9 +37,73 @@ var command = common.SlashCommand{
 					},
 					{
 						Name:        "response",
-						Description: "The response for the command",
+						Description: "The response, supports placeholders like {user}, {args}, {random:a|b} and {count}",
 						Type:        discordgo.ApplicationCommandOptionString,
-						Required:    true,
+						Required:    false,
+					},
+					{
+						Name:        "type",
+						Description: "How the response is sent, defaults to text (optional)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Text message", Value: ResponseText},
+							{Name: "Embed", Value: ResponseEmbed},
+							{Name: "Reaction, the response is the emojis", Value: ResponseReaction},
+							{Name: "File, the response is the caption", Value: ResponseFile},
+							{Name: "TTS in the author's voice channel", Value: ResponseTTS},
+						},
+					},
+					{
+						Name:        "file",
+						Description: "The file to send for the file type (optional)",
+						Type:        discordgo.ApplicationCommandOptionAttachment,
+						Required:    false,
+					},
+					{
+						Name:        "title",
+						Description: "The embed title for the embed type (optional)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+					},
+					{
+						Name:        "language",
+						Description: "The TTS language for the tts type, defaults to en (optional)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+					},
+					{
+						Name:        "match",
+						Description: "How the trigger is matched, defaults to exact (optional)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Exact", Value: MatchExact},
+							{Name: "Case insensitive", Value: MatchIgnoreCase},
+							{Name: "Starts with", Value: MatchStartsWith},
+							{Name: "Contains", Value: MatchContains},
+							{Name: "Regex, groups can be used with {match:N}", Value: MatchRegex},
+							{Name: "Wildcard, * and ? can be used with {match:N}", Value: MatchWildcard},
+						},
+					},
+					{
+						Name:        "cooldown",
+						Description: "Seconds between two uses of the command (optional)",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Required:    false,
+						MinValue:    &minCooldown,
+					},
+					{
+						Name:        "allowed_channels",
+						Description: "Only run in these channels, e.g. #general #memes (optional)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+					},
+					{
+						Name:        "denied_channels",
+						Description: "Never run in these channels, e.g. #rules (optional)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
 					},
 				},
 			},
@@ -50,25 +120,97 @@ var command = common.SlashCommand{
 					},
 				},
 			},
+			{
+				Name:        "edit",
+				Description: "Edit a custom command response in an editor",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "trigger",
+						Description: "The trigger word for the command to edit",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+				},
+			},
 			{
 				Name:        "list",
 				Description: "List all custom commands",
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
 			},
+			{
+				Name:        "export",
+				Description: "Export all custom commands as a file",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "format",
+						Description: "The file format, defaults to json (optional)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "JSON", Value: "json"},
+							{Name: "YAML", Value: "yaml"},
+						},
+					},
+				},
+			},
+			{
+				Name:        "import",
+				Description: "Import custom commands from an exported file",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "file",
+						Description: "A .json, .yaml or .yml file",
+						Type:        discordgo.ApplicationCommandOptionAttachment,
+						Required:    true,
+					},
+					{
+						Name:        "mode",
+						Description: "Merge with or replace the current commands, defaults to merge (optional)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Merge", Value: ImportMerge},
+							{Name: "Replace", Value: ImportReplace},
+						},
+					},
+					{
+						Name:        "dry_run",
+						Description: "Only show the changes without saving them (optional)",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Required:    false,
+					},
+				},
+			},
 		},
 	},
 
+	Feature: common.FeatureCustomCommands,
 	Handler: cmdHandler,
 }
 
 func init() {
 	events.RegisterSlashCommand(&command)
+	events.HandleModal(editModalRoute, onEditModalSubmit)
 }
 
 type cmdOptions struct {
-	subcommand string // "set" or "remove"
-	trigger    string // required for "add" and "remove"
-	response   string // required for "add"
+	subcommand string   // "add", "edit", "remove", "list", "export" or "import"
+	trigger    string   // required for "add", "edit" and "remove"
+	response   string   // required for "add", except for the "file" type
+	respType   string   // optional for "add"
+	fileID     string   // required for "import" and "add" with the "file" type
+	title      string   // optional for "add"
+	lang       string   // optional for "add"
+	match      string   // optional for "add"
+	cooldown   int      // optional for "add"
+	allowed    []string // optional for "add"
+	denied     []string // optional for "add"
+	format     string   // optional for "export"
+	importMode string   // optional for "import"
+	dryRun     bool     // optional for "import"
 }
 
 func parseCmdOptions(options []*discordgo.ApplicationCommandInteractionDataOption) (cmdOptions, error) {
@@ -95,8 +237,50 @@ func parseCmdOptions(options []*discordgo.ApplicationCommandInteractionDataOptio
 					return results, fmt.Errorf("please enter a response")
 				}
 				results.response = val
+
+			case "type":
+				val, err := utils.CheckOptionStringValue(opt)
+				if err == nil {
+					results.respType = val
+				}
+
+			case "file":
+				if opt.Value != nil {
+					results.fileID = opt.Value.(string)
+				}
+
+			case "title":
+				results.title = opt.StringValue()
+
+			case "language":
+				results.lang = opt.StringValue()
+
+			case "match":
+				val, err := utils.CheckOptionStringValue(opt)
+				if err == nil {
+					results.match = val
+				}
+
+			case "cooldown":
+				results.cooldown = int(opt.IntValue())
+
+			case "allowed_channels":
+				results.allowed = parseChannelMentions(opt.StringValue())
+
+			case "denied_channels":
+				results.denied = parseChannelMentions(opt.StringValue())
 			}
 		}
+
+		err := ValidateMatch(results.match, results.trigger)
+		if err != nil {
+			return results, err
+		}
+
+		err = ValidateResponse(results.respType, results.response, results.fileID != "")
+		if err != nil {
+			return results, err
+		}
 	}
 
 	if subcommand == "remove" {
@@ -114,13 +298,70 @@ func parseCmdOptions(options []*discordgo.ApplicationCommandInteractionDataOptio
 		}
 	}
 
+	if subcommand == "edit" {
+		results.subcommand = "edit"
+
+		for _, opt := range subcommandOptions {
+			switch opt.Name {
+			case "trigger":
+				val, err := utils.CheckOptionStringValue(opt)
+				if err != nil {
+					return results, fmt.Errorf("please enter a trigger word")
+				}
+				results.trigger = val
+			}
+		}
+	}
+
 	if subcommand == "list" {
 		results.subcommand = "list"
 	}
 
+	if subcommand == "export" {
+		results.subcommand = "export"
+
+		for _, opt := range subcommandOptions {
+			switch opt.Name {
+			case "format":
+				results.format = opt.StringValue()
+			}
+		}
+	}
+
+	if subcommand == "import" {
+		results.subcommand = "import"
+		results.importMode = ImportMerge
+
+		for _, opt := range subcommandOptions {
+			switch opt.Name {
+			case "file":
+				if opt.Value == nil {
+					return results, fmt.Errorf("please attach a file")
+				}
+				results.fileID = opt.Value.(string)
+			case "mode":
+				val, err := utils.CheckOptionStringValue(opt)
+				if err == nil {
+					results.importMode = val
+				}
+			case "dry_run":
+				results.dryRun = opt.BoolValue()
+			}
+		}
+	}
+
 	return results, nil
 }
 
+// parseChannelMentions returns the channel IDs from a text like "<#123> <#456>"
+func parseChannelMentions(text string) []string {
+	var channels []string
+	for _, match := range channelMentionRegex.FindAllStringSubmatch(text, -1) {
+		channels = append(channels, match[1])
+	}
+	return channels
+}
+
 func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData) {
 	user := utils.GetInteractionAuthor(i.Interaction)
 
@@ -150,10 +391,58 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 
 	// * ADD
 	if options.subcommand == "add" {
-		newItem := firebase.CustomCommand{When: options.trigger, Say: options.response}
+		unlock := lockCommands(i.GuildID)
+		defer unlock()
+		defer InvalidateMatchers(i.GuildID) // the commands changed, compile them again
+		err = ValidateTemplate(options.response)
+		if err != nil {
+			Log.Debug(Log.Level.Error, `validating "custom-command" response:`, err.Error())
+			sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while validating the response:\n`%s`\n\u200b\n**Available placeholders:**\n%s", err.Error(), PlaceholdersHelp()), true)
+			if sendError != nil {
+				Log.Error("\nCustomCommands:", sendError.Error())
+				Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
+			}
+			return
+		}
+
+		newItem := firebase.CustomCommand{
+			When:          options.trigger,
+			Say:           options.response,
+			Match:         options.match,
+			Cooldown:      options.cooldown,
+			AllowChannels: options.allowed,
+			DenyChannels:  options.denied,
+			Type:          options.respType,
+			Title:         options.title,
+			Lang:          options.lang,
+		}
 		currentItem, notFoundErr := guildData.CustomCommandsGetItem(options.trigger)
 		exists := notFoundErr == nil
 
+		// store the file of the "file" type
+		if options.respType == ResponseFile {
+			var attachment *discordgo.MessageAttachment
+			if appData.Resolved != nil {
+				attachment = appData.Resolved.Attachments[options.fileID]
+			}
+
+			if attachment == nil {
+				err = fmt.Errorf("attachment not found")
+			} else {
+				newItem.File, err = storeFile(i.GuildID, attachment)
+			}
+
+			if err != nil {
+				Log.Debug(Log.Level.Error, `storing a "custom-command" file:`, err.Error())
+				sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while storing the file:\n`%s`", err.Error()), true)
+				if sendError != nil {
+					Log.Error("\nCustomCommands:", sendError.Error())
+					Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
+				}
+				return
+			}
+		}
+
 		if exists {
 			// update
 			guildData.CustomCommandsUpdateItem(newItem)
@@ -171,6 +460,7 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 			} else {
 				guildData.CustomCommandsRemoveItem(options.trigger)
 			}
+			removeFile(i.GuildID, newItem.File)
 
 			Log.Debug(Log.Level.Error, `uploading "custom-command (add)" data to firebase:`, err.Error())
 			sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while uploading **custom-command (add)** data to firebase:\n`%s`", err.Error()), true)
@@ -181,16 +471,54 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 			return
 		}
 
+		// the previous file is replaced
+		if exists && currentItem.File != newItem.File {
+			removeFile(i.GuildID, currentItem.File)
+		}
+
 		sendError := interaction.RespondWithText(s, i, "**Success:** Custom command added", true)
 		if sendError != nil {
 			Log.Error("\nCustomCommands:", sendError.Error())
 			Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
 		}
+
+		change := audit.Change{
+			GuildID: i.GuildID,
+			UserID:  user.ID,
+			Action:  audit.ActionCustomCommandAdd,
+			Target:  newItem.When,
+			After:   describeCommand(newItem),
+		}
+		if exists {
+			change.Action = audit.ActionCustomCommandEdit
+			change.Before = describeCommand(*currentItem)
+		}
+		audit.Record(s, change)
+		return
+	}
+
+	// * EDIT
+	if options.subcommand == "edit" {
+		currentItem, notFoundError := guildData.CustomCommandsGetItem(options.trigger)
+		if notFoundError != nil {
+			Log.Debug(Log.Level.Error, `getting a "custom-command" item:`, notFoundError.Error())
+			sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while getting a **custom-command** item:\n`%s`", notFoundError.Error()), true)
+			if sendError != nil {
+				Log.Error("\nCustomCommands:", sendError.Error())
+				Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
+			}
+			return
+		}
+
+		openEditModal(s, i, currentItem)
 		return
 	}
 
 	// * REMOVE
 	if options.subcommand == "remove" {
+		unlock := lockCommands(i.GuildID)
+		defer unlock()
+		defer InvalidateMatchers(i.GuildID) // the commands changed, compile them again
 		currentItem, notFoundError := guildData.CustomCommandsGetItem(options.trigger)
 		if notFoundError != nil {
 			Log.Debug(Log.Level.Error, `getting a "custom-command" item:`, notFoundError.Error())
@@ -219,12 +547,50 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 			return
 		}
 
+		removeFile(i.GuildID, currentItem.File)
+
 		// send confirmation
 		sendError := interaction.RespondWithText(s, i, "**Success:** Welcome message removed", true)
 		if sendError != nil {
 			Log.Error("\nCustomCommands:", sendError.Error())
 			Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
 		}
+
+		audit.Record(s, audit.Change{
+			GuildID: i.GuildID,
+			UserID:  user.ID,
+			Action:  audit.ActionCustomCommandRemove,
+			Target:  currentItem.When,
+			Before:  describeCommand(*currentItem),
+		})
+		return
+	}
+
+	// the import can replace all the commands, so both need the same permission as the server settings
+	if (options.subcommand == "export" || options.subcommand == "import") &&
+		(i.Member == nil || i.Member.Permissions&discordgo.PermissionManageServer == 0) {
+		sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** you need the **Manage Server** permission to %s custom commands", options.subcommand), true)
+		if sendError != nil {
+			Log.Error("\nCustomCommands:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
+		}
+		return
+	}
+
+	// * EXPORT
+	if options.subcommand == "export" {
+		exportCommands(s, i, guildData, options.format)
+		return
+	}
+
+	// * IMPORT
+	if options.subcommand == "import" {
+		var attachment *discordgo.MessageAttachment
+		if appData.Resolved != nil {
+			attachment = appData.Resolved.Attachments[options.fileID]
+		}
+
+		importCommands(s, i, guildData, attachment, options.importMode, options.dryRun)
 		return
 	}
 
@@ -241,7 +607,15 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 
 		formattedResponse := "A list of custom commands:\n"
 		for _, item := range guildData.CustomCommands {
-			formattedResponse += fmt.Sprintf("🔹 **%s**: %s\n", item.When, item.Say)
+			match := item.Match
+			if match == "" {
+				match = MatchExact
+			}
+			responseType := item.Type
+			if responseType == "" {
+				responseType = ResponseText
+			}
+			formattedResponse += fmt.Sprintf("🔹 **%s** _(%s, %s)_: %s\n", item.When, match, responseType, item.Say)
 		}
 
 		sendError := interaction.RespondWithText(s, i, formattedResponse, true)
@@ -0,0 +1,162 @@
+package customCommands
+
+import (
+	"discord-bot/discord/audit"
+	"discord-bot/discord/components"
+	"discord-bot/discord/events"
+	"discord-bot/discord/interaction"
+	"discord-bot/firebase"
+	"discord-bot/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	// triggers waiting for their modal to be submitted, the key is the interaction ID of the "edit" command
+	pendingEdits      = map[string]string{}
+	pendingEditsMutex sync.Mutex
+)
+
+// discord closes the modal after 15 minutes
+const modalTimeout = 15 * time.Minute
+
+// the payload is the interaction ID of the "edit" command
+var editModalRoute = events.NewRoute[string]("custom_command_edit", 1)
+
+// openEditModal opens a modal pre-filled with the current values of a custom command
+func openEditModal(s *discordgo.Session, i *discordgo.InteractionCreate, item *firebase.CustomCommand) {
+	// the trigger can be longer than the custom ID limit
+	pendingEditsMutex.Lock()
+	pendingEdits[i.ID] = item.When
+	pendingEditsMutex.Unlock()
+
+	go func() {
+		defer events.RecoverEvent("customCommands:editExpiry")
+
+		if !events.Sleep(modalTimeout) {
+			return
+		}
+		pendingEditsMutex.Lock()
+		delete(pendingEdits, i.ID)
+		pendingEditsMutex.Unlock()
+	}()
+
+	sendError := interaction.RespondWithModal(s, i, editModalRoute.CustomID(i.ID), "Edit "+item.When,
+		components.NewRow(
+			components.NewTextInput().SetCustomID("response").SetLabel("Response").SetStyleParagraph().
+				SetPlaceholder("Supports placeholders like {user}, {args}, {random:a|b} and {count}").
+				SetValue(item.Say).SetRequired(false).SetMaxLength(4000),
+		),
+		components.NewRow(
+			components.NewTextInput().SetCustomID("title").SetLabel("Embed title").SetStyleShort().
+				SetValue(item.Title).SetRequired(false).SetMaxLength(256),
+		),
+		components.NewRow(
+			components.NewTextInput().SetCustomID("cooldown").SetLabel("Cooldown in seconds").SetStyleShort().
+				SetValue(strconv.Itoa(item.Cooldown)).SetRequired(false).SetMaxLength(10),
+		),
+		components.NewRow(
+			components.NewTextInput().SetCustomID("language").SetLabel("TTS language").SetStyleShort().
+				SetPlaceholder("en").SetValue(item.Lang).SetRequired(false).SetMaxLength(10),
+		),
+	)
+	if sendError != nil {
+		Log.Error("\nCustomCommands:", sendError.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
+	}
+}
+
+// onEditModalSubmit validates and saves the values of the edit modal
+func onEditModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.ModalSubmitInteractionData, interactionID string) {
+	respondError := func(text string, err error) {
+		Log.Debug(Log.Level.Error, text, err.Error())
+		sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while %s:\n`%s`", text, err.Error()), true)
+		if sendError != nil {
+			Log.Error("\nCustomCommands:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
+		}
+	}
+
+	pendingEditsMutex.Lock()
+	trigger, ok := pendingEdits[interactionID]
+	pendingEditsMutex.Unlock()
+	if !ok {
+		events.RespondExpired(s, i)
+		return
+	}
+
+	guildData, err := firebase.GetGuildData(i.GuildID)
+	if err != nil {
+		respondError("getting this guild data from firebase", err)
+		return
+	}
+
+	unlock := lockCommands(i.GuildID)
+	defer unlock()
+
+	currentItem, err := guildData.CustomCommandsGetItem(trigger)
+	if err != nil {
+		respondError("getting a custom-command item", err)
+		return
+	}
+
+	newItem := *currentItem
+	newItem.Say = interaction.GetModalValue(data, "response")
+	newItem.Title = strings.TrimSpace(interaction.GetModalValue(data, "title"))
+	newItem.Lang = strings.TrimSpace(interaction.GetModalValue(data, "language"))
+
+	cooldown := strings.TrimSpace(interaction.GetModalValue(data, "cooldown"))
+	newItem.Cooldown = 0
+	if cooldown != "" {
+		newItem.Cooldown, err = strconv.Atoi(cooldown)
+		if err != nil || newItem.Cooldown < 0 {
+			respondError("validating the cooldown", fmt.Errorf("the cooldown should be a positive number of seconds"))
+			return
+		}
+	}
+
+	err = ValidateResponse(newItem.Type, newItem.Say, newItem.File != "")
+	if err == nil {
+		err = ValidateTemplate(newItem.Say)
+	}
+	if err != nil {
+		respondError("validating the response", err)
+		return
+	}
+
+	guildData.CustomCommandsUpdateItem(newItem)
+	InvalidateMatchers(i.GuildID)
+
+	customCommandsMap := guildData.CustomCommandsToMap()
+	err = firebase.SetCustomCommand(i.GuildID, &customCommandsMap)
+	if err != nil {
+		// revoke changes on error
+		guildData.CustomCommandsUpdateItem(*currentItem)
+		respondError("uploading **custom-command (edit)** data to firebase", err)
+		return
+	}
+
+	pendingEditsMutex.Lock()
+	delete(pendingEdits, interactionID)
+	pendingEditsMutex.Unlock()
+
+	sendError := interaction.RespondWithText(s, i, "**Success:** Custom command updated", true)
+	if sendError != nil {
+		Log.Error("\nCustomCommands:", sendError.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
+	}
+
+	audit.Record(s, audit.Change{
+		GuildID: i.GuildID,
+		UserID:  utils.GetInteractionAuthor(i.Interaction).ID,
+		Action:  audit.ActionCustomCommandEdit,
+		Target:  trigger,
+		Before:  describeCommand(*currentItem),
+		After:   describeCommand(newItem),
+	})
+}
@@ -0,0 +1,73 @@
+package customCommands
+
+import (
+	"discord-bot/firebase"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Execute runs the custom command that matches the message content, returns false if none matched
+func Execute(s *discordgo.Session, m *discordgo.MessageCreate, guildData *firebase.FirebaseData, content string) bool {
+	compiled, rest, groups := findCommand(m.GuildID, m.ChannelID, guildData, content)
+	if compiled == nil {
+		return false
+	}
+
+	command := compiled.command
+	if onCooldown(m.GuildID, command) {
+		Log.Debug(Log.Level.Info, "CustomCommand on cooldown:", command.When, "GuildID:", m.GuildID)
+		return true
+	}
+
+	Log.Debug(Log.Level.Info, "CustomCommand:", command.When, "GuildID:", m.GuildID, "ChannelID:", m.ChannelID, "UserID:", m.Author.ID, "UserName:", m.Author.Username)
+
+	data := TemplateData{
+		Author:    m.Author,
+		ChannelID: m.ChannelID,
+		Args:      strings.Fields(rest),
+		Matches:   groups,
+		Count:     command.Counter,
+	}
+
+	guild, err := s.State.Guild(m.GuildID)
+	if err == nil {
+		data.GuildName = guild.Name
+	}
+
+	if UsesCounter(command.Say) {
+		data.Count = incrementCounter(m.GuildID, guildData, command.When)
+	}
+
+	response, err := RenderTemplate(command.Say, data)
+	if err != nil {
+		// commands added before templates may have invalid placeholders
+		response = command.Say
+	}
+
+	err = sendResponse(s, m, command, response)
+	if err != nil {
+		Log.Error("\nCustomCommands:", err.Error())
+		Log.Debug(Log.Level.Error, "sending a custom command response:", command.When, err.Error())
+	}
+
+	return true
+}
+
+// incrementCounter increments the counter of a command and saves it, the commands of the guild are locked so
+// concurrent messages and changes don't lose an update
+func incrementCounter(guildID string, guildData *firebase.FirebaseData, trigger string) int {
+	unlock := lockCommands(guildID)
+	defer unlock()
+
+	count := guildData.CustomCommandsIncrementCounter(trigger)
+
+	customCommandsMap := guildData.CustomCommandsToMap()
+	err := firebase.SetCustomCommand(guildID, &customCommandsMap)
+	if err != nil {
+		Log.Error("\nCustomCommands:", err.Error())
+		Log.Debug(Log.Level.Error, `uploading "custom-command" counter to firebase:`, err.Error())
+	}
+
+	return count
+}
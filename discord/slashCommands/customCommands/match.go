@@ -0,0 +1,297 @@
+package customCommands
+
+import (
+	"discord-bot/firebase"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type MatchMode = string
+
+const (
+	MatchExact      MatchMode = "exact"       // the whole message, followed by optional arguments
+	MatchIgnoreCase MatchMode = "ignore-case" // like exact, but case insensitive
+	MatchStartsWith MatchMode = "starts-with" // the message starts with the trigger
+	MatchContains   MatchMode = "contains"    // the trigger is anywhere in the message
+	MatchRegex      MatchMode = "regex"       // a regular expression, its groups can be used with `{match:N}`
+	MatchWildcard   MatchMode = "wildcard"    // the whole message, case insensitive, `*` and `?` can be used with `{match:N}`
+)
+
+// when many commands match, the mode with the lower rank wins
+var matchRanks = map[MatchMode]int{
+	MatchExact:      0,
+	MatchIgnoreCase: 1,
+	MatchStartsWith: 2,
+	MatchWildcard:   3,
+	MatchRegex:      4,
+	MatchContains:   5,
+}
+
+type compiledCommand struct {
+	command *firebase.CustomCommand // a copy, the cached guild commands can change after it's compiled
+	mode    MatchMode
+	regex   *regexp.Regexp
+}
+
+var (
+	// compiled commands of each guild, the key is the guild ID
+	matchersCache = map[string][]compiledCommand{}
+	matchersMutex sync.RWMutex
+
+	// last time a command was used, the key is [guildID+trigger]
+	lastUsed      = map[string]time.Time{}
+	lastUsedMutex sync.Mutex
+
+	// the custom commands of a guild are changed one at a time, the key is the guild ID
+	commandsLocks      = map[string]*sync.Mutex{}
+	commandsLocksMutex sync.Mutex
+)
+
+// lockCommands waits until the custom commands of a guild can be read or changed, the returned function must be
+// called when it's done, the matchers must be invalidated before it when the commands changed
+func lockCommands(guildID string) func() {
+	commandsLocksMutex.Lock()
+	lock, ok := commandsLocks[guildID]
+	if !ok {
+		lock = &sync.Mutex{}
+		commandsLocks[guildID] = lock
+	}
+	commandsLocksMutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// ValidateMatch checks the match mode and compiles the trigger if it's a regex
+func ValidateMatch(mode MatchMode, trigger string) error {
+	if mode == "" {
+		return nil
+	}
+
+	if _, ok := matchRanks[mode]; !ok {
+		return fmt.Errorf("unknown match mode: %s", mode)
+	}
+
+	if mode == MatchRegex {
+		_, err := regexp.Compile(trigger)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %s", err.Error())
+		}
+	}
+
+	// it would match every message
+	if mode == MatchWildcard && strings.Trim(trigger, "*? ") == "" {
+		return fmt.Errorf("the wildcard trigger should have a character that is not `*` or `?`")
+	}
+
+	return nil
+}
+
+// wildcardRegex turns a wildcard trigger into a regex of the whole message, each `*` and `?` is a group
+func wildcardRegex(trigger string) *regexp.Regexp {
+	var pattern strings.Builder
+	pattern.WriteString(`(?is)^`)
+	for _, char := range trigger {
+		switch char {
+		case '*':
+			pattern.WriteString(`(.*?)`)
+		case '?':
+			pattern.WriteString(`(.)`)
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	pattern.WriteString(`$`)
+
+	return regexp.MustCompile(pattern.String())
+}
+
+// InvalidateMatchers removes the compiled commands of a guild, it must be called after its custom commands change
+func InvalidateMatchers(guildID string) {
+	matchersMutex.Lock()
+	defer matchersMutex.Unlock()
+
+	delete(matchersCache, guildID)
+}
+
+func getMatchers(guildID string, guildData *firebase.FirebaseData) []compiledCommand {
+	matchersMutex.RLock()
+	matchers, ok := matchersCache[guildID]
+	matchersMutex.RUnlock()
+
+	if ok {
+		return matchers
+	}
+
+	// the commands can't change until the matchers are cached, so an invalidation is never overwritten
+	unlock := lockCommands(guildID)
+	defer unlock()
+
+	matchers = make([]compiledCommand, 0, len(guildData.CustomCommands))
+	for _, command := range guildData.CustomCommands {
+		compiled := compiledCommand{command: &command, mode: command.Match}
+		if compiled.mode == "" {
+			compiled.mode = MatchExact
+		}
+
+		if compiled.mode == MatchRegex {
+			regex, err := regexp.Compile(command.When)
+			if err != nil {
+				Log.Debug(Log.Level.Error, "compiling custom command regex:", command.When, err.Error())
+				continue
+			}
+			compiled.regex = regex
+		}
+
+		if compiled.mode == MatchWildcard {
+			compiled.regex = wildcardRegex(command.When)
+		}
+
+		matchers = append(matchers, compiled)
+	}
+
+	matchersMutex.Lock()
+	matchersCache[guildID] = matchers
+	matchersMutex.Unlock()
+
+	return matchers
+}
+
+// prefixFold checks if s starts with prefix ignoring the case, and returns the length of the prefix in s, it can
+// be different from the length of prefix, e.g. "K" (Kelvin sign) and "k"
+func prefixFold(s string, prefix string) (int, bool) {
+	end := 0
+	for _, prefixChar := range prefix {
+		if end >= len(s) {
+			return 0, false
+		}
+
+		char, size := utf8.DecodeRuneInString(s[end:])
+		if char != prefixChar && !strings.EqualFold(string(char), string(prefixChar)) {
+			return 0, false
+		}
+		end += size
+	}
+	return end, true
+}
+
+// indexFold returns the start and the end of the first instance of substr in s ignoring the case, -1 if there is none
+func indexFold(s string, substr string) (int, int) {
+	for start := range s {
+		if length, ok := prefixFold(s[start:], substr); ok {
+			return start, start + length
+		}
+	}
+	return -1, -1
+}
+
+// match returns the arguments after the trigger and the regex groups, false if the content doesn't match
+func (c *compiledCommand) match(content string) (args string, groups []string, ok bool) {
+	trigger := c.command.When
+
+	switch c.mode {
+	case MatchExact, MatchIgnoreCase:
+		end := len(trigger)
+		if c.mode == MatchExact && !strings.HasPrefix(content, trigger) {
+			return "", nil, false
+		}
+		if c.mode == MatchIgnoreCase {
+			length, ok := prefixFold(content, trigger)
+			if !ok {
+				return "", nil, false
+			}
+			end = length
+		}
+
+		rest := content[end:]
+		if rest != "" && !strings.HasPrefix(rest, " ") {
+			return "", nil, false
+		}
+		return rest, nil, true
+
+	case MatchStartsWith:
+		if end, ok := prefixFold(content, trigger); ok {
+			return content[end:], nil, true
+		}
+
+	case MatchContains:
+		if _, end := indexFold(content, trigger); end != -1 {
+			return content[end:], nil, true
+		}
+
+	case MatchRegex, MatchWildcard:
+		location := c.regex.FindStringSubmatchIndex(content)
+		if location != nil {
+			groups = make([]string, len(location)/2)
+			for group := range groups {
+				if location[group*2] >= 0 {
+					groups[group] = content[location[group*2]:location[group*2+1]]
+				}
+			}
+			return content[location[1]:], groups, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// allowedIn checks the channel allow and deny lists of a command
+func (c *compiledCommand) allowedIn(channelID string) bool {
+	if slices.Contains(c.command.DenyChannels, channelID) {
+		return false
+	}
+	return len(c.command.AllowChannels) == 0 || slices.Contains(c.command.AllowChannels, channelID)
+}
+
+// findCommand returns the best matching command for a message, the arguments and the regex groups
+func findCommand(guildID string, channelID string, guildData *firebase.FirebaseData, content string) (*compiledCommand, string, []string) {
+	var best *compiledCommand
+	var bestArgs string
+	var bestGroups []string
+
+	matchers := getMatchers(guildID, guildData)
+	for index := range matchers {
+		matcher := &matchers[index]
+
+		if !matcher.allowedIn(channelID) {
+			continue
+		}
+
+		args, groups, ok := matcher.match(content)
+		if !ok {
+			continue
+		}
+
+		// the best mode wins, then the longest trigger, e.g. "hello there" over "hello"
+		if best == nil ||
+			matchRanks[matcher.mode] < matchRanks[best.mode] ||
+			(matchRanks[matcher.mode] == matchRanks[best.mode] && len(matcher.command.When) > len(best.command.When)) {
+			best, bestArgs, bestGroups = matcher, args, groups
+		}
+	}
+
+	return best, bestArgs, bestGroups
+}
+
+// onCooldown checks if a command was used less than its cooldown ago, and marks it as used if not
+func onCooldown(guildID string, command *firebase.CustomCommand) bool {
+	if command.Cooldown <= 0 {
+		return false
+	}
+
+	lastUsedMutex.Lock()
+	defer lastUsedMutex.Unlock()
+
+	key := guildID + command.When
+	if time.Since(lastUsed[key]) < time.Duration(command.Cooldown)*time.Second {
+		return true
+	}
+
+	lastUsed[key] = time.Now()
+	return false
+}
@@ -0,0 +1,213 @@
+package customCommands
+
+import (
+	tts "discord-bot/TTS"
+	"discord-bot/discord/components"
+	"discord-bot/discord/events"
+	"discord-bot/firebase"
+	"discord-bot/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type ResponseType = string
+
+const (
+	ResponseText     ResponseType = "text"     // send the response as a message
+	ResponseEmbed    ResponseType = "embed"    // send the response as an embed description
+	ResponseReaction ResponseType = "reaction" // react to the trigger message with the emojis in the response
+	ResponseFile     ResponseType = "file"     // send the stored file, the response is used as a caption
+	ResponseTTS      ResponseType = "tts"      // speak the response in the author's voice channel
+)
+
+// max size of a stored file, the discord upload limit without nitro
+const maxFileSize = 8 * 1024 * 1024
+
+// matches custom emojis like <:name:id> and <a:name:id>
+var customEmojiRegex = regexp.MustCompile(`^<a?:(\w+:\d+)>$`)
+
+// ValidateResponse checks that a response fits its type
+func ValidateResponse(responseType ResponseType, response string, hasFile bool) error {
+	switch responseType {
+	case "", ResponseText, ResponseEmbed, ResponseTTS:
+		if strings.TrimSpace(response) == "" {
+			return fmt.Errorf("please enter a response")
+		}
+	case ResponseReaction:
+		if len(parseReactions(response)) == 0 {
+			return fmt.Errorf("please enter one or more emojis separated by spaces")
+		}
+	case ResponseFile:
+		if !hasFile {
+			return fmt.Errorf("please attach a file")
+		}
+	default:
+		return fmt.Errorf("unknown response type: %s", responseType)
+	}
+
+	return nil
+}
+
+// parseReactions converts the emojis of a response to the format used by the reactions API
+func parseReactions(response string) []string {
+	var emojis []string
+	for _, emoji := range strings.Fields(response) {
+		if match := customEmojiRegex.FindStringSubmatch(emoji); match != nil {
+			emoji = match[1]
+		}
+		emojis = append(emojis, emoji)
+	}
+	return emojis
+}
+
+func filesDir() string {
+	return utils.GetAppConfig().CustomCommands.FilesDir
+}
+
+// storeFile downloads an attachment into the files directory and returns its path relative to it
+func storeFile(guildID string, attachment *discordgo.MessageAttachment) (string, error) {
+	data, err := utils.DownloadAttachment(attachment, maxFileSize)
+	if err != nil {
+		return "", err
+	}
+
+	relativePath := filepath.Join(guildID, attachment.ID+"_"+filepath.Base(attachment.Filename))
+	fullPath := filepath.Join(filesDir(), relativePath)
+
+	err = os.MkdirAll(filepath.Dir(fullPath), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.WriteFile(fullPath, data, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	return relativePath, nil
+}
+
+// guildFilePath returns the full path of a stored file, the file must be in the directory of the guild so a command
+// can't point to the bot config or to the files of another guild
+func guildFilePath(guildID string, relativePath string) (string, error) {
+	if guildID == "" || !filepath.IsLocal(relativePath) || !strings.HasPrefix(filepath.Clean(relativePath), guildID+string(filepath.Separator)) {
+		return "", fmt.Errorf("the file should be in the files directory of this server")
+	}
+	return filepath.Join(filesDir(), relativePath), nil
+}
+
+// removeFile deletes a stored file, it's not an error if it doesn't exist
+func removeFile(guildID string, relativePath string) {
+	if relativePath == "" {
+		return
+	}
+
+	fullPath, err := guildFilePath(guildID, relativePath)
+	if err == nil {
+		err = os.Remove(fullPath)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		Log.Error("\nCustomCommands:", err.Error())
+		Log.Debug(Log.Level.Error, "removing a custom command file:", err.Error())
+	}
+}
+
+// sendResponse sends a rendered response based on the command response type
+func sendResponse(s *discordgo.Session, m *discordgo.MessageCreate, command *firebase.CustomCommand, response string) error {
+	switch command.Type {
+	case ResponseEmbed:
+		embed := components.NewEmbed().SetColor(0x0099ff).SetDescription(response)
+		if command.Title != "" {
+			embed.SetTitle(command.Title)
+		}
+		_, err := s.ChannelMessageSendEmbed(m.ChannelID, embed.Truncate().Into())
+		return err
+
+	case ResponseReaction:
+		for _, emoji := range parseReactions(response) {
+			err := s.MessageReactionAdd(m.ChannelID, m.ID, emoji)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case ResponseFile:
+		fullPath, err := guildFilePath(m.GuildID, command.File)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(fullPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		// remove the attachment ID prefix
+		_, name, _ := strings.Cut(filepath.Base(command.File), "_")
+
+		_, err = s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+			Content: response,
+			Files:   []*discordgo.File{{Name: name, Reader: file}},
+		})
+		return err
+
+	case ResponseTTS:
+		return speakInVoiceChannel(s, m.GuildID, m.Author.ID, response, command.Lang)
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, response)
+	return err
+}
+
+// speakInVoiceChannel joins the user's voice channel in a guild and speaks the message
+func speakInVoiceChannel(s *discordgo.Session, guildID string, userID string, message string, lang string) error {
+	vs, err := s.State.VoiceState(guildID, userID)
+	if err != nil {
+		return fmt.Errorf("the user is not in a voice channel")
+	}
+
+	if lang == "" {
+		lang = "en"
+	}
+
+	unlock := events.LockVoice(guildID)
+	defer unlock()
+
+	vc, err := s.ChannelVoiceJoin(guildID, vs.ChannelID, false, true)
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	vc.Speaking(true)
+
+	err = tts.GenerateAndSendToVoiceChannel(message, vc, tts.TTSOptions{Lang: lang, Slow: false})
+
+	vc.Speaking(false)
+	time.Sleep(250 * time.Millisecond)
+	vc.Disconnect()
+
+	return err
+}
+
+// describeCommand formats the response of a command for the audit log, like "[text] Hello {user}"
+func describeCommand(item firebase.CustomCommand) string {
+	responseType := item.Type
+	if responseType == "" {
+		responseType = ResponseText
+	}
+
+	description := fmt.Sprintf("[%s] %s", responseType, item.Say)
+	if item.File != "" {
+		description += fmt.Sprintf(" (file %s)", filepath.Base(item.File))
+	}
+	return description
+}
@@ -0,0 +1,198 @@
+package customCommands
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TemplateData is the context used to fill a custom command response
+type TemplateData struct {
+	Author    *discordgo.User
+	ChannelID string
+	GuildName string
+	Args      []string
+	Matches   []string // regex groups of the trigger, the first one is the whole match
+	Count     int
+}
+
+type templateNode struct {
+	text  string // literal text, used when name is empty
+	name  string // placeholder name
+	param string // text after ":" in the placeholder
+}
+
+// the placeholders that can be used in a response, with their description
+var placeholders = map[string]string{
+	"user":      "mention of the message author",
+	"user.name": "username of the message author",
+	"channel":   "mention of the current channel",
+	"guild":     "name of the server",
+	"args":      "all the words after the trigger",
+	"arg":       "a word after the trigger, e.g. `{arg:1}`",
+	"match":     "a regex trigger group, e.g. `{match:1}`",
+	"date":      "the current date",
+	"time":      "the current time",
+	"random":    "a random choice, e.g. `{random:yes|no|maybe}`",
+	"count":     "how many times the command was used",
+}
+
+// placeholders that require a parameter
+var requiredParams = map[string]bool{"arg": true, "match": true, "random": true}
+
+// parseTemplate splits a response into literal text and placeholders like `{name}` or `{name:param}`,
+// "{{" and "}}" are used for literal braces
+func parseTemplate(template string) ([]templateNode, error) {
+	var nodes []templateNode
+	var text strings.Builder
+
+	for index := 0; index < len(template); index++ {
+		char := template[index]
+
+		if char == '}' {
+			if index+1 < len(template) && template[index+1] == '}' {
+				text.WriteByte('}')
+				index++
+				continue
+			}
+			return nil, fmt.Errorf("unexpected '}' at position %d, use '}}' for a literal brace", index+1)
+		}
+
+		if char != '{' {
+			text.WriteByte(char)
+			continue
+		}
+
+		if index+1 < len(template) && template[index+1] == '{' {
+			text.WriteByte('{')
+			index++
+			continue
+		}
+
+		end := strings.IndexByte(template[index:], '}')
+		if end == -1 {
+			return nil, fmt.Errorf("missing '}' for the placeholder at position %d", index+1)
+		}
+
+		if text.Len() > 0 {
+			nodes = append(nodes, templateNode{text: text.String()})
+			text.Reset()
+		}
+
+		name, param, hasParam := strings.Cut(template[index+1:index+end], ":")
+		name = strings.TrimSpace(name)
+
+		if _, ok := placeholders[name]; !ok {
+			return nil, fmt.Errorf("unknown placeholder '{%s}'", name)
+		}
+		if requiredParams[name] && (!hasParam || param == "") {
+			return nil, fmt.Errorf("placeholder '{%s}' requires a value, e.g. '{%s:...}'", name, name)
+		}
+		if name == "arg" {
+			position, err := strconv.Atoi(param)
+			if err != nil || position < 1 {
+				return nil, fmt.Errorf("placeholder '{arg}' requires a position starting from 1, e.g. '{arg:1}'")
+			}
+		}
+		if name == "match" {
+			group, err := strconv.Atoi(param)
+			if err != nil || group < 0 {
+				return nil, fmt.Errorf("placeholder '{match}' requires a group number starting from 0, e.g. '{match:1}'")
+			}
+		}
+
+		nodes = append(nodes, templateNode{name: name, param: param})
+		index += end
+	}
+
+	if text.Len() > 0 {
+		nodes = append(nodes, templateNode{text: text.String()})
+	}
+
+	return nodes, nil
+}
+
+// ValidateTemplate checks that a response only uses known placeholders
+func ValidateTemplate(template string) error {
+	_, err := parseTemplate(template)
+	return err
+}
+
+// UsesCounter checks if a response uses the `{count}` placeholder
+func UsesCounter(template string) bool {
+	nodes, err := parseTemplate(template)
+	if err != nil {
+		return false
+	}
+
+	for _, node := range nodes {
+		if node.name == "count" {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderTemplate fills the placeholders of a response
+func RenderTemplate(template string, data TemplateData) (string, error) {
+	nodes, err := parseTemplate(template)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	now := time.Now()
+
+	for _, node := range nodes {
+		switch node.name {
+		case "":
+			result.WriteString(node.text)
+		case "user":
+			result.WriteString(data.Author.Mention())
+		case "user.name":
+			result.WriteString(data.Author.Username)
+		case "channel":
+			result.WriteString("<#" + data.ChannelID + ">")
+		case "guild":
+			result.WriteString(data.GuildName)
+		case "args":
+			result.WriteString(strings.Join(data.Args, " "))
+		case "arg":
+			position, _ := strconv.Atoi(node.param)
+			if position <= len(data.Args) {
+				result.WriteString(data.Args[position-1])
+			}
+		case "match":
+			group, _ := strconv.Atoi(node.param)
+			if group < len(data.Matches) {
+				result.WriteString(data.Matches[group])
+			}
+		case "date":
+			result.WriteString(now.Format("2006-01-02"))
+		case "time":
+			result.WriteString(now.Format("15:04"))
+		case "random":
+			choices := strings.Split(node.param, "|")
+			result.WriteString(strings.TrimSpace(choices[rand.Intn(len(choices))]))
+		case "count":
+			result.WriteString(strconv.Itoa(data.Count))
+		}
+	}
+
+	return result.String(), nil
+}
+
+// PlaceholdersHelp returns a list of the available placeholders for the users
+func PlaceholdersHelp() string {
+	names := []string{"user", "user.name", "channel", "guild", "args", "arg", "match", "date", "time", "random", "count"}
+
+	help := ""
+	for _, name := range names {
+		help += fmt.Sprintf("🔹 `{%s}` %s\n", name, placeholders[name])
+	}
+	return help
+}
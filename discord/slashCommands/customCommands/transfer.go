@@ -0,0 +1,352 @@
+package customCommands
+
+import (
+	"bytes"
+	"discord-bot/discord/audit"
+	"discord-bot/discord/interaction"
+	"discord-bot/firebase"
+	"discord-bot/utils"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"gopkg.in/yaml.v3"
+)
+
+// the version of the export file schema
+const exportVersion = 1
+
+// max size of an import file
+const maxImportSize = 1024 * 1024
+
+type ImportMode = string
+
+const (
+	ImportMerge   ImportMode = "merge"   // add new commands and update existing ones
+	ImportReplace ImportMode = "replace" // the file replaces all the commands
+)
+
+// ExportFile is the schema of the exported custom commands
+type ExportFile struct {
+	Version  int             `json:"version" yaml:"version"`
+	Commands []ExportCommand `json:"commands" yaml:"commands"`
+}
+
+type ExportCommand struct {
+	Trigger       string   `json:"trigger" yaml:"trigger"`
+	Response      string   `json:"response" yaml:"response"`
+	Match         string   `json:"match,omitempty" yaml:"match,omitempty"`
+	Type          string   `json:"type,omitempty" yaml:"type,omitempty"`
+	Title         string   `json:"title,omitempty" yaml:"title,omitempty"`
+	File          string   `json:"file,omitempty" yaml:"file,omitempty"`
+	Lang          string   `json:"lang,omitempty" yaml:"lang,omitempty"`
+	Cooldown      int      `json:"cooldown,omitempty" yaml:"cooldown,omitempty"`
+	AllowChannels []string `json:"allowChannels,omitempty" yaml:"allowChannels,omitempty"`
+	DenyChannels  []string `json:"denyChannels,omitempty" yaml:"denyChannels,omitempty"`
+}
+
+func toExportCommand(item firebase.CustomCommand) ExportCommand {
+	return ExportCommand{
+		Trigger:       item.When,
+		Response:      item.Say,
+		Match:         item.Match,
+		Type:          item.Type,
+		Title:         item.Title,
+		File:          item.File,
+		Lang:          item.Lang,
+		Cooldown:      item.Cooldown,
+		AllowChannels: item.AllowChannels,
+		DenyChannels:  item.DenyChannels,
+	}
+}
+
+func (c ExportCommand) toCustomCommand(counter int) firebase.CustomCommand {
+	return firebase.CustomCommand{
+		When:          c.Trigger,
+		Say:           c.Response,
+		Counter:       counter,
+		Match:         c.Match,
+		Type:          c.Type,
+		Title:         c.Title,
+		File:          c.File,
+		Lang:          c.Lang,
+		Cooldown:      c.Cooldown,
+		AllowChannels: c.AllowChannels,
+		DenyChannels:  c.DenyChannels,
+	}
+}
+
+func (c ExportCommand) equal(other ExportCommand) bool {
+	return c.Trigger == other.Trigger && c.Response == other.Response && c.Match == other.Match &&
+		c.Type == other.Type && c.Title == other.Title && c.File == other.File && c.Lang == other.Lang &&
+		c.Cooldown == other.Cooldown &&
+		slices.Equal(c.AllowChannels, other.AllowChannels) && slices.Equal(c.DenyChannels, other.DenyChannels)
+}
+
+// validate checks a command the same way "/custom-command add" does
+func (c ExportCommand) validate(guildID string) error {
+	if strings.TrimSpace(c.Trigger) == "" {
+		return fmt.Errorf("missing trigger")
+	}
+	if c.Cooldown < 0 {
+		return fmt.Errorf("cooldown should not be negative")
+	}
+
+	err := ValidateMatch(c.Match, c.Trigger)
+	if err != nil {
+		return err
+	}
+
+	hasFile := false
+	if c.File != "" {
+		fullPath, err := guildFilePath(guildID, c.File)
+		if err != nil {
+			return err
+		}
+		hasFile = utils.FileExists(fullPath)
+	}
+
+	err = ValidateResponse(c.Type, c.Response, hasFile)
+	if err != nil {
+		return err
+	}
+
+	return ValidateTemplate(c.Response)
+}
+
+// parseImportFile decodes a json or yaml export file and validates every command in it for a guild
+func parseImportFile(guildID string, name string, data []byte) (*ExportFile, error) {
+	var file ExportFile
+	var err error
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+	default:
+		return nil, fmt.Errorf("unsupported file type, please use a .json, .yaml or .yml file")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid file: %s", err.Error())
+	}
+
+	if file.Version != exportVersion {
+		return nil, fmt.Errorf("unsupported version %d, expected %d", file.Version, exportVersion)
+	}
+
+	var problems []string
+	seen := map[string]bool{}
+	for index, command := range file.Commands {
+		if seen[command.Trigger] {
+			problems = append(problems, fmt.Sprintf("#%d \"%s\": duplicated trigger", index+1, command.Trigger))
+			continue
+		}
+		seen[command.Trigger] = true
+
+		err := command.validate(guildID)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("#%d \"%s\": %s", index+1, command.Trigger, err.Error()))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "\n"))
+	}
+
+	return &file, nil
+}
+
+type importDiff struct {
+	added     []string
+	changed   []string
+	removed   []string
+	unchanged int
+	commands  []firebase.CustomCommand // the commands after the import
+}
+
+// diffImport compares the current commands with the imported ones
+func diffImport(current []firebase.CustomCommand, file *ExportFile, mode ImportMode) importDiff {
+	diff := importDiff{}
+
+	currentByTrigger := map[string]firebase.CustomCommand{}
+	for _, item := range current {
+		currentByTrigger[item.When] = item
+	}
+
+	imported := map[string]bool{}
+	for _, command := range file.Commands {
+		imported[command.Trigger] = true
+
+		item, exists := currentByTrigger[command.Trigger]
+		switch {
+		case !exists:
+			diff.added = append(diff.added, command.Trigger)
+		case !toExportCommand(item).equal(command):
+			diff.changed = append(diff.changed, command.Trigger)
+		default:
+			diff.unchanged++
+		}
+	}
+
+	// keep the order of the current commands, then append the new ones
+	for _, item := range current {
+		if !imported[item.When] {
+			if mode == ImportReplace {
+				diff.removed = append(diff.removed, item.When)
+			} else {
+				diff.commands = append(diff.commands, item)
+			}
+		}
+	}
+	for _, command := range file.Commands {
+		diff.commands = append(diff.commands, command.toCustomCommand(currentByTrigger[command.Trigger].Counter))
+	}
+
+	return diff
+}
+
+func (d importDiff) String() string {
+	formatList := func(title string, triggers []string) string {
+		if len(triggers) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("**%s (%d):** `%s`\n", title, len(triggers), strings.Join(triggers, "`, `"))
+	}
+
+	return formatList("Added", d.added) +
+		formatList("Changed", d.changed) +
+		formatList("Removed", d.removed) +
+		fmt.Sprintf("**Unchanged:** `%d`\n", d.unchanged)
+}
+
+// exportCommands sends the custom commands of the guild as a json or yaml file
+func exportCommands(s *discordgo.Session, i *discordgo.InteractionCreate, guildData *firebase.FirebaseData, format string) {
+	file := ExportFile{Version: exportVersion, Commands: []ExportCommand{}}
+	for _, item := range guildData.CustomCommands {
+		file.Commands = append(file.Commands, toExportCommand(item))
+	}
+
+	var data []byte
+	var err error
+	if format == "yaml" {
+		data, err = yaml.Marshal(file)
+	} else {
+		format = "json"
+		data, err = json.MarshalIndent(file, "", "  ")
+	}
+
+	if err != nil {
+		Log.Debug(Log.Level.Error, `encoding "custom-command" export file:`, err.Error())
+		sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while encoding the custom commands:\n`%s`", err.Error()), true)
+		if sendError != nil {
+			Log.Error("\nCustomCommands:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
+		}
+		return
+	}
+
+	sendError := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Content: fmt.Sprintf("Exported `%d` custom commands.", len(file.Commands)),
+			Files:   []*discordgo.File{{Name: "custom-commands." + format, Reader: bytes.NewReader(data)}},
+		},
+	})
+	if sendError != nil {
+		Log.Error("\nCustomCommands:", sendError.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
+	}
+}
+
+// importCommands validates an export file, shows the diff and saves the commands unless it's a dry run
+func importCommands(s *discordgo.Session, i *discordgo.InteractionCreate, guildData *firebase.FirebaseData, attachment *discordgo.MessageAttachment, mode ImportMode, dryRun bool) {
+	sendError := interaction.RespondWithThinking(s, i, true)
+	if sendError != nil {
+		Log.Error("\nCustomCommands:", sendError.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
+		return
+	}
+
+	respondEdit := func(content string) {
+		// discord message limit
+		content = utils.Truncate(content, 2000)
+
+		sendError := interaction.RespondEdit(s, i, content)
+		if sendError != nil {
+			Log.Error("\nCustomCommands:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "custom-command" command:`, sendError.Error())
+		}
+	}
+
+	if attachment == nil {
+		respondEdit("**Error:** while importing the custom commands:\n`attachment not found`")
+		return
+	}
+
+	data, err := utils.DownloadAttachment(attachment, maxImportSize)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `downloading "custom-command" import file:`, err.Error())
+		respondEdit(fmt.Sprintf("**Error:** while downloading the import file:\n`%s`", err.Error()))
+		return
+	}
+
+	file, err := parseImportFile(i.GuildID, attachment.Filename, data)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `validating "custom-command" import file:`, err.Error())
+		respondEdit(fmt.Sprintf("**Error:** while validating the import file:\n```\n%s\n```", err.Error()))
+		return
+	}
+
+	unlock := lockCommands(i.GuildID)
+	defer unlock()
+
+	diff := diffImport(guildData.CustomCommands, file, mode)
+
+	if dryRun {
+		respondEdit(fmt.Sprintf("**Dry run** _(%s)_, nothing was saved:\n\u200b\n%s", mode, diff.String()))
+		return
+	}
+
+	defer InvalidateMatchers(i.GuildID) // the commands changed, compile them again
+
+	previousCommands := guildData.CustomCommands
+	guildData.CustomCommands = diff.commands
+
+	customCommandsMap := guildData.CustomCommandsToMap()
+	err = firebase.SetCustomCommand(i.GuildID, &customCommandsMap)
+	if err != nil {
+		// revoke changes on error
+		guildData.CustomCommands = previousCommands
+
+		Log.Debug(Log.Level.Error, `uploading "custom-command (import)" data to firebase:`, err.Error())
+		respondEdit(fmt.Sprintf("**Error:** while uploading **custom-command (import)** data to firebase:\n`%s`", err.Error()))
+		return
+	}
+
+	// the files of the removed commands are not used anymore
+	for _, item := range previousCommands {
+		if slices.Contains(diff.removed, item.When) {
+			removeFile(i.GuildID, item.File)
+		}
+	}
+
+	respondEdit(fmt.Sprintf("**Success:** Custom commands imported _(%s)_\n\u200b\n%s", mode, diff.String()))
+
+	audit.Record(s, audit.Change{
+		GuildID: i.GuildID,
+		UserID:  utils.GetInteractionAuthor(i.Interaction).ID,
+		Action:  audit.ActionCustomCommandImport,
+		Target:  attachment.Filename,
+		After:   fmt.Sprintf("%s mode, %d added, %d changed, %d removed", mode, len(diff.added), len(diff.changed), len(diff.removed)),
+	})
+}
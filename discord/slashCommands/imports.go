@@ -1,11 +1,14 @@
 package slashCommands
 
 import (
+	_ "discord-bot/discord/slashCommands/audit"
 	_ "discord-bot/discord/slashCommands/botActivity"
+	_ "discord-bot/discord/slashCommands/cooldown"
 	_ "discord-bot/discord/slashCommands/customCommands"
 	_ "discord-bot/discord/slashCommands/memeMe"
 	_ "discord-bot/discord/slashCommands/prefixCommand"
 	_ "discord-bot/discord/slashCommands/sayCommand"
+	_ "discord-bot/discord/slashCommands/schedule"
 	_ "discord-bot/discord/slashCommands/torrent"
 	_ "discord-bot/discord/slashCommands/welcomeVoiceMessage"
 	_ "discord-bot/discord/slashCommands/yts"
@@ -5,6 +5,7 @@ import (
 	"discord-bot/common"
 	"discord-bot/discord/events"
 	"discord-bot/discord/interaction"
+	"discord-bot/ratelimit"
 	"discord-bot/utils"
 	"encoding/json"
 	"errors"
@@ -12,6 +13,7 @@ import (
 	"image"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/fogleman/gg"
@@ -41,7 +43,9 @@ var command = common.SlashCommand{
 		},
 	},
 
-	Handler: cmdHandler,
+	Feature:  common.FeatureMemeMe,
+	Cooldown: 5 * time.Second,
+	Handler:  cmdHandler,
 }
 
 func init() {
@@ -172,6 +176,12 @@ func loadImgFromUrl(url string) (image.Image, error) {
 func createMemeImage(meme *common.Meme, firstLine *string, secondLine *string) (*bytes.Buffer, error) {
 	var imageBuffer bytes.Buffer
 
+	err := ratelimit.Images.Wait(events.Context())
+	if err != nil {
+		return &imageBuffer, err
+	}
+	defer ratelimit.Images.Release()
+
 	// load the image from url
 	img, err := loadImgFromUrl(meme.Url)
 	if err != nil {
@@ -2,6 +2,7 @@ package prefixCommand
 
 import (
 	"discord-bot/common"
+	"discord-bot/discord/audit"
 	"discord-bot/discord/events"
 	"discord-bot/discord/interaction"
 	"discord-bot/firebase"
@@ -39,6 +40,7 @@ var command = common.SlashCommand{
 		},
 	},
 
+	Feature: common.FeaturePrefixCommand,
 	Handler: cmdHandler,
 }
 
@@ -108,6 +110,8 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 
 	// * SET
 	if options.subcommand == "set" {
+		oldPrefix := guildData.Prefix
+
 		err := firebase.SetCommandPrefix(i.GuildID, options.prefix)
 		if err != nil {
 			Log.Debug(Log.Level.Error, `uploading "prefix (set)" data to firebase:`, err.Error())
@@ -115,8 +119,8 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 			if sendError != nil {
 				Log.Error("\nprefixCommand:", sendError.Error())
 				Log.Debug(Log.Level.Error, `sending a respond for "prefix" command:`, sendError.Error())
-				return
 			}
+			return
 		}
 
 		sendError := interaction.RespondWithText(s, i, "**Success:** Prefix set to: "+options.prefix, true)
@@ -125,6 +129,13 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 			Log.Debug(Log.Level.Error, `sending a respond for "prefix" command:`, sendError.Error())
 		}
 
+		audit.Record(s, audit.Change{
+			GuildID: i.GuildID,
+			UserID:  user.ID,
+			Action:  audit.ActionPrefixSet,
+			Before:  oldPrefix,
+			After:   options.prefix,
+		})
 		return
 	}
 
@@ -67,7 +67,9 @@ var command = common.SlashCommand{
 		},
 	},
 
-	Handler: cmdHandler,
+	Feature:  common.FeatureSay,
+	Cooldown: 5 * time.Second,
+	Handler:  cmdHandler,
 }
 
 func init() {
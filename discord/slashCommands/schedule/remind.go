@@ -0,0 +1,387 @@
+package schedule
+
+import (
+	"discord-bot/common"
+	"discord-bot/discord/events"
+	"discord-bot/discord/interaction"
+	"discord-bot/firebase"
+	"discord-bot/scheduler"
+	"discord-bot/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// the max number of pending reminders per user in a guild
+const maxRemindersPerUser = 25
+
+var remindCommand = common.SlashCommand{
+	Command: discordgo.ApplicationCommand{
+		Name:        "remind",
+		Description: "Remind you of something later",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "set",
+				Description: "Set a reminder",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "when",
+						Description: "e.g. 10m, 2h 30m, 15:30, tomorrow 9am, 2024-12-31 18:00",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+					{
+						Name:        "message",
+						Description: "What to remind you of",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						MaxLength:   1000,
+					},
+					{
+						Name:        "tts",
+						Description: "Also say it in your voice channel",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Required:    false,
+					},
+					{
+						Name:        "language",
+						Description: "The TTS language code, e.g. en, de, ar (default: en)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+					},
+				},
+			},
+			{
+				Name:        "list",
+				Description: "Show your reminders",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "cancel",
+				Description: "Cancel one of your reminders",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "id",
+						Description: "The reminder ID, see /remind list",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "timezone",
+				Description: "Set/show your timezone, used for absolute times",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "name",
+						Description: "e.g. Europe/Berlin, America/New_York, UTC",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+					},
+				},
+			},
+		},
+	},
+
+	Feature: common.FeatureSchedule,
+	Handler: remindHandler,
+}
+
+func init() {
+	events.RegisterSlashCommand(&remindCommand)
+}
+
+type remindOptions struct {
+	subcommand string // "set", "list", "cancel" or "timezone"
+	when       string // required for "set"
+	message    string // required for "set"
+	tts        bool
+	lang       string
+	id         string // required for "cancel"
+	timezone   string // optional for "timezone", shows the current one if empty
+}
+
+func parseRemindOptions(options []*discordgo.ApplicationCommandInteractionDataOption) (remindOptions, error) {
+	results := remindOptions{}
+
+	subcommand := options[0].Name
+	subcommandOptions := options[0].Options
+
+	if subcommand == "set" {
+		results.subcommand = "set"
+
+		for _, opt := range subcommandOptions {
+			switch opt.Name {
+			case "when":
+				val, err := utils.CheckOptionStringValue(opt)
+				if err != nil {
+					return results, fmt.Errorf("please enter when to remind you")
+				}
+				results.when = val
+
+			case "message":
+				val, err := utils.CheckOptionStringValue(opt)
+				if err != nil {
+					return results, fmt.Errorf("please enter a message")
+				}
+				results.message = val
+
+			case "tts":
+				results.tts = opt.BoolValue()
+
+			case "language":
+				results.lang = strings.ToLower(opt.StringValue())
+			}
+		}
+	}
+
+	if subcommand == "list" {
+		results.subcommand = "list"
+	}
+
+	if subcommand == "cancel" {
+		results.subcommand = "cancel"
+
+		for _, opt := range subcommandOptions {
+			switch opt.Name {
+			case "id":
+				val, err := utils.CheckOptionStringValue(opt)
+				if err != nil {
+					return results, fmt.Errorf("please enter a reminder ID")
+				}
+				results.id = strings.ToLower(val)
+			}
+		}
+	}
+
+	if subcommand == "timezone" {
+		results.subcommand = "timezone"
+
+		for _, opt := range subcommandOptions {
+			switch opt.Name {
+			case "name":
+				results.timezone = strings.TrimSpace(opt.StringValue())
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// respond sends an ephemeral text respond and logs the errors
+func respond(s *discordgo.Session, i *discordgo.InteractionCreate, commandName string, text string) {
+	sendError := interaction.RespondWithText(s, i, text, true)
+	if sendError != nil {
+		Log.Error("\n"+commandName+":", sendError.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "`+commandName+`" command:`, sendError.Error())
+	}
+}
+
+// userTimezone returns the saved timezone of a user, UTC if not set
+func userTimezone(userID string) (string, *time.Location, error) {
+	userData, err := firebase.GetUserData(userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	location, err := scheduler.LoadTimezone(userData.Timezone)
+	if err != nil {
+		// the saved timezone is no longer valid
+		return "", time.UTC, nil
+	}
+
+	return userData.Timezone, location, nil
+}
+
+func remindHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData) {
+	user := utils.GetInteractionAuthor(i.Interaction)
+
+	Log.Debug(Log.Level.Info, `SlashCommand: "remind", GuildID:`, i.GuildID, "ChannelID:", i.ChannelID, "UserID:", user.ID, "UserName:", user.Username)
+
+	options, err := parseRemindOptions(appData.Options)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `parsing "remind" command options:`, err.Error())
+		respond(s, i, "remind", fmt.Sprintf("**Error:** while parsing **remind** command options:\n`%s`", err.Error()))
+		return
+	}
+
+	// * TIMEZONE
+	if options.subcommand == "timezone" {
+		if options.timezone == "" {
+			timezone, _, err := userTimezone(user.ID)
+			if err != nil {
+				Log.Debug(Log.Level.Error, `getting user firebase data for "remind" command:`, err.Error())
+				respond(s, i, "remind", fmt.Sprintf("**Error:** while getting your data from firebase:\n`%s`", err.Error()))
+				return
+			}
+
+			if timezone == "" {
+				timezone = "UTC"
+			}
+			respond(s, i, "remind", fmt.Sprintf("Your timezone is: `%s`", timezone))
+			return
+		}
+
+		location, err := scheduler.LoadTimezone(options.timezone)
+		if err != nil {
+			respond(s, i, "remind", fmt.Sprintf("**Error:** %s", err.Error()))
+			return
+		}
+
+		err = firebase.SetUserTimezone(user.ID, location.String())
+		if err != nil {
+			Log.Debug(Log.Level.Error, `uploading "remind (timezone)" data to firebase:`, err.Error())
+			respond(s, i, "remind", fmt.Sprintf("**Error:** while uploading **remind (timezone)** data to firebase:\n`%s`", err.Error()))
+			return
+		}
+
+		now := time.Now().In(location)
+		respond(s, i, "remind", fmt.Sprintf("**Success:** Timezone set to: `%s`, your time is %s", location.String(), now.Format("15:04")))
+		return
+	}
+
+	guildData, err := firebase.GetGuildData(i.GuildID)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `getting guild firebase data for "remind" command:`, err.Error())
+		respond(s, i, "remind", fmt.Sprintf("**Error:** while getting this guild data from firebase:\n`%s`", err.Error()))
+		return
+	}
+
+	// * SET
+	if options.subcommand == "set" {
+		_, location, err := userTimezone(user.ID)
+		if err != nil {
+			Log.Debug(Log.Level.Error, `getting user firebase data for "remind" command:`, err.Error())
+			respond(s, i, "remind", fmt.Sprintf("**Error:** while getting your data from firebase:\n`%s`", err.Error()))
+			return
+		}
+
+		at, err := scheduler.ParseWhen(options.when, time.Now().In(location))
+		if err != nil {
+			respond(s, i, "remind", fmt.Sprintf("**Error:** %s", err.Error()))
+			return
+		}
+
+		if !at.After(time.Now()) {
+			respond(s, i, "remind", fmt.Sprintf("**Error:** <t:%d:f> is in the past", at.Unix()))
+			return
+		}
+
+		newReminder := firebase.Reminder{
+			ID:        newID(),
+			UserID:    user.ID,
+			ChannelID: i.ChannelID,
+			Message:   options.message,
+			At:        at.Unix(),
+			TTS:       options.tts,
+			Lang:      options.lang,
+		}
+
+		mutex.Lock()
+
+		count := 0
+		for _, reminder := range guildData.Reminders {
+			if reminder.UserID == user.ID {
+				count++
+			}
+		}
+		if count >= maxRemindersPerUser {
+			mutex.Unlock()
+			respond(s, i, "remind", fmt.Sprintf("**Error:** you can't have more than %d reminders, cancel some of them first", maxRemindersPerUser))
+			return
+		}
+
+		guildData.Reminders = append(guildData.Reminders, newReminder)
+
+		remindersMap := guildData.RemindersToMap()
+		err = firebase.SetReminders(i.GuildID, &remindersMap)
+		if err != nil {
+			// revoke changes on error
+			guildData.RemindersRemoveItem(newReminder.ID)
+		}
+
+		mutex.Unlock()
+
+		if err != nil {
+			Log.Debug(Log.Level.Error, `uploading "remind (set)" data to firebase:`, err.Error())
+			respond(s, i, "remind", fmt.Sprintf("**Error:** while uploading **remind (set)** data to firebase:\n`%s`", err.Error()))
+			return
+		}
+
+		respond(s, i, "remind", fmt.Sprintf("**Success:** I will remind you <t:%d:R> (<t:%d:f>), ID: `%s`", newReminder.At, newReminder.At, newReminder.ID))
+		return
+	}
+
+	// * LIST
+	if options.subcommand == "list" {
+		lines := []string{}
+
+		mutex.Lock()
+		for _, reminder := range guildData.Reminders {
+			if reminder.UserID != user.ID {
+				continue
+			}
+
+			tts := ""
+			if reminder.TTS {
+				tts = " 🔊"
+			}
+			lines = append(lines, fmt.Sprintf("`%s` <t:%d:f> (<t:%d:R>)%s: %s", reminder.ID, reminder.At, reminder.At, tts, reminder.Message))
+		}
+		mutex.Unlock()
+
+		if len(lines) == 0 {
+			respond(s, i, "remind", "You don't have any reminders.")
+			return
+		}
+
+		text := "Your reminders:\n" + strings.Join(lines, "\n")
+		text = utils.Truncate(text, 2000)
+
+		respond(s, i, "remind", text)
+		return
+	}
+
+	// * CANCEL
+	if options.subcommand == "cancel" {
+		mutex.Lock()
+
+		var current *firebase.Reminder
+		for _, reminder := range guildData.Reminders {
+			if reminder.ID == options.id && reminder.UserID == user.ID {
+				current = &reminder
+				break
+			}
+		}
+
+		if current == nil {
+			mutex.Unlock()
+			respond(s, i, "remind", fmt.Sprintf("**Error:** you don't have a reminder with the ID `%s`", options.id))
+			return
+		}
+
+		guildData.RemindersRemoveItem(current.ID)
+
+		remindersMap := guildData.RemindersToMap()
+		err = firebase.SetReminders(i.GuildID, &remindersMap)
+		if err != nil {
+			// revoke changes on error
+			guildData.Reminders = append(guildData.Reminders, *current)
+		}
+
+		mutex.Unlock()
+
+		if err != nil {
+			Log.Debug(Log.Level.Error, `uploading "remind (cancel)" data to firebase:`, err.Error())
+			respond(s, i, "remind", fmt.Sprintf("**Error:** while uploading **remind (cancel)** data to firebase:\n`%s`", err.Error()))
+			return
+		}
+
+		respond(s, i, "remind", fmt.Sprintf("**Success:** Reminder `%s` canceled", current.ID))
+	}
+}
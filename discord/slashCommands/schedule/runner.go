@@ -0,0 +1,261 @@
+package schedule
+
+import (
+	tts "discord-bot/TTS"
+	"discord-bot/discord/events"
+	"discord-bot/firebase"
+	"discord-bot/scheduler"
+	"discord-bot/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var Log = &utils.Log
+
+// how often the scheduler checks the reminders and the schedules
+const checkInterval = 20 * time.Second
+
+// guards the reminders and schedules of the guilds data and the next runs, they are changed by the commands and the scheduler
+var mutex sync.Mutex
+
+// the next run of each schedule, the key is the guild ID and the schedule ID
+var nextRuns = map[string]time.Time{}
+
+// the ready event is sent again on reconnects
+var startOnce sync.Once
+
+func init() {
+	events.RegisterOnReadyEvent(startScheduler)
+}
+
+func startScheduler(s *discordgo.Session, e *discordgo.Ready) {
+	startOnce.Do(func() {
+		go runScheduler(s)
+	})
+}
+
+func runScheduler(s *discordgo.Session) {
+	defer events.RecoverEvent("scheduler:loop")
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	ctx := events.Context()
+
+	now := time.Now()
+	for {
+		for _, guild := range s.State.Guilds {
+			checkGuild(s, guild.ID, now)
+		}
+
+		select {
+		case now = <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkGuild runs the due reminders and schedules of a guild, a panic only skips this guild until the next check
+func checkGuild(s *discordgo.Session, guildID string, now time.Time) {
+	defer events.RecoverEvent("scheduler")
+
+	guildData, err := firebase.GetGuildData(guildID)
+	if err != nil {
+		Log.Error("\nScheduler:", err.Error())
+		Log.Debug(Log.Level.Error, "getting guild firebase data for the scheduler:", err.Error())
+		return
+	}
+
+	checkReminders(s, guildID, guildData, now)
+	checkSchedules(s, guildID, guildData, now)
+}
+
+// newID returns a short random ID for a reminder or a schedule
+func newID() string {
+	return strconv.FormatInt(int64(utils.RandomInt(36*36*36, 36*36*36*36)), 36)
+}
+
+func nextRunKey(guildID string, scheduleID string) string {
+	return guildID + ":" + scheduleID
+}
+
+// nextRun returns the next time a schedule runs after the given time
+func nextRun(schedule firebase.ScheduledMessage, after time.Time) (time.Time, error) {
+	cron, err := scheduler.ParseCron(schedule.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	location, err := scheduler.LoadTimezone(schedule.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return cron.Next(after.In(location)), nil
+}
+
+// checkReminders removes the due reminders of a guild and sends them, reminders missed while offline are sent late
+func checkReminders(s *discordgo.Session, guildID string, guildData *firebase.FirebaseData, now time.Time) {
+	for _, reminder := range takeDueReminders(guildID, guildData, now) {
+		go sendReminder(s, guildID, reminder)
+	}
+}
+
+// takeDueReminders removes the due reminders of a guild and returns them
+func takeDueReminders(guildID string, guildData *firebase.FirebaseData, now time.Time) []firebase.Reminder {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	due := []firebase.Reminder{}
+	for _, reminder := range guildData.Reminders {
+		if reminder.At <= now.Unix() {
+			due = append(due, reminder)
+		}
+	}
+
+	if len(due) == 0 {
+		return nil
+	}
+
+	for _, reminder := range due {
+		guildData.RemindersRemoveItem(reminder.ID)
+	}
+
+	// they are not added back on error, so they are not sent twice
+	remindersMap := guildData.RemindersToMap()
+	err := firebase.SetReminders(guildID, &remindersMap)
+	if err != nil {
+		Log.Error("\nScheduler:", err.Error())
+		Log.Debug(Log.Level.Error, `uploading "remind" data to firebase:`, err.Error())
+	}
+
+	return due
+}
+
+func sendReminder(s *discordgo.Session, guildID string, reminder firebase.Reminder) {
+	defer events.RecoverEvent("scheduler:reminder")
+
+	message := &discordgo.MessageSend{
+		Content:         fmt.Sprintf("⏰ <@%s> reminder: %s", reminder.UserID, reminder.Message),
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{reminder.UserID}},
+	}
+
+	_, sendError := s.ChannelMessageSendComplex(reminder.ChannelID, message)
+	if sendError != nil {
+		// the channel may be deleted, try the DMs of the user
+		channel, err := s.UserChannelCreate(reminder.UserID)
+		if err == nil {
+			_, sendError = s.ChannelMessageSendComplex(channel.ID, message)
+		}
+	}
+	if sendError != nil {
+		Log.Error("\nScheduler:", sendError.Error())
+		Log.Debug(Log.Level.Error, "sending a reminder:", sendError.Error())
+	}
+
+	if !reminder.TTS {
+		return
+	}
+
+	vs, err := s.State.VoiceState(guildID, reminder.UserID)
+	if err != nil {
+		return // not in a voice channel
+	}
+
+	err = speak(s, guildID, vs.ChannelID, reminder.Message, reminder.Lang)
+	if err != nil {
+		Log.Error("\nScheduler:", err.Error())
+		Log.Debug(Log.Level.Error, "speaking a reminder:", err.Error())
+	}
+}
+
+// checkSchedules runs the schedules of a guild that are due, runs missed while offline are skipped
+func checkSchedules(s *discordgo.Session, guildID string, guildData *firebase.FirebaseData, now time.Time) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	keys := map[string]bool{}
+	for _, schedule := range guildData.Schedules {
+		key := nextRunKey(guildID, schedule.ID)
+		keys[key] = true
+
+		next, ok := nextRuns[key]
+		if ok && now.Before(next) {
+			continue
+		}
+
+		if ok {
+			go runSchedule(s, guildID, schedule)
+		}
+
+		next, err := nextRun(schedule, now)
+		if err != nil {
+			Log.Error("\nScheduler:", err.Error())
+			Log.Debug(Log.Level.Error, "parsing a schedule:", err.Error())
+			continue
+		}
+		nextRuns[key] = next
+	}
+
+	// the schedules that are not in the guild data anymore
+	for key := range nextRuns {
+		if strings.HasPrefix(key, guildID+":") && !keys[key] {
+			delete(nextRuns, key)
+		}
+	}
+}
+
+func runSchedule(s *discordgo.Session, guildID string, schedule firebase.ScheduledMessage) {
+	defer events.RecoverEvent("scheduler:schedule")
+
+	Log.With(utils.FieldGuild, guildID, utils.FieldChannel, schedule.ChannelID, utils.FieldUser, schedule.CreatedBy).
+		Info("scheduler: running schedule", "schedule", schedule.ID, "cron", schedule.Cron)
+
+	var err error
+	if schedule.VoiceChannelID != "" {
+		err = speak(s, guildID, schedule.VoiceChannelID, schedule.Message, schedule.Lang)
+	} else {
+		_, err = s.ChannelMessageSendComplex(schedule.ChannelID, &discordgo.MessageSend{
+			Content: schedule.Message,
+			// no @everyone or role pings from a schedule
+			AllowedMentions: &discordgo.MessageAllowedMentions{Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers}},
+		})
+	}
+
+	if err != nil {
+		Log.Error("\nScheduler:", err.Error())
+		Log.Debug(Log.Level.Error, "running a schedule:", err.Error())
+	}
+}
+
+// speak joins a voice channel and speaks the message
+func speak(s *discordgo.Session, guildID string, channelID string, message string, lang string) error {
+	unlock := events.LockVoice(guildID)
+	defer unlock()
+
+	if lang == "" {
+		lang = "en"
+	}
+
+	vc, err := s.ChannelVoiceJoin(guildID, channelID, false, true)
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	vc.Speaking(true)
+
+	err = tts.GenerateAndSendToVoiceChannel(message, vc, tts.TTSOptions{Lang: lang, Slow: false})
+
+	vc.Speaking(false)
+	time.Sleep(250 * time.Millisecond)
+	vc.Disconnect()
+
+	return err
+}
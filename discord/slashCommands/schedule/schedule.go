@@ -0,0 +1,357 @@
+package schedule
+
+import (
+	"discord-bot/common"
+	"discord-bot/discord/events"
+	"discord-bot/firebase"
+	"discord-bot/scheduler"
+	"discord-bot/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// the max number of schedules per guild
+const maxSchedulesPerGuild = 25
+
+// only members that can manage messages can schedule messages by default
+var schedulePermissions int64 = discordgo.PermissionManageMessages
+
+var scheduleCommand = common.SlashCommand{
+	Command: discordgo.ApplicationCommand{
+		Name:                     "schedule",
+		Description:              "Send recurring messages to a channel",
+		DefaultMemberPermissions: &schedulePermissions,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "add",
+				Description: "Schedule a recurring message",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "cron",
+						Description: `When to send it, e.g. "0 9 * * mon-fri", "*/30 * * * *", "@daily"`,
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+					{
+						Name:        "message",
+						Description: "The message to send",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						MaxLength:   1000,
+					},
+					{
+						Name:         "channel",
+						Description:  "The channel to send it to (default: this channel)",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						Required:     false,
+					},
+					{
+						Name:         "voice_channel",
+						Description:  "Say it with TTS in this voice channel instead",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+						Required:     false,
+					},
+					{
+						Name:        "language",
+						Description: "The TTS language code, e.g. en, de, ar (default: en)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+					},
+				},
+			},
+			{
+				Name:        "list",
+				Description: "Show the scheduled messages of this server",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "cancel",
+				Description: "Cancel a scheduled message",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "id",
+						Description: "The schedule ID, see /schedule list",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+				},
+			},
+		},
+	},
+
+	Feature: common.FeatureSchedule,
+	Handler: scheduleHandler,
+}
+
+func init() {
+	events.RegisterSlashCommand(&scheduleCommand)
+}
+
+type scheduleOptions struct {
+	subcommand     string // "add", "list" or "cancel"
+	cron           string // required for "add"
+	message        string // required for "add"
+	channelID      string
+	voiceChannelID string
+	lang           string
+	id             string // required for "cancel"
+}
+
+func parseScheduleOptions(options []*discordgo.ApplicationCommandInteractionDataOption) (scheduleOptions, error) {
+	results := scheduleOptions{}
+
+	subcommand := options[0].Name
+	subcommandOptions := options[0].Options
+
+	if subcommand == "add" {
+		results.subcommand = "add"
+
+		for _, opt := range subcommandOptions {
+			switch opt.Name {
+			case "cron":
+				val, err := utils.CheckOptionStringValue(opt)
+				if err != nil {
+					return results, fmt.Errorf("please enter a cron expression")
+				}
+				results.cron = val
+
+			case "message":
+				val, err := utils.CheckOptionStringValue(opt)
+				if err != nil {
+					return results, fmt.Errorf("please enter a message")
+				}
+				results.message = val
+
+			case "channel":
+				results.channelID, _ = opt.Value.(string)
+
+			case "voice_channel":
+				results.voiceChannelID, _ = opt.Value.(string)
+
+			case "language":
+				results.lang = strings.ToLower(opt.StringValue())
+			}
+		}
+	}
+
+	if subcommand == "list" {
+		results.subcommand = "list"
+	}
+
+	if subcommand == "cancel" {
+		results.subcommand = "cancel"
+
+		for _, opt := range subcommandOptions {
+			switch opt.Name {
+			case "id":
+				val, err := utils.CheckOptionStringValue(opt)
+				if err != nil {
+					return results, fmt.Errorf("please enter a schedule ID")
+				}
+				results.id = strings.ToLower(val)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func scheduleHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData) {
+	user := utils.GetInteractionAuthor(i.Interaction)
+
+	Log.Debug(Log.Level.Info, `SlashCommand: "schedule", GuildID:`, i.GuildID, "ChannelID:", i.ChannelID, "UserID:", user.ID, "UserName:", user.Username)
+
+	options, err := parseScheduleOptions(appData.Options)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `parsing "schedule" command options:`, err.Error())
+		respond(s, i, "schedule", fmt.Sprintf("**Error:** while parsing **schedule** command options:\n`%s`", err.Error()))
+		return
+	}
+
+	guildData, err := firebase.GetGuildData(i.GuildID)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `getting guild firebase data for "schedule" command:`, err.Error())
+		respond(s, i, "schedule", fmt.Sprintf("**Error:** while getting this guild data from firebase:\n`%s`", err.Error()))
+		return
+	}
+
+	// * ADD
+	if options.subcommand == "add" {
+		cron, err := scheduler.ParseCron(options.cron)
+		if err != nil {
+			respond(s, i, "schedule", fmt.Sprintf("**Error:** invalid cron expression `%s`:\n`%s`", options.cron, err.Error()))
+			return
+		}
+
+		// the cron expression uses the timezone of its creator
+		timezone, location, err := userTimezone(user.ID)
+		if err != nil {
+			Log.Debug(Log.Level.Error, `getting user firebase data for "schedule" command:`, err.Error())
+			respond(s, i, "schedule", fmt.Sprintf("**Error:** while getting your data from firebase:\n`%s`", err.Error()))
+			return
+		}
+
+		newSchedule := firebase.ScheduledMessage{
+			ID:             newID(),
+			CreatedBy:      user.ID,
+			ChannelID:      options.channelID,
+			VoiceChannelID: options.voiceChannelID,
+			Message:        options.message,
+			Cron:           options.cron,
+			Timezone:       timezone,
+			Lang:           options.lang,
+		}
+
+		if newSchedule.ChannelID == "" {
+			newSchedule.ChannelID = i.ChannelID
+		}
+
+		// the bot sends it with its own permissions, the creator must be allowed to send it there too
+		allowed, err := canPostTo(s, user.ID, newSchedule)
+		if err != nil {
+			Log.Debug(Log.Level.Error, `checking the permissions for "schedule" command:`, err.Error())
+			respond(s, i, "schedule", fmt.Sprintf("**Error:** while checking your permissions in the channel:\n`%s`", err.Error()))
+			return
+		}
+		if !allowed {
+			respond(s, i, "schedule", "**Error:** you can't send messages in this channel yourself, so you can't schedule messages there")
+			return
+		}
+
+		next := cron.Next(time.Now().In(location))
+
+		mutex.Lock()
+
+		if len(guildData.Schedules) >= maxSchedulesPerGuild {
+			mutex.Unlock()
+			respond(s, i, "schedule", fmt.Sprintf("**Error:** a server can't have more than %d scheduled messages, cancel some of them first", maxSchedulesPerGuild))
+			return
+		}
+
+		guildData.Schedules = append(guildData.Schedules, newSchedule)
+
+		schedulesMap := guildData.SchedulesToMap()
+		err = firebase.SetSchedules(i.GuildID, &schedulesMap)
+		if err != nil {
+			// revoke changes on error
+			guildData.SchedulesRemoveItem(newSchedule.ID)
+		} else {
+			nextRuns[nextRunKey(i.GuildID, newSchedule.ID)] = next
+		}
+
+		mutex.Unlock()
+
+		if err != nil {
+			Log.Debug(Log.Level.Error, `uploading "schedule (add)" data to firebase:`, err.Error())
+			respond(s, i, "schedule", fmt.Sprintf("**Error:** while uploading **schedule (add)** data to firebase:\n`%s`", err.Error()))
+			return
+		}
+
+		if timezone == "" {
+			timezone = "UTC"
+		}
+		respond(s, i, "schedule", fmt.Sprintf("**Success:** Message scheduled (`%s`, %s), next run <t:%d:R>, ID: `%s`\nUse `/remind timezone` to change your timezone.", newSchedule.Cron, timezone, next.Unix(), newSchedule.ID))
+		return
+	}
+
+	// * LIST
+	if options.subcommand == "list" {
+		lines := []string{}
+
+		mutex.Lock()
+		for _, schedule := range guildData.Schedules {
+			target := "<#" + schedule.ChannelID + ">"
+			if schedule.VoiceChannelID != "" {
+				target = "🔊 <#" + schedule.VoiceChannelID + ">"
+			}
+
+			timezone := schedule.Timezone
+			if timezone == "" {
+				timezone = "UTC"
+			}
+
+			next := "never"
+			if run, err := nextRun(schedule, time.Now()); err == nil && !run.IsZero() {
+				next = fmt.Sprintf("<t:%d:R>", run.Unix())
+			}
+
+			lines = append(lines, fmt.Sprintf("`%s` `%s` (%s) in %s by <@%s>, next %s: %s", schedule.ID, schedule.Cron, timezone, target, schedule.CreatedBy, next, schedule.Message))
+		}
+		mutex.Unlock()
+
+		if len(lines) == 0 {
+			respond(s, i, "schedule", "There are no scheduled messages in this server.")
+			return
+		}
+
+		text := "Scheduled messages:\n" + strings.Join(lines, "\n")
+		text = utils.Truncate(text, 2000)
+
+		respond(s, i, "schedule", text)
+		return
+	}
+
+	// * CANCEL
+	if options.subcommand == "cancel" {
+		mutex.Lock()
+
+		var current *firebase.ScheduledMessage
+		for _, schedule := range guildData.Schedules {
+			if schedule.ID == options.id {
+				current = &schedule
+				break
+			}
+		}
+
+		if current == nil {
+			mutex.Unlock()
+			respond(s, i, "schedule", fmt.Sprintf("**Error:** there is no scheduled message with the ID `%s`", options.id))
+			return
+		}
+
+		guildData.SchedulesRemoveItem(current.ID)
+
+		schedulesMap := guildData.SchedulesToMap()
+		err = firebase.SetSchedules(i.GuildID, &schedulesMap)
+		if err != nil {
+			// revoke changes on error
+			guildData.Schedules = append(guildData.Schedules, *current)
+		} else {
+			delete(nextRuns, nextRunKey(i.GuildID, current.ID))
+		}
+
+		mutex.Unlock()
+
+		if err != nil {
+			Log.Debug(Log.Level.Error, `uploading "schedule (cancel)" data to firebase:`, err.Error())
+			respond(s, i, "schedule", fmt.Sprintf("**Error:** while uploading **schedule (cancel)** data to firebase:\n`%s`", err.Error()))
+			return
+		}
+
+		respond(s, i, "schedule", fmt.Sprintf("**Success:** Scheduled message `%s` canceled", current.ID))
+	}
+}
+
+// canPostTo checks if a user can send the schedule themselves, in its text channel or with TTS in its voice channel
+func canPostTo(s *discordgo.Session, userID string, schedule firebase.ScheduledMessage) (bool, error) {
+	channelID := schedule.ChannelID
+	var required int64 = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages
+	if schedule.VoiceChannelID != "" {
+		channelID = schedule.VoiceChannelID
+		required = discordgo.PermissionViewChannel | discordgo.PermissionVoiceConnect | discordgo.PermissionVoiceSpeak
+	}
+
+	permissions, err := s.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		return false, err
+	}
+
+	return permissions&required == required, nil
+}
@@ -1,9 +1,13 @@
 package torrentCommand
 
 import (
+	"discord-bot/common"
+	"discord-bot/discord/audit"
 	"discord-bot/discord/components"
+	"discord-bot/discord/events"
 	"discord-bot/discord/interaction"
 	"discord-bot/torrentClient"
+	"discord-bot/utils"
 	"fmt"
 	"time"
 
@@ -11,7 +15,30 @@ import (
 	"github.com/cenkalti/rain/torrent"
 )
 
-func addTorrent(s *discordgo.Session, i *discordgo.InteractionCreate, uri *string, tor *torrent.Torrent) {
+// startFromURI starts downloading a torrent from a magnet link or a url, a nil policy uses the global seeding policy
+func startFromURI(uri string, policy *common.SeedingPolicy) func() (func() torrentClient.TorrentInfo, error) {
+	return func() (func() torrentClient.TorrentInfo, error) {
+		return torrentClient.Download(uri, policy)
+	}
+}
+
+// startFromTorrent resumes an existing torrent
+func startFromTorrent(tor *torrent.Torrent) func() (func() torrentClient.TorrentInfo, error) {
+	return func() (func() torrentClient.TorrentInfo, error) {
+		return torrentClient.Resume(tor)
+	}
+}
+
+// startFromFile starts downloading a torrent from a parsed .torrent file, a nil policy uses the global seeding policy
+func startFromFile(file *torrentClient.TorrentFile, policy *common.SeedingPolicy) func() (func() torrentClient.TorrentInfo, error) {
+	return func() (func() torrentClient.TorrentInfo, error) {
+		return torrentClient.DownloadFromFile(file, policy)
+	}
+}
+
+// addTorrent starts a torrent and prints its status, action is recorded in the audit log, only an added torrent
+// gets a new owner, a resumed one keeps the user who added it
+func addTorrent(s *discordgo.Session, i *discordgo.InteractionCreate, action audit.Action, start func() (func() torrentClient.TorrentInfo, error)) {
 	sendErr := interaction.RespondWithThinking(s, i, false)
 	if sendErr != nil {
 		Log.Error("\nTorrent:", sendErr.Error())
@@ -19,17 +46,7 @@ func addTorrent(s *discordgo.Session, i *discordgo.InteractionCreate, uri *strin
 		return
 	}
 
-	var (
-		status func() torrentClient.TorrentInfo
-		err    error
-	)
-
-	// download from a uri, or resume a torrent
-	if uri != nil {
-		status, err = torrentClient.Download(*uri)
-	} else {
-		status, err = torrentClient.Resume(tor)
-	}
+	status, err := start()
 
 	// error while starting downloading
 	if err != nil {
@@ -42,8 +59,28 @@ func addTorrent(s *discordgo.Session, i *discordgo.InteractionCreate, uri *strin
 		return
 	}
 
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+
+	// remember who added it, so they can check it from their DMs
+	if action == audit.ActionTorrentAdd {
+		torrentClient.SetOwner(status().ID, user.ID)
+	}
+
+	info := status()
+	audit.Record(s, audit.Change{
+		GuildID: i.GuildID,
+		UserID:  user.ID,
+		Action:  action,
+		Target:  torrentTarget(info.ID, info.Name),
+	})
+
 	// print the status
 	go func() {
+		defer events.RecoverEvent("torrent:progress")
+
 		ticker := time.NewTicker(5 * time.Second)
 		defer ticker.Stop()
 
@@ -57,7 +94,17 @@ func addTorrent(s *discordgo.Session, i *discordgo.InteractionCreate, uri *strin
 					Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
 				}
 
-				torrentClient.Stop(false) // make sure the torrent is stopped
+				torrentClient.FinishDownload(state.ID) // stop the torrent or keep it seeding
+
+				// the hooks run in the torrent client, this only reports them
+				hookResults, ok := torrentClient.WaitPostDownloadHooks(events.Context(), state.ID)
+				if ok && len(hookResults) > 0 {
+					sendErr = interaction.RespondEdit(s, i, "Download complete!\n\u200b\n"+formatHookResults(hookResults))
+					if sendErr != nil {
+						Log.Error("\nTorrent:", sendErr.Error())
+						Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+					}
+				}
 				break
 			}
 
@@ -91,14 +138,15 @@ func addTorrent(s *discordgo.Session, i *discordgo.InteractionCreate, uri *strin
 				"**Download Speed:** `", state.DownloadSpeed+"s", "`\n",
 				"**Upload Speed:** `", state.UploadSpeed+"s", "`\n",
 				"**Peers:** `", state.Peers, "`\n",
+				"**Ratio:** `", state.Ratio, "`\n",
 				"**ETA:** `", state.ETA, "`\n\u200b",
 			)
 
 			sendErr := interaction.RespondEditWithComponents(s, i, &format,
 				components.AddMessageComponents(
 					components.NewRow(
-						components.NewButton().SetLabel("Stop").SetCustomID("torrent_stop").SetStylePrimary(),
-						components.NewButton().SetLabel("Stop and remove").SetCustomID("torrent_stop_remove").SetStyleDanger(),
+						components.NewButton().SetLabel("Stop").SetCustomID(stopRoute.CustomID(state.ID)).SetStylePrimary(),
+						components.NewButton().SetLabel("Stop and remove").SetCustomID(stopAndRemoveRoute.CustomID(state.ID)).SetStyleDanger(),
 					),
 				),
 			)
@@ -107,7 +155,29 @@ func addTorrent(s *discordgo.Session, i *discordgo.InteractionCreate, uri *strin
 				Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
 			}
 
-			<-ticker.C // wait for ticker
+			// wait for ticker, stop when the bot is shutting down
+			select {
+			case <-ticker.C:
+			case <-events.Context().Done():
+				return
+			}
 		}
 	}()
 }
+
+// formatHookResults formats the results of the post download hooks for a discord message
+func formatHookResults(results []torrentClient.HookResult) string {
+	content := ""
+	for _, result := range results {
+		if result.Error != nil {
+			content += fmt.Sprintf("🔸 **%s:** failed `%s`\n", result.Name, result.Error.Error())
+			continue
+		}
+		content += fmt.Sprintf("🔹 **%s:** %s\n", result.Name, result.Info)
+	}
+
+	// discord message limit
+	content = utils.Truncate(content, 1900)
+
+	return content
+}
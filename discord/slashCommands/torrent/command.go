@@ -2,13 +2,15 @@ package torrentCommand
 
 import (
 	"discord-bot/common"
+	"discord-bot/discord/audit"
 	"discord-bot/discord/events"
 	"discord-bot/discord/interaction"
+	"discord-bot/torrentClient"
 	"discord-bot/utils"
 	"fmt"
 	"net/url"
 	"path/filepath"
-	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/cenkalti/rain/torrent"
@@ -41,7 +43,30 @@ var command = common.SlashCommand{
 						Name:        "uri",
 						Description: "The URI of the torrent",
 						Type:        discordgo.ApplicationCommandOptionString,
-						Required:    true,
+						Required:    false,
+					},
+					{
+						Name:        "file",
+						Description: "A .torrent file",
+						Type:        discordgo.ApplicationCommandOptionAttachment,
+						Required:    false,
+					},
+					{
+						Name:        "seeding",
+						Description: "Seeding policy after download, defaults to the global policy (optional)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "No seeding", Value: common.SeedingNone},
+							{Name: "Seed until ratio", Value: common.SeedingRatio},
+							{Name: "Seed for hours", Value: common.SeedingTime},
+						},
+					},
+					{
+						Name:        "seeding_limit",
+						Description: "The ratio or the number of hours to seed for (optional)",
+						Type:        discordgo.ApplicationCommandOptionNumber,
+						Required:    false,
 					},
 				},
 			},
@@ -49,6 +74,27 @@ var command = common.SlashCommand{
 				Name:        "list",
 				Description: "List all torrents",
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "status",
+						Description: "Only show torrents with this status (optional)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "All", Value: torrentClient.StateAll},
+							{Name: "Downloading", Value: torrentClient.StateDownloading},
+							{Name: "Completed", Value: torrentClient.StateCompleted},
+							{Name: "Stopped", Value: torrentClient.StateStopped},
+							{Name: "Errored", Value: torrentClient.StateErrored},
+						},
+					},
+					{
+						Name:        "name",
+						Description: "Only show torrents that contain this name (optional)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+					},
+				},
 			},
 			{
 				Name:        "search",
@@ -106,17 +152,23 @@ var command = common.SlashCommand{
 		},
 	},
 
-	Handler: cmdHandler,
+	Feature:  common.FeatureTorrent,
+	Cooldown: 3 * time.Second,
+	Handler:  cmdHandler,
 }
 
 func init() {
 	events.RegisterSlashCommand(&command)
-	events.RegisterComponentReactionEvent(onComponentReaction)
+	registerRoutes()
 }
 
 type cmdOptions struct {
 	subcommand string                // "add" or "list"
-	uri        string                // required for "add"
+	uri        string                // "uri" or "file" is required for "add"
+	fileID     string                // "uri" or "file" is required for "add"
+	seeding    *common.SeedingPolicy // optional for "add"
+	status     string                // optional for "list"
+	name       string                // optional for "list"
 	query      string                // required for "search"
 	category   common.X1337xCategory // optional for "search"
 	sort       common.X1337xSort     // optional for "search"
@@ -132,6 +184,11 @@ func parseCmdOptions(options []*discordgo.ApplicationCommandInteractionDataOptio
 	if subcommand == "add" {
 		results.subcommand = subcommand
 
+		var (
+			seedingMode  common.SeedingMode
+			seedingLimit float64
+		)
+
 		for _, option := range subcommandOptions {
 			switch option.Name {
 			case "uri":
@@ -140,12 +197,63 @@ func parseCmdOptions(options []*discordgo.ApplicationCommandInteractionDataOptio
 					return results, fmt.Errorf("please enter a URI")
 				}
 				results.uri = val
+			case "file":
+				if option.Value == nil {
+					return results, fmt.Errorf("please attach a .torrent file")
+				}
+				results.fileID = option.Value.(string)
+			case "seeding":
+				val, err := utils.CheckOptionStringValue(option)
+				if err == nil {
+					seedingMode = val
+				}
+			case "seeding_limit":
+				seedingLimit = option.FloatValue()
 			}
 		}
+
+		if seedingMode == "" && seedingLimit != 0 {
+			return results, fmt.Errorf("please choose a seeding policy for the seeding limit")
+		}
+
+		if seedingMode != "" {
+			results.seeding = &common.SeedingPolicy{Mode: seedingMode}
+			if seedingMode == common.SeedingRatio {
+				results.seeding.Ratio = seedingLimit
+			}
+			if seedingMode == common.SeedingTime {
+				results.seeding.Hours = seedingLimit
+			}
+
+			err := torrentClient.ValidateSeedingPolicy(*results.seeding)
+			if err != nil {
+				return results, err
+			}
+		}
+
+		if results.uri == "" && results.fileID == "" {
+			return results, fmt.Errorf("please enter a URI or attach a .torrent file")
+		}
 	}
 
 	if subcommand == "list" {
 		results.subcommand = subcommand
+		results.status = torrentClient.StateAll
+
+		for _, option := range subcommandOptions {
+			switch option.Name {
+			case "status":
+				val, err := utils.CheckOptionStringValue(option)
+				if err == nil {
+					results.status = val
+				}
+			case "name":
+				val, err := utils.CheckOptionStringValue(option)
+				if err == nil {
+					results.name = val
+				}
+			}
+		}
 	}
 
 	if subcommand == "search" {
@@ -193,13 +301,22 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 
 	// * ADD
 	if options.subcommand == "add" {
-		addTorrent(s, i, &options.uri, nil)
+		if options.fileID != "" {
+			var attachment *discordgo.MessageAttachment
+			if appData.Resolved != nil {
+				attachment = appData.Resolved.Attachments[options.fileID]
+			}
+			addTorrentFile(s, i, attachment, options.seeding)
+			return
+		}
+
+		addTorrent(s, i, audit.ActionTorrentAdd, startFromURI(options.uri, options.seeding))
 		return
 	}
 
 	// * LIST
 	if options.subcommand == "list" {
-		listTorrents(s, i)
+		listTorrents(s, i, options.status, options.name)
 		return
 	}
 
@@ -209,63 +326,6 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 	}
 }
 
-func onComponentReaction(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.MessageComponentInteractionData) {
-	if data.CustomID == "yts_torrents" {
-		ytsListOnSelect(data, s, i)
-		return
-	}
-
-	if data.CustomID == "torrent_stop" {
-		torrentStopButton(s, i)
-		return
-	}
-
-	if data.CustomID == "torrent_stop_remove" {
-		torrentStopAndRemoveButton(s, i)
-		return
-	}
-
-	if strings.HasPrefix(data.CustomID, "torrent_remove") {
-		TorrentDeleteButton(data, s, i)
-		return
-	}
-
-	if strings.HasPrefix(data.CustomID, "torrent_resume") {
-		torrentResumeButton(data, s, i)
-		return
-	}
-
-	if strings.HasPrefix(data.CustomID, "torrent_links") {
-		generateLinksButton(data, s, i)
-		return
-	}
-
-	if data.CustomID == "show_torrents_list" {
-		showTorrentListButton(s, i)
-		return
-	}
-
-	if data.CustomID == "torrent_list" {
-		onTorrentListSelect(s, i, data)
-		return
-	}
-
-	if data.CustomID == "search_list" {
-		onSearchListSelect(data, s, i)
-		return
-	}
-
-	if data.CustomID == "next_page" {
-		nextPageButton(s, i)
-		return
-	}
-
-	if strings.HasPrefix(data.CustomID, "search_download") {
-		searchDownloadButton(data, s, i)
-		return
-	}
-}
-
 func getVideoUrls(tor *torrent.Torrent) ([]string, error) {
 	config := utils.GetAppConfig()
 
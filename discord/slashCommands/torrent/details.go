@@ -0,0 +1,224 @@
+package torrentCommand
+
+import (
+	"bytes"
+	"discord-bot/discord/components"
+	"discord-bot/discord/events"
+	"discord-bot/discord/interaction"
+	"discord-bot/ratelimit"
+	"discord-bot/torrentClient"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// max number of trackers, peers and files to show in the details embed
+const detailsListLimit = 10
+
+func torrentDetailsButton(s *discordgo.Session, i *discordgo.InteractionCreate, torrentID string) {
+
+	sendErr := interaction.RespondWithNothing(s, i)
+	if sendErr != nil {
+		Log.Error("\ntorrentList:", sendErr.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+		return
+	}
+
+	details, err := torrentClient.GetTorrentDetails(torrentID)
+	if err != nil {
+		Log.Debug(Log.Level.Error, "getting a torrent details:", err.Error())
+		sendErr = interaction.RespondEdit(s, i, fmt.Sprintf("**Error:** while getting a torrent details:\n`%s`", err.Error()))
+		if sendErr != nil {
+			Log.Error("\ntorrentList:", sendErr.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+		}
+		return
+	}
+
+	buf, err := createPiecesImage(details)
+	if err != nil {
+		Log.Debug(Log.Level.Error, "creating a torrent pieces image:", err.Error())
+		sendErr = interaction.RespondEdit(s, i, fmt.Sprintf("**Error:** while creating a torrent pieces image:\n`%s`", err.Error()))
+		if sendErr != nil {
+			Log.Error("\ntorrentList:", sendErr.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+		}
+		return
+	}
+
+	content := ""
+	_, sendErr = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:     &content,
+		Embeds:      &[]*discordgo.MessageEmbed{createDetailsEmbed(details)},
+		Files:       []*discordgo.File{{Name: "pieces.png", ContentType: "image/png", Reader: buf}},
+		Attachments: &[]*discordgo.MessageAttachment{}, // replace the previous image
+		Components: components.AddMessageComponents(
+			components.NewRow(
+				components.NewButton().SetLabel("Refresh").SetCustomID(detailsRoute.CustomID(torrentID)).SetStyleSecondary(),
+				components.NewButton().SetLabel("Show Torrents List").SetCustomID(showListRoute.CustomID(events.NoPayload{})).SetStyleSecondary(),
+			),
+		),
+	})
+	if sendErr != nil {
+		Log.Error("\ntorrentList:", sendErr.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+	}
+}
+
+// createDetailsEmbed creates the embed for a specific torrent details
+func createDetailsEmbed(details *torrentClient.TorrentDetails) *discordgo.MessageEmbed {
+	embed := components.NewEmbed().
+		SetColor(0x0099ff).
+		SetTitle(details.Name).
+		SetDescription(fmt.Sprintf("**Info Hash:** `%s`", details.InfoHash)).
+		AddField("Status", details.Status, true).
+		AddField("Ratio", fmt.Sprintf("%.2f", details.Ratio), true).
+		AddField("Private", fmt.Sprintf("%t", details.Private), true).
+		AddField("Size", fmt.Sprintf("%s / %s", torrentClient.FormatBytes(details.BytesCompleted), torrentClient.FormatBytes(details.BytesTotal)), true).
+		AddField("Uploaded", torrentClient.FormatBytes(details.BytesUploaded), true).
+		AddField("Pieces", fmt.Sprintf("%d / %d (%d available)", details.PiecesHave, details.PiecesTotal, details.PiecesAvailable), true)
+
+	// trackers
+	trackers := ""
+	for index, tracker := range details.Trackers {
+		if index >= detailsListLimit {
+			trackers += fmt.Sprintf("_and %d more..._\n", len(details.Trackers)-detailsListLimit)
+			break
+		}
+		trackers += fmt.Sprintf("🔹 `%s`\n%s, seeders: `%d`, leechers: `%d`\n", tracker.URL, tracker.Status, tracker.Seeders, tracker.Leechers)
+		if tracker.Error != "" {
+			trackers += fmt.Sprintf("_%s_\n", tracker.Error)
+		}
+	}
+	if trackers == "" {
+		trackers = "No trackers"
+	}
+	embed.AddField(fmt.Sprintf("Trackers (%d)", len(details.Trackers)), trackers, false)
+
+	// peers
+	peers := ""
+	for index, peer := range details.Peers {
+		if index >= detailsListLimit {
+			peers += fmt.Sprintf("_and %d more..._\n", len(details.Peers)-detailsListLimit)
+			break
+		}
+		peers += fmt.Sprintf("🔹 `%s` %s ↓ `%s/s` ↑ `%s/s`\n",
+			peer.Addr, peer.Client, torrentClient.FormatBytes(peer.DownloadSpeed), torrentClient.FormatBytes(peer.UploadSpeed),
+		)
+	}
+	if peers == "" {
+		peers = "No connected peers"
+	}
+	embed.AddField(fmt.Sprintf("Peers (%d)", len(details.Peers)), peers, false)
+
+	// files
+	files := ""
+	for index, file := range details.Files {
+		if index >= detailsListLimit {
+			files += fmt.Sprintf("_and %d more..._\n", len(details.Files)-detailsListLimit)
+			break
+		}
+		files += fmt.Sprintf("🔹 `%s` %s `%.1f%%`\n", file.Path, torrentClient.FormatBytes(file.BytesTotal), percentage(file.BytesCompleted, file.BytesTotal))
+	}
+	if files == "" {
+		files = "Metadata is not downloaded yet"
+	}
+	embed.AddField(fmt.Sprintf("Files (%d)", len(details.Files)), files, false)
+
+	embed.SetImage("attachment://pieces.png").
+		SetTimestamp(time.Now().Format(time.RFC3339)).
+		SetFooter("ID: " + details.ID)
+
+	return embed.Truncate().Into()
+}
+
+// createPiecesImage draws the downloaded files and the available pieces as two progress bars
+func createPiecesImage(details *torrentClient.TorrentDetails) (*bytes.Buffer, error) {
+	var imageBuffer bytes.Buffer
+
+	const (
+		width     = 800
+		barHeight = 24
+		padding   = 8
+		labelSize = 14
+	)
+
+	height := padding*5 + barHeight*2 + labelSize*2
+
+	err := ratelimit.Images.Wait(events.Context())
+	if err != nil {
+		return &imageBuffer, err
+	}
+	defer ratelimit.Images.Release()
+
+	font, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		return &imageBuffer, err
+	}
+
+	dc := gg.NewContext(width, height)
+	dc.SetFontFace(truetype.NewFace(font, &truetype.Options{Size: labelSize}))
+
+	// background
+	dc.SetHexColor("#2b2d31")
+	dc.Clear()
+
+	barWidth := float64(width - padding*2)
+
+	// * downloaded bar, every file takes a space relative to its size
+	y := float64(padding)
+	dc.SetHexColor("#ffffff")
+	dc.DrawStringAnchored(fmt.Sprintf("Downloaded %.1f%%", percentage(details.BytesCompleted, details.BytesTotal)), padding, y+labelSize/2, 0, 0.5)
+	y += labelSize + padding
+
+	dc.SetHexColor("#1e1f22")
+	dc.DrawRectangle(padding, y, barWidth, barHeight)
+	dc.Fill()
+
+	dc.SetHexColor("#04B575")
+	if len(details.Files) > 0 && details.BytesTotal > 0 {
+		x := float64(padding)
+		for _, file := range details.Files {
+			fileWidth := barWidth * float64(file.BytesTotal) / float64(details.BytesTotal)
+			completedWidth := fileWidth * percentage(file.BytesCompleted, file.BytesTotal) / 100
+			dc.DrawRectangle(x, y, completedWidth, barHeight)
+			dc.Fill()
+			x += fileWidth
+		}
+	} else {
+		dc.DrawRectangle(padding, y, barWidth*percentage(int64(details.PiecesHave), int64(details.PiecesTotal))/100, barHeight)
+		dc.Fill()
+	}
+	y += barHeight + padding
+
+	// * available bar
+	dc.SetHexColor("#ffffff")
+	dc.DrawStringAnchored(fmt.Sprintf("Available in swarm %.1f%%", percentage(int64(details.PiecesAvailable), int64(details.PiecesTotal))), padding, y+labelSize/2, 0, 0.5)
+	y += labelSize + padding
+
+	dc.SetHexColor("#1e1f22")
+	dc.DrawRectangle(padding, y, barWidth, barHeight)
+	dc.Fill()
+
+	dc.SetHexColor("#5eb9ff")
+	dc.DrawRectangle(padding, y, barWidth*percentage(int64(details.PiecesAvailable), int64(details.PiecesTotal))/100, barHeight)
+	dc.Fill()
+
+	err = dc.EncodePNG(&imageBuffer)
+	if err != nil {
+		return &imageBuffer, err
+	}
+
+	return &imageBuffer, nil
+}
+
+func percentage(value, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(value) / float64(total) * 100
+}
@@ -0,0 +1,258 @@
+package torrentCommand
+
+import (
+	"discord-bot/common"
+	"discord-bot/discord/audit"
+	"discord-bot/discord/components"
+	"discord-bot/discord/events"
+	"discord-bot/discord/interaction"
+	"discord-bot/torrentClient"
+	"discord-bot/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// the maximum size of a .torrent file attachment
+const maxTorrentFileSize = 5 * 1024 * 1024
+
+type pendingFile struct {
+	File   *torrentClient.TorrentFile
+	Policy *common.SeedingPolicy
+	UserID string // the user who sent the file, only they can confirm it
+}
+
+var (
+	// parsed .torrent files waiting for confirmation, the key is the confirmation message ID
+	pendingFiles      = map[string]*pendingFile{}
+	pendingFilesMutex sync.Mutex
+)
+
+// addTorrentFile downloads and parses a .torrent attachment then asks the user for confirmation
+func addTorrentFile(s *discordgo.Session, i *discordgo.InteractionCreate, attachment *discordgo.MessageAttachment, policy *common.SeedingPolicy) {
+	sendErr := interaction.RespondWithThinking(s, i, false)
+	if sendErr != nil {
+		Log.Error("\nTorrent:", sendErr.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+		return
+	}
+
+	file, err := fetchTorrentFile(attachment)
+	if err != nil {
+		Log.Debug(Log.Level.Error, "reading a torrent file:", err.Error())
+		sendErr := interaction.RespondEdit(s, i, fmt.Sprintf("**Error:** while reading the torrent file:\n`%s`", err.Error()))
+		if sendErr != nil {
+			Log.Error("\nTorrent:", sendErr.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+		}
+		return
+	}
+
+	content := torrentFileConfirmContent(file, policy)
+	msg, sendErr := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: torrentFileConfirmComponents(),
+	})
+	if sendErr != nil {
+		Log.Error("\nTorrent:", sendErr.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+		return
+	}
+
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+
+	addPendingFile(s, msg, &pendingFile{File: file, Policy: policy, UserID: user.ID})
+}
+
+// AddTorrentFileFromMessage asks for confirmation to download the first .torrent file attached to a message
+func AddTorrentFileFromMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	var attachment *discordgo.MessageAttachment
+	for _, a := range m.Attachments {
+		if strings.HasSuffix(strings.ToLower(a.Filename), ".torrent") {
+			attachment = a
+			break
+		}
+	}
+
+	if attachment == nil {
+		_, sendErr := s.ChannelMessageSendReply(m.ChannelID, "Please attach a `.torrent` file to your message.", m.Reference())
+		if sendErr != nil {
+			Log.Error("\nTorrent:", sendErr.Error())
+			Log.Debug(Log.Level.Error, sendErr.Error())
+		}
+		return
+	}
+
+	file, err := fetchTorrentFile(attachment)
+	if err != nil {
+		Log.Debug(Log.Level.Error, "reading a torrent file:", err.Error())
+		_, sendErr := s.ChannelMessageSendReply(m.ChannelID, fmt.Sprintf("**Error:** while reading the torrent file:\n`%s`", err.Error()), m.Reference())
+		if sendErr != nil {
+			Log.Error("\nTorrent:", sendErr.Error())
+			Log.Debug(Log.Level.Error, sendErr.Error())
+		}
+		return
+	}
+
+	msg, sendErr := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content:    torrentFileConfirmContent(file, nil),
+		Components: *torrentFileConfirmComponents(),
+		Reference:  m.Reference(),
+	})
+	if sendErr != nil {
+		Log.Error("\nTorrent:", sendErr.Error())
+		Log.Debug(Log.Level.Error, sendErr.Error())
+		return
+	}
+
+	addPendingFile(s, msg, &pendingFile{File: file, UserID: m.Author.ID})
+}
+
+func torrentFileAddButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	pending, ok := takePendingFile(s, i)
+	if !ok {
+		return
+	}
+
+	deleteMsgErr := s.ChannelMessageDelete(i.ChannelID, i.Message.ID)
+	if deleteMsgErr != nil {
+		Log.Error("\nTorrent:", deleteMsgErr.Error())
+		Log.Debug(Log.Level.Error, `deleting a message for "torrent" command:`, deleteMsgErr.Error())
+	}
+
+	addTorrent(s, i, audit.ActionTorrentAdd, startFromFile(pending.File, pending.Policy))
+}
+
+func torrentFileCancelButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if _, ok := takePendingFile(s, i); !ok {
+		return
+	}
+
+	sendErr := interaction.RespondWithNothing(s, i)
+	if sendErr != nil {
+		Log.Error("\nTorrent:", sendErr.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+		return
+	}
+
+	deleteMsgErr := s.ChannelMessageDelete(i.ChannelID, i.Message.ID)
+	if deleteMsgErr != nil {
+		Log.Error("\nTorrent:", deleteMsgErr.Error())
+		Log.Debug(Log.Level.Error, `deleting a message for "torrent" command:`, deleteMsgErr.Error())
+	}
+}
+
+// utils
+
+// fetchTorrentFile downloads a .torrent attachment and validates its metainfo
+func fetchTorrentFile(attachment *discordgo.MessageAttachment) (*torrentClient.TorrentFile, error) {
+	if attachment == nil {
+		return nil, errors.New("attachment not found")
+	}
+
+	if !strings.HasSuffix(strings.ToLower(attachment.Filename), ".torrent") {
+		return nil, errors.New("the attached file is not a .torrent file")
+	}
+
+	data, err := utils.DownloadAttachment(attachment, maxTorrentFileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return torrentClient.ParseTorrentFile(data)
+}
+
+// addPendingFile saves a parsed file until the user confirms, it expires after 5 minutes
+func addPendingFile(s *discordgo.Session, msg *discordgo.Message, pending *pendingFile) {
+	pendingFilesMutex.Lock()
+	pendingFiles[msg.ID] = pending
+	pendingFilesMutex.Unlock()
+
+	go func() {
+		defer events.RecoverEvent("torrent:fileExpiry")
+
+		if !events.Sleep(5 * time.Minute) {
+			return
+		}
+
+		pendingFilesMutex.Lock()
+		_, ok := pendingFiles[msg.ID]
+		delete(pendingFiles, msg.ID)
+		pendingFilesMutex.Unlock()
+
+		if !ok {
+			return
+		}
+
+		err := s.ChannelMessageDelete(msg.ChannelID, msg.ID)
+		if err != nil {
+			Log.Error("\nTorrent:", err.Error())
+			Log.Debug(Log.Level.Error, "deleting a message:", err.Error())
+		}
+	}()
+}
+
+// takePendingFile removes the pending file of a confirmation message, it responds to the interaction and returns
+// false when the file expired or the user is not the one who sent it
+func takePendingFile(s *discordgo.Session, i *discordgo.InteractionCreate) (*pendingFile, bool) {
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+
+	pendingFilesMutex.Lock()
+	pending, ok := pendingFiles[i.Message.ID]
+	owner := ok && pending.UserID == user.ID
+	if owner {
+		delete(pendingFiles, i.Message.ID)
+	}
+	pendingFilesMutex.Unlock()
+
+	if !ok {
+		events.RespondExpired(s, i)
+		return nil, false
+	}
+
+	if !owner {
+		sendErr := interaction.RespondWithText(s, i, "❗ Only the user who sent this file can confirm it.", true)
+		if sendErr != nil {
+			Log.Error("\nTorrent:", sendErr.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+		}
+		return nil, false
+	}
+
+	return pending, true
+}
+
+func torrentFileConfirmContent(file *torrentClient.TorrentFile, policy *common.SeedingPolicy) string {
+	seeding := torrentClient.GetGlobalSeedingPolicy()
+	if policy != nil {
+		seeding = *policy
+	}
+
+	return fmt.Sprint(
+		"_This message will be deleted in `5` minutes._\n\u200b\n",
+		"**", file.Name, "**\n",
+		"\u200b\n",
+		"**Size:** `", file.TotalSize, "`\n",
+		"**Files:** `", file.FileCount, "`\n",
+		"**Seeding:** `", torrentClient.FormatSeedingPolicy(seeding), "`\n\u200b",
+	)
+}
+
+func torrentFileConfirmComponents() *[]discordgo.MessageComponent {
+	return components.AddMessageComponents(
+		components.NewRow(
+			components.NewButton().SetLabel("Download").SetCustomID(fileAddRoute.CustomID(events.NoPayload{})).SetStylePrimary(),
+			components.NewButton().SetLabel("Cancel").SetCustomID(fileCancelRoute.CustomID(events.NoPayload{})).SetStyleSecondary(),
+		),
+	)
+}
@@ -2,55 +2,312 @@ package torrentCommand
 
 import (
 	"discord-bot/discord/components"
+	"discord-bot/discord/events"
 	"discord-bot/discord/interaction"
 	"discord-bot/torrentClient"
+	"discord-bot/utils"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+const (
+	// max number of options in a select menu
+	listPageSize = 25
+
+	// how long the state of a torrents list is kept after its last use
+	listTTL = time.Hour
+)
+
+type ListTmp struct {
+	UserID   string // the user who sent the list, only they can use it
+	State    torrentClient.TorrentState
+	Name     string
+	Page     int
+	Selected []string
+	usedAt   time.Time
+}
+
+var (
+	// store the key as the list message ID
+	listTmp      = map[string]*ListTmp{}
+	listTmpMutex sync.Mutex
+)
+
 // send a list of all torrents to the user in shape of a select menu
-func listTorrents(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	allTorrents := torrentClient.GetAllTorrents()
+func listTorrents(s *discordgo.Session, i *discordgo.InteractionCreate, state torrentClient.TorrentState, name string) {
+	sendErr := interaction.RespondWithThinking(s, i, false)
+	if sendErr != nil {
+		Log.Error("\nTorrent:", `sending a respond for "torrent" command:`, sendErr.Error())
+		return
+	}
 
-	// no torrents found
-	if len(allTorrents) == 0 {
-		sendErr := interaction.RespondWithText(s, i, "No torrents found.", true)
+	list := ListTmp{UserID: utils.GetInteractionAuthor(i.Interaction).ID, State: state, Name: name}
+	content, messageComponents := createListPage(&list)
+
+	msg, sendErr := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: messageComponents,
+	})
+	if sendErr != nil {
+		Log.Error("\nTorrent:", `sending a respond for "torrent" command:`, sendErr.Error())
+		return
+	}
+
+	setList(msg.ID, list)
+}
+
+// setList saves the state of a list message and removes the ones that were not used for listTTL
+func setList(messageID string, list ListTmp) {
+	listTmpMutex.Lock()
+	defer listTmpMutex.Unlock()
+
+	now := time.Now()
+	for id, old := range listTmp {
+		if now.Sub(old.usedAt) >= listTTL {
+			delete(listTmp, id)
+		}
+	}
+
+	list.usedAt = now
+	listTmp[messageID] = &list
+}
+
+func removeList(messageID string) {
+	listTmpMutex.Lock()
+	defer listTmpMutex.Unlock()
+
+	delete(listTmp, messageID)
+}
+
+// getList returns a copy of the state of a list message
+func getList(messageID string) (ListTmp, bool) {
+	listTmpMutex.Lock()
+	defer listTmpMutex.Unlock()
+
+	list, ok := listTmp[messageID]
+	if !ok {
+		return ListTmp{}, false
+	}
+	return *list, true
+}
+
+// getOwnList returns the state of the list message of a component, it responds to the interaction and returns false
+// when the list expired or the user is not the one who sent it
+func getOwnList(s *discordgo.Session, i *discordgo.InteractionCreate) (ListTmp, bool) {
+	list, ok := getList(i.Message.ID)
+	if !ok {
+		events.RespondExpired(s, i)
+		return list, false
+	}
+
+	if list.UserID != utils.GetInteractionAuthor(i.Interaction).ID {
+		respondNotYourList(s, i)
+		return list, false
+	}
+
+	return list, true
+}
+
+// respondNotYourList tells a user that another user's list can't be used
+func respondNotYourList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	sendErr := interaction.RespondWithText(s, i, "❗ This list is not yours, use `/torrent list` to get your own.", true)
+	if sendErr != nil {
+		Log.Error("\ntorrentList:", sendErr.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+	}
+}
+
+// listPageButton moves the torrents list to the next or the previous page
+func listPageButton(s *discordgo.Session, i *discordgo.InteractionCreate, step int) {
+	list, ok := getOwnList(s, i)
+	if !ok {
+		return
+	}
+
+	sendErr := interaction.RespondWithNothing(s, i)
+	if sendErr != nil {
+		Log.Error("\ntorrentList:", sendErr.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+		return
+	}
+
+	list.Page += step
+	list.Selected = nil
+
+	content, messageComponents := createListPage(&list)
+	setList(i.Message.ID, list)
+
+	sendErr = interaction.RespondEditWithComponents(s, i, &content, messageComponents)
+	if sendErr != nil {
+		Log.Error("\ntorrentList:", sendErr.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+	}
+}
+
+// torrentBulkButton runs an action on all the selected torrents
+func torrentBulkButton(s *discordgo.Session, i *discordgo.InteractionCreate, action string) {
+	list, ok := getOwnList(s, i)
+	if !ok {
+		return
+	}
+
+	sendErr := interaction.RespondWithNothing(s, i)
+	if sendErr != nil {
+		Log.Error("\ntorrentList:", sendErr.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+		return
+	}
+
+	if len(list.Selected) == 0 {
+		sendErr = interaction.RespondEdit(s, i, "No torrents selected, please use `/torrent list` again.")
 		if sendErr != nil {
-			Log.Error("\nTorrent:", `sending a respond for "torrent" command:`, sendErr.Error())
+			Log.Error("\ntorrentList:", sendErr.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
 		}
 		return
 	}
 
-	// create select menu options for each torrent
-	var menuOptions []*components.SelectMenuOption
-	for i, torrent := range allTorrents {
-		if i >= 24 {
-			break
+	Log.Debug(Log.Level.Info, "Torrent bulk action:", action, "GuildID:", i.GuildID, "Torrents:", strings.Join(list.Selected, ", "))
+
+	succeeded := 0
+	errors := ""
+	links := ""
+	config := utils.GetAppConfig()
+
+	for _, torrentID := range list.Selected {
+		name := torrentClient.GetTorrentName(torrentID)
+
+		var err error
+		switch action {
+		case "remove":
+			err = torrentClient.Remove(torrentID)
+		case "stop":
+			err = torrentClient.StopByID(torrentID)
+		case "resume":
+			err = torrentClient.StartByID(torrentID)
+		case "links":
+			var tor, getErr = torrentClient.GetTorrentByID(torrentID)
+			if getErr != nil {
+				err = getErr
+				break
+			}
+
+			var urls []string
+			urls, err = getVideoUrls(tor)
+			for _, url := range urls {
+				links += fmt.Sprint(config.Http.Domain, config.Http.Routes.Video, url, "\n")
+			}
+		}
+
+		if err != nil {
+			Log.Debug(Log.Level.Error, "torrent bulk action", action, torrentID, err.Error())
+			errors += fmt.Sprintf("🔸 **%s**: `%s`\n", name, err.Error())
+			continue
 		}
-		menuOptions = append(menuOptions, components.NewMenuOption().SetLabel(torrent.Name()).SetValue(torrent.ID()))
+
+		if action == "remove" {
+			recordRemove(s, i, torrentID, name)
+		}
+
+		succeeded++
 	}
 
-	content := fmt.Sprintf("Found %d torrents", len(allTorrents))
-	if len(allTorrents) > 25 {
-		content += ", (Can only show 25 torrents at once)"
+	content := fmt.Sprintf("**Success:** `%s` done for `%d` of `%d` torrents.\n", action, succeeded, len(list.Selected))
+	if action == "links" {
+		if links == "" {
+			links = "No videos found.\n"
+		}
+		content = "Video links:\n" + links
+	}
+	if errors != "" {
+		content += "\u200b\n**Errors:**\n" + errors
 	}
 
-	sendErr := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: "\u200b\n" + content,
-			Components: *components.AddMessageComponents(
-				components.NewRow(
-					components.NewSelectMenu().SetStringType().SetPlaceholder("Select a torrent to show actions").
-						SetCustomID("torrent_list").
-						SetOptions(menuOptions...),
-				),
-			),
-		},
-	})
+	// discord message limit
+	content = utils.Truncate(content, 2000)
+
+	list.Selected = nil
+	setList(i.Message.ID, list)
 
+	sendErr = interaction.RespondEditWithComponents(s, i, &content,
+		components.AddMessageComponents(
+			components.NewRow(
+				components.NewButton().SetLabel("Show Torrents List").SetCustomID(showListRoute.CustomID(events.NoPayload{})).SetStyleSecondary(),
+			),
+		),
+	)
 	if sendErr != nil {
-		Log.Error("\nTorrent:", `sending a respond for "torrent" command:`, sendErr.Error())
+		Log.Error("\ntorrentList:", sendErr.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+	}
+}
+
+// createListPage creates the content and the components of the current page of a torrents list
+func createListPage(list *ListTmp) (string, *[]discordgo.MessageComponent) {
+	allTorrents := torrentClient.FilterTorrents(list.State, list.Name)
+
+	filters := fmt.Sprintf("**Status:** `%s`", list.State)
+	if list.Name != "" {
+		filters += fmt.Sprintf(", **Name:** `%s`", list.Name)
+	}
+
+	// no torrents found
+	if len(allTorrents) == 0 {
+		return "\u200b\nNo torrents found.\n" + filters, &[]discordgo.MessageComponent{}
+	}
+
+	pagesCount := (len(allTorrents) + listPageSize - 1) / listPageSize
+	if list.Page >= pagesCount {
+		list.Page = pagesCount - 1
+	}
+	if list.Page < 0 {
+		list.Page = 0
 	}
+
+	start := list.Page * listPageSize
+	end := min(start+listPageSize, len(allTorrents))
+
+	// create select menu options for each torrent
+	var menuOptions []*components.SelectMenuOption
+	for _, torrent := range allTorrents[start:end] {
+		name := torrent.Name()
+		if name == "" {
+			name = torrent.ID()
+		}
+		name = utils.Truncate(name, 100)
+
+		description := fmt.Sprintf("%s • %s • %.2f%% • Ratio: %.2f",
+			torrentClient.GetTorrentState(torrent),
+			torrentClient.FormatBytes(torrent.Stats().Bytes.Total),
+			torrentClient.GetProgress(torrent),
+			torrentClient.GetRatio(torrent),
+		)
+
+		menuOptions = append(menuOptions, components.NewMenuOption().SetLabel(name).SetValue(torrent.ID()).SetDescription(description))
+	}
+
+	content := fmt.Sprintf("\u200b\nFound `%d` torrents, page `%d` of `%d`\n%s\n_Select one torrent to show its actions, or many for bulk actions._",
+		len(allTorrents), list.Page+1, pagesCount, filters,
+	)
+
+	minValues := 1
+	maxValues := len(menuOptions)
+
+	return content, components.AddMessageComponents(
+		components.NewRow(
+			components.NewSelectMenu().SetStringType().SetPlaceholder("Select torrents to show actions").
+				SetCustomID(listSelectRoute.CustomID(events.NoPayload{})).
+				SetMinValues(&minValues).
+				SetMaxValues(&maxValues).
+				SetOptions(menuOptions...),
+		),
+		components.NewRow(
+			components.NewButton().SetLabel("Previous Page").SetCustomID(listPageRoute.CustomID(-1)).SetStyleSecondary().SetDisabled(list.Page == 0),
+			components.NewButton().SetLabel("Next Page").SetCustomID(listPageRoute.CustomID(1)).SetStyleSecondary().SetDisabled(list.Page >= pagesCount-1),
+		),
+	)
 }
@@ -2,7 +2,9 @@ package torrentCommand
 
 import (
 	"discord-bot/common"
+	"discord-bot/discord/audit"
 	"discord-bot/discord/components"
+	"discord-bot/discord/events"
 	"discord-bot/discord/interaction"
 	"discord-bot/torrentClient"
 	"discord-bot/utils"
@@ -18,11 +20,19 @@ import (
 
 func ytsListOnSelect(data *discordgo.MessageComponentInteractionData, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	selectedValue := data.Values[0]
-	addTorrent(s, i, &selectedValue, nil)
+	addTorrent(s, i, audit.ActionTorrentAdd, startFromURI(selectedValue, nil))
 }
 
-func torrentStopAndRemoveButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	torrentClient.Stop(true)
+func torrentStopAndRemoveButton(s *discordgo.Session, i *discordgo.InteractionCreate, torrentID string) {
+	name := torrentClient.GetTorrentName(torrentID)
+
+	// the button of an old progress message, another torrent is downloading now
+	if !torrentClient.StopCurrent(torrentID, true) {
+		events.RespondExpired(s, i)
+		return
+	}
+
+	recordRemove(s, i, torrentID, name)
 
 	sendErr := interaction.RespondWithNothing(s, i)
 	if sendErr != nil {
@@ -31,8 +41,12 @@ func torrentStopAndRemoveButton(s *discordgo.Session, i *discordgo.InteractionCr
 	}
 }
 
-func torrentStopButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	torrentClient.Stop(false)
+func torrentStopButton(s *discordgo.Session, i *discordgo.InteractionCreate, torrentID string) {
+	// the button of an old progress message, another torrent is downloading now
+	if !torrentClient.StopCurrent(torrentID, false) {
+		events.RespondExpired(s, i)
+		return
+	}
 
 	sendErr := interaction.RespondWithNothing(s, i)
 	if sendErr != nil {
@@ -42,6 +56,11 @@ func torrentStopButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
 }
 
 func onTorrentListSelect(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.MessageComponentInteractionData) {
+	list, ok := getOwnList(s, i)
+	if !ok {
+		return
+	}
+
 	sendErr := interaction.RespondWithNothing(s, i)
 	if sendErr != nil {
 		Log.Error("\ntorrentList:", sendErr.Error())
@@ -49,6 +68,34 @@ func onTorrentListSelect(s *discordgo.Session, i *discordgo.InteractionCreate, d
 		return
 	}
 
+	list.Selected = data.Values
+	setList(i.Message.ID, list)
+
+	// multiple torrents selected
+	if len(data.Values) > 1 {
+		content := fmt.Sprintf("**%d** torrents selected\n\u200b", len(data.Values))
+		sendErr = interaction.RespondEditWithComponents(s, i, &content,
+			components.AddMessageComponents(
+				components.NewRow(
+					components.NewButton().SetLabel("Generate Video Links").SetCustomID(bulkRoute.CustomID("links")).SetStyleSecondary(),
+				),
+				components.NewRow(
+					components.NewButton().SetLabel("Resume All").SetCustomID(bulkRoute.CustomID("resume")).SetStylePrimary(),
+					components.NewButton().SetLabel("Stop All").SetCustomID(bulkRoute.CustomID("stop")).SetStyleSecondary(),
+					components.NewButton().SetLabel("Remove All").SetCustomID(bulkRoute.CustomID("remove")).SetStyleDanger(),
+				),
+				components.NewRow(
+					components.NewButton().SetLabel("Show Torrents List").SetCustomID(showListRoute.CustomID(events.NoPayload{})).SetStyleSecondary(),
+				),
+			),
+		)
+		if sendErr != nil {
+			Log.Error("\ntorrentList:", sendErr.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
+		}
+		return
+	}
+
 	torrentID := data.Values[0]
 	torrentName := fmt.Sprintf("**%s**\n\u200b", torrentClient.GetTorrentName(torrentID))
 	exists := torrentClient.Exists(torrentID)
@@ -67,14 +114,15 @@ func onTorrentListSelect(s *discordgo.Session, i *discordgo.InteractionCreate, d
 	sendErr = interaction.RespondEditWithComponents(s, i, &torrentName,
 		components.AddMessageComponents(
 			components.NewRow(
-				components.NewButton().SetLabel("Generate Video Links").SetCustomID("torrent_links:"+torrentID).SetStyleSecondary(),
+				components.NewButton().SetLabel("Details").SetCustomID(detailsRoute.CustomID(torrentID)).SetStyleSecondary(),
+				components.NewButton().SetLabel("Generate Video Links").SetCustomID(linksRoute.CustomID(torrentID)).SetStyleSecondary(),
 			),
 			components.NewRow(
-				components.NewButton().SetLabel("Resume").SetCustomID("torrent_resume:"+torrentID).SetStylePrimary(),
-				components.NewButton().SetLabel("Remove").SetCustomID("torrent_remove:"+torrentID).SetStyleDanger(),
+				components.NewButton().SetLabel("Resume").SetCustomID(resumeRoute.CustomID(torrentID)).SetStylePrimary(),
+				components.NewButton().SetLabel("Remove").SetCustomID(removeRoute.CustomID(torrentID)).SetStyleDanger(),
 			),
 			components.NewRow(
-				components.NewButton().SetLabel("Show Torrents List").SetCustomID("show_torrents_list").SetStyleSecondary(),
+				components.NewButton().SetLabel("Show Torrents List").SetCustomID(showListRoute.CustomID(events.NoPayload{})).SetStyleSecondary(),
 			),
 		),
 	)
@@ -86,8 +134,7 @@ func onTorrentListSelect(s *discordgo.Session, i *discordgo.InteractionCreate, d
 	}
 }
 
-func generateLinksButton(data *discordgo.MessageComponentInteractionData, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	torrentID := strings.Split(data.CustomID, ":")[1]
+func generateLinksButton(s *discordgo.Session, i *discordgo.InteractionCreate, torrentID string) {
 
 	sendErr := interaction.RespondWithNothing(s, i)
 	if sendErr != nil {
@@ -135,8 +182,7 @@ func generateLinksButton(data *discordgo.MessageComponentInteractionData, s *dis
 	}
 }
 
-func torrentResumeButton(data *discordgo.MessageComponentInteractionData, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	torrentID := strings.Split(data.CustomID, ":")[1]
+func torrentResumeButton(s *discordgo.Session, i *discordgo.InteractionCreate, torrentID string) {
 
 	tor, err := torrentClient.GetTorrentByID(torrentID)
 	if err != nil {
@@ -163,11 +209,10 @@ func torrentResumeButton(data *discordgo.MessageComponentInteractionData, s *dis
 		return
 	}
 
-	addTorrent(s, i, nil, tor)
+	addTorrent(s, i, audit.ActionTorrentResume, startFromTorrent(tor))
 }
 
-func TorrentDeleteButton(data *discordgo.MessageComponentInteractionData, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	torrentID := strings.Split(data.CustomID, ":")[1]
+func TorrentDeleteButton(s *discordgo.Session, i *discordgo.InteractionCreate, torrentID string) {
 
 	sendErr := interaction.RespondWithNothing(s, i)
 	if sendErr != nil {
@@ -176,6 +221,7 @@ func TorrentDeleteButton(data *discordgo.MessageComponentInteractionData, s *dis
 		return
 	}
 
+	name := torrentClient.GetTorrentName(torrentID)
 	err := torrentClient.Remove(torrentID)
 	if err != nil {
 		Log.Debug(Log.Level.Error, "removing a torrent", err.Error())
@@ -192,15 +238,49 @@ func TorrentDeleteButton(data *discordgo.MessageComponentInteractionData, s *dis
 		Log.Error("\ntorrentList:", sendErr.Error())
 		Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
 	}
+
+	recordRemove(s, i, torrentID, name)
+}
+
+// recordRemove adds a removed torrent to the audit log
+func recordRemove(s *discordgo.Session, i *discordgo.InteractionCreate, torrentID string, name string) {
+	audit.Record(s, audit.Change{
+		GuildID: i.GuildID,
+		UserID:  utils.GetInteractionAuthor(i.Interaction).ID,
+		Action:  audit.ActionTorrentRemove,
+		Target:  torrentTarget(torrentID, name),
+	})
+}
+
+// torrentTarget is the name of a torrent in the audit log, the magnet links have no name until the metadata is downloaded
+func torrentTarget(torrentID string, name string) string {
+	if name == "" {
+		return torrentID
+	}
+	return name
 }
 
 func showTorrentListButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	list, ok := getList(i.Message.ID)
+	if ok && list.UserID != utils.GetInteractionAuthor(i.Interaction).ID {
+		respondNotYourList(s, i)
+		return
+	}
+
 	msgDeleteErr := s.ChannelMessageDelete(i.ChannelID, i.Message.ID)
 	if msgDeleteErr != nil {
 		Log.Error("\ntorrentList:", msgDeleteErr.Error())
 		Log.Debug(Log.Level.Error, `deleting a message for "torrent" command:`, msgDeleteErr.Error())
 	}
-	listTorrents(s, i)
+
+	// keep the filters of the previous list
+	state, name := torrentClient.StateAll, ""
+	if ok {
+		state, name = list.State, list.Name
+	}
+	removeList(i.Message.ID)
+
+	listTorrents(s, i, state, name)
 }
 
 func onSearchListSelect(data *discordgo.MessageComponentInteractionData, s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -210,11 +290,7 @@ func onSearchListSelect(data *discordgo.MessageComponentInteractionData, s *disc
 	search, ok := searchTmp[i.GuildID+i.ChannelID]
 
 	if !ok || err != nil || searchIndex < 0 || searchIndex >= len(search.Results) {
-		sendErr := interaction.RespondWithText(s, i, "No search results found.", false)
-		if sendErr != nil {
-			Log.Error("\nTorrent:", sendErr.Error())
-			Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
-		}
+		events.RespondExpired(s, i)
 		return
 	}
 
@@ -250,7 +326,7 @@ func onSearchListSelect(data *discordgo.MessageComponentInteractionData, s *disc
 			Embeds:  &[]*discordgo.MessageEmbed{createSearchDetailEmbed(searchResult)},
 			Components: components.AddMessageComponents(
 				components.NewRow(
-					components.NewButton().SetLabel("Download").SetCustomID("search_download:" + searchIndexStr).SetStyleSecondary(),
+					components.NewButton().SetLabel("Download").SetCustomID(searchDownloadRoute.CustomID(searchIndex)).SetStyleSecondary(),
 				),
 			),
 		})
@@ -270,7 +346,7 @@ func onSearchListSelect(data *discordgo.MessageComponentInteractionData, s *disc
 			Embeds:  []*discordgo.MessageEmbed{createSearchDetailEmbed(searchResult)},
 			Components: *components.AddMessageComponents(
 				components.NewRow(
-					components.NewButton().SetLabel("Download").SetCustomID("search_download:" + searchIndexStr).SetStyleSecondary(),
+					components.NewButton().SetLabel("Download").SetCustomID(searchDownloadRoute.CustomID(searchIndex)).SetStyleSecondary(),
 				),
 			),
 		},
@@ -290,6 +366,12 @@ func onSearchListSelect(data *discordgo.MessageComponentInteractionData, s *disc
 }
 
 func nextPageButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	search, ok := searchTmp[i.GuildID+i.ChannelID]
+	if !ok {
+		events.RespondExpired(s, i)
+		return
+	}
+
 	sendErr := interaction.RespondWithNothing(s, i)
 	if sendErr != nil {
 		Log.Error("\ntorrentList:", sendErr.Error())
@@ -297,19 +379,13 @@ func nextPageButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
-	search, ok := searchTmp[i.GuildID+i.ChannelID]
-	if !ok {
-		sendErr = interaction.RespondEdit(s, i, "No previous page")
-		if sendErr != nil {
-			Log.Error("\nTorrent:", sendErr.Error())
-			Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
-		}
-		return
-	}
-
 	search.Options.page += 1
 
-	results := search_1337x(search.Options.query, search.Options.category, search.Options.sort, search.Options.page)
+	results, err := search_1337x(search.Options.query, search.Options.category, search.Options.sort, search.Options.page)
+	if err != nil {
+		events.RespondError(s, i, "searching 1337x", err)
+		return
+	}
 
 	if len(results) == 0 {
 		sendErr = interaction.RespondEdit(s, i, "No results found.")
@@ -349,11 +425,11 @@ func nextPageButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		components.AddMessageComponents(
 			components.NewRow(
 				components.NewSelectMenu().SetStringType().SetPlaceholder("Select a result to download").
-					SetCustomID("search_list").
+					SetCustomID(searchSelectRoute.CustomID(events.NoPayload{})).
 					SetOptions(menuOptions...),
 			),
 			components.NewRow(
-				components.NewButton().SetLabel("Next Page").SetCustomID("next_page"),
+				components.NewButton().SetLabel("Next Page").SetCustomID(searchNextPageRoute.CustomID(events.NoPayload{})),
 			),
 		),
 	)
@@ -363,17 +439,11 @@ func nextPageButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 }
 
-func searchDownloadButton(data *discordgo.MessageComponentInteractionData, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	indexStr := strings.Split(data.CustomID, ":")[1]
-	searchIndex, err := strconv.Atoi(indexStr)
-
+func searchDownloadButton(s *discordgo.Session, i *discordgo.InteractionCreate, searchIndex int) {
+	// the search results are removed with the message
 	search, ok := searchTmp[i.GuildID+i.ChannelID]
-	if !ok || err != nil || searchIndex < 0 || searchIndex >= len(search.Results) {
-		sendErr := interaction.RespondWithText(s, i, "No search results found.", false)
-		if sendErr != nil {
-			Log.Error("\nTorrent:", sendErr.Error())
-			Log.Debug(Log.Level.Error, `sending a respond for "torrent" command:`, sendErr.Error())
-		}
+	if !ok || searchIndex < 0 || searchIndex >= len(search.Results) {
+		events.RespondExpired(s, i)
 		return
 	}
 
@@ -389,7 +459,7 @@ func searchDownloadButton(data *discordgo.MessageComponentInteractionData, s *di
 		return
 	}
 
-	addTorrent(s, i, &magnet, nil)
+	addTorrent(s, i, audit.ActionTorrentAdd, startFromURI(magnet, nil))
 }
 
 // utils
@@ -0,0 +1,99 @@
+package torrentCommand
+
+import (
+	"discord-bot/discord/events"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// YtsTorrentsRoute is the select menu of the torrents of a YTS movie, it's sent by the yts command
+var YtsTorrentsRoute = events.NewRoute[events.NoPayload]("yts_torrents", 1)
+
+var (
+	// the payload is the torrent ID
+	stopRoute          = events.NewRoute[string]("torrent_stop", 2)
+	stopAndRemoveRoute = events.NewRoute[string]("torrent_stop_remove", 2)
+	removeRoute        = events.NewRoute[string]("torrent_remove", 1)
+	resumeRoute        = events.NewRoute[string]("torrent_resume", 1)
+	detailsRoute       = events.NewRoute[string]("torrent_details", 1)
+	linksRoute         = events.NewRoute[string]("torrent_links", 1)
+
+	showListRoute   = events.NewRoute[events.NoPayload]("show_torrents_list", 1)
+	listSelectRoute = events.NewRoute[events.NoPayload]("torrent_list", 1)
+	listPageRoute   = events.NewRoute[int]("torrent_list_page", 1) // the payload is the page step, -1 or 1
+	bulkRoute       = events.NewRoute[string]("torrent_bulk", 1)   // the payload is the action
+	fileAddRoute    = events.NewRoute[events.NoPayload]("torrent_file_add", 1)
+	fileCancelRoute = events.NewRoute[events.NoPayload]("torrent_file_cancel", 1)
+
+	searchSelectRoute   = events.NewRoute[events.NoPayload]("search_list", 1)
+	searchNextPageRoute = events.NewRoute[events.NoPayload]("search_next_page", 1)
+	searchDownloadRoute = events.NewRoute[int]("search_download", 1) // the payload is the index of the search result
+)
+
+type componentData = *discordgo.MessageComponentInteractionData
+
+func registerRoutes() {
+	events.HandleComponent(YtsTorrentsRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, data componentData, _ events.NoPayload) {
+		ytsListOnSelect(data, s, i)
+	})
+
+	events.HandleComponent(stopRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, torrentID string) {
+		torrentStopButton(s, i, torrentID)
+	})
+
+	events.HandleComponent(stopAndRemoveRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, torrentID string) {
+		torrentStopAndRemoveButton(s, i, torrentID)
+	})
+
+	events.HandleComponent(removeRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, torrentID string) {
+		TorrentDeleteButton(s, i, torrentID)
+	})
+
+	events.HandleComponent(resumeRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, torrentID string) {
+		torrentResumeButton(s, i, torrentID)
+	})
+
+	events.HandleComponent(detailsRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, torrentID string) {
+		torrentDetailsButton(s, i, torrentID)
+	})
+
+	events.HandleComponent(linksRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, torrentID string) {
+		generateLinksButton(s, i, torrentID)
+	})
+
+	events.HandleComponent(showListRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, _ events.NoPayload) {
+		showTorrentListButton(s, i)
+	})
+
+	events.HandleComponent(listSelectRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, data componentData, _ events.NoPayload) {
+		onTorrentListSelect(s, i, data)
+	})
+
+	events.HandleComponent(listPageRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, step int) {
+		listPageButton(s, i, step)
+	})
+
+	events.HandleComponent(bulkRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, action string) {
+		torrentBulkButton(s, i, action)
+	})
+
+	events.HandleComponent(searchSelectRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, data componentData, _ events.NoPayload) {
+		onSearchListSelect(data, s, i)
+	})
+
+	events.HandleComponent(searchNextPageRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, _ events.NoPayload) {
+		nextPageButton(s, i)
+	})
+
+	events.HandleComponent(searchDownloadRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, searchIndex int) {
+		searchDownloadButton(s, i, searchIndex)
+	})
+
+	events.HandleComponent(fileAddRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, _ events.NoPayload) {
+		torrentFileAddButton(s, i)
+	})
+
+	events.HandleComponent(fileCancelRoute, func(s *discordgo.Session, i *discordgo.InteractionCreate, _ componentData, _ events.NoPayload) {
+		torrentFileCancelButton(s, i)
+	})
+}
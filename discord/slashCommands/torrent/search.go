@@ -3,7 +3,9 @@ package torrentCommand
 import (
 	"discord-bot/common"
 	"discord-bot/discord/components"
+	"discord-bot/discord/events"
 	"discord-bot/discord/interaction"
+	"discord-bot/ratelimit"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -36,7 +38,11 @@ func searchTorrent(s *discordgo.Session, i *discordgo.InteractionCreate, options
 		options.page = 1
 	}
 
-	results := search_1337x(options.query, options.category, options.sort, options.page)
+	results, err := search_1337x(options.query, options.category, options.sort, options.page)
+	if err != nil {
+		events.RespondError(s, i, "searching 1337x", err)
+		return
+	}
 
 	if len(results) == 0 {
 		sendErr = interaction.RespondEdit(s, i, "No results found.")
@@ -77,23 +83,28 @@ func searchTorrent(s *discordgo.Session, i *discordgo.InteractionCreate, options
 		Components: components.AddMessageComponents(
 			components.NewRow(
 				components.NewSelectMenu().SetStringType().SetPlaceholder("Select a result to show details").
-					SetCustomID("search_list").
+					SetCustomID(searchSelectRoute.CustomID(events.NoPayload{})).
 					SetOptions(menuOptions...),
 			),
 			components.NewRow(
-				components.NewButton().SetLabel("Next Page").SetCustomID("next_page"),
+				components.NewButton().SetLabel("Next Page").SetCustomID(searchNextPageRoute.CustomID(events.NoPayload{})),
 			),
 		),
 	})
 	if sendErr != nil {
 		Log.Error("\nTorrent:", `sending a respond for "torrent" command:`, sendErr.Error())
+		return
 	}
 
 	searchTmp[i.GuildID+i.ChannelID].MsgID = msg.ID
 
 	// delete self after 1 minute
 	go func() {
-		<-time.After(time.Minute)
+		defer events.RecoverEvent("torrent:searchExpiry")
+
+		if !events.Sleep(time.Minute) {
+			return
+		}
 
 		search, ok := searchTmp[i.GuildID+i.ChannelID]
 		if !ok {
@@ -119,7 +130,7 @@ func searchTorrent(s *discordgo.Session, i *discordgo.InteractionCreate, options
 	}()
 }
 
-func search_1337x(query string, category common.X1337xCategory, sort common.X1337xSort, page int) (results []common.SearchResult) {
+func search_1337x(query string, category common.X1337xCategory, sort common.X1337xSort, page int) (results []common.SearchResult, err error) {
 	if page < 1 {
 		page = 1
 	}
@@ -139,10 +150,15 @@ func search_1337x(query string, category common.X1337xCategory, sort common.X133
 	}
 
 	fullUrl, err := url.JoinPath(baseUrl, searchCMD, query, category.Parse(), sort.Parse(), pageStr, "/")
+	if err != nil {
+		return nil, err
+	}
 
+	err = ratelimit.Scraping.Wait(events.Context())
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	defer ratelimit.Scraping.Release()
 
 	c := colly.NewCollector()
 
@@ -195,10 +211,16 @@ func search_1337x(query string, category common.X1337xCategory, sort common.X133
 
 	c.Visit(fullUrl)
 
-	return
+	return results, nil
 }
 
 func getMagnet(url string) (magnet string) {
+	if err := ratelimit.Scraping.Wait(events.Context()); err != nil {
+		Log.Debug(Log.Level.Warning, "getting a magnet link:", err.Error())
+		return
+	}
+	defer ratelimit.Scraping.Release()
+
 	c := colly.NewCollector()
 
 	c.OnHTML(`a[href^="magnet:?"]`, func(e *colly.HTMLElement) {
@@ -2,6 +2,7 @@ package welcomeVoiceMessage
 
 import (
 	"discord-bot/common"
+	"discord-bot/discord/audit"
 	"discord-bot/discord/events"
 	"discord-bot/discord/interaction"
 	"discord-bot/firebase"
@@ -13,6 +14,35 @@ import (
 
 var Log = &utils.Log
 
+// the languages supported by the TTS
+var languageChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "Afrikaans", Value: "af"},
+	{Name: "Armenian", Value: "hy"},
+	{Name: "Indonesian", Value: "id"},
+	{Name: "German", Value: "de"},
+	{Name: "English", Value: "en"},
+	{Name: "Spanish", Value: "es"},
+	{Name: "French", Value: "fr"},
+	{Name: "Italian", Value: "it"},
+	{Name: "Dutch", Value: "nl"},
+	{Name: "Norwegian", Value: "nb"},
+	{Name: "Polish", Value: "pl"},
+	{Name: "Portuguese", Value: "pt"},
+	{Name: "Romanian", Value: "ro"},
+	{Name: "Finnish", Value: "fi"},
+	{Name: "Swedish", Value: "sv"},
+	{Name: "Turkish", Value: "tr"},
+	{Name: "Greek", Value: "el"},
+	{Name: "Russian", Value: "ru"},
+	{Name: "Ukrainian", Value: "uk"},
+	{Name: "Arabic", Value: "ar"},
+	{Name: "Persian", Value: "fa"},
+	{Name: "Hindi", Value: "hi"},
+	{Name: "Korean", Value: "ko"},
+	{Name: "Japanese", Value: "ja"},
+	{Name: "Chinese", Value: "zh"},
+}
+
 var command = common.SlashCommand{
 	Command: discordgo.ApplicationCommand{
 		Name:        "welcome-voice-message",
@@ -39,34 +69,21 @@ var command = common.SlashCommand{
 						Name:        "language",
 						Description: "Enter the language of the text",
 						Type:        discordgo.ApplicationCommandOptionString,
-						Choices: []*discordgo.ApplicationCommandOptionChoice{
-							{Name: "Afrikaans", Value: "af"},
-							{Name: "Armenian", Value: "hy"},
-							{Name: "Indonesian", Value: "id"},
-							{Name: "German", Value: "de"},
-							{Name: "English", Value: "en"},
-							{Name: "Spanish", Value: "es"},
-							{Name: "French", Value: "fr"},
-							{Name: "Italian", Value: "it"},
-							{Name: "Dutch", Value: "nl"},
-							{Name: "Norwegian", Value: "nb"},
-							{Name: "Polish", Value: "pl"},
-							{Name: "Portuguese", Value: "pt"},
-							{Name: "Romanian", Value: "ro"},
-							{Name: "Finnish", Value: "fi"},
-							{Name: "Swedish", Value: "sv"},
-							{Name: "Turkish", Value: "tr"},
-							{Name: "Greek", Value: "el"},
-							{Name: "Russian", Value: "ru"},
-							{Name: "Ukrainian", Value: "uk"},
-							{Name: "Arabic", Value: "ar"},
-							{Name: "Persian", Value: "fa"},
-							{Name: "Hindi", Value: "hi"},
-							{Name: "Korean", Value: "ko"},
-							{Name: "Japanese", Value: "ja"},
-							{Name: "Chinese", Value: "zh"},
-						},
-						Required: false,
+						Choices:     languageChoices,
+						Required:    false,
+					},
+				},
+			},
+			{
+				Name:        "edit",
+				Description: "Edit a welcome TTS message in an editor",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "user",
+						Description: "The user to edit the welcome message for",
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Required:    true,
 					},
 				},
 			},
@@ -86,16 +103,29 @@ var command = common.SlashCommand{
 		},
 	},
 
+	Feature: common.FeatureWelcomeVoiceMessage,
 	Handler: cmdHandler,
 }
 
+// user context menu, shown under "Apps" when right clicking a user
+var userMenu = common.SlashCommand{
+	Command: discordgo.ApplicationCommand{
+		Name: "Set welcome message",
+		Type: discordgo.UserApplicationCommand,
+	},
+	Feature: common.FeatureWelcomeVoiceMessage,
+	Handler: userMenuHandler,
+}
+
 func init() {
 	events.RegisterSlashCommand(&command)
+	events.RegisterSlashCommand(&userMenu)
+	events.HandleModal(editModalRoute, onEditModalSubmit)
 }
 
 type cmdOptions struct {
-	subcommand string                                             // "set" or "remove"
-	user       *discordgo.ApplicationCommandInteractionDataOption // required for "set" and "remove"
+	subcommand string                                             // "set", "edit" or "remove"
+	user       *discordgo.ApplicationCommandInteractionDataOption // required for "set", "edit" and "remove"
 	message    string                                             // required for "set"
 	language   string                                             // optional
 }
@@ -106,6 +136,20 @@ func parseCmdOptions(options []*discordgo.ApplicationCommandInteractionDataOptio
 	subcommand := options[0].Name
 	subcommandOptions := options[0].Options
 
+	if subcommand == "edit" {
+		results.subcommand = "edit"
+
+		for _, opt := range subcommandOptions {
+			switch opt.Name {
+			case "user":
+				if opt.Value == nil {
+					return results, fmt.Errorf("please choose a user")
+				}
+				results.user = opt
+			}
+		}
+	}
+
 	if subcommand == "remove" {
 		results.subcommand = "remove"
 
@@ -231,6 +275,30 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 			Log.Error("\nWelcomeVoiceMessage:", sendError.Error())
 			Log.Debug(Log.Level.Error, `sending a respond for "welcome-voice-message" command:`, sendError.Error())
 		}
+
+		change := audit.Change{
+			GuildID: i.GuildID,
+			UserID:  user.ID,
+			Action:  audit.ActionWelcomeSet,
+			Target:  "<@" + setForUser.ID + ">",
+			After:   DescribeMessage(newItem),
+		}
+		if exists {
+			change.Before = DescribeMessage(*currentItem)
+		}
+		audit.Record(s, change)
+		return
+	}
+
+	// * EDIT
+	if options.subcommand == "edit" {
+		currentItem, notFoundErr := guildData.VoiceMessagesGetItem(setForUser.ID)
+		if notFoundErr != nil {
+			// start with an empty message
+			currentItem = &firebase.VoiceWelcomeMessage{Id: setForUser.ID, Lang: "en"}
+		}
+
+		openEditModal(s, i, setForUser, currentItem)
 		return
 	}
 
@@ -270,6 +338,14 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 			Log.Error("\nWelcomeVoiceMessage:", sendError.Error())
 			Log.Debug(Log.Level.Error, `sending a respond for "welcome-voice-message" command:`, sendError.Error())
 		}
+
+		audit.Record(s, audit.Change{
+			GuildID: i.GuildID,
+			UserID:  user.ID,
+			Action:  audit.ActionWelcomeRemove,
+			Target:  "<@" + setForUser.ID + ">",
+			Before:  DescribeMessage(*currentItem),
+		})
 		return
 	}
 }
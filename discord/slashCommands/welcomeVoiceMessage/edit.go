@@ -0,0 +1,178 @@
+package welcomeVoiceMessage
+
+import (
+	"discord-bot/discord/audit"
+	"discord-bot/discord/components"
+	"discord-bot/discord/events"
+	"discord-bot/discord/interaction"
+	"discord-bot/firebase"
+	"discord-bot/utils"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// userMenuHandler opens the edit modal for the user targeted by the context menu
+func userMenuHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *discordgo.ApplicationCommandInteractionData) {
+	Log.Debug(Log.Level.Info, "Command:", appData.Name, "GuildID:", i.GuildID, "ChannelID:", i.ChannelID, "UserID:", i.Member.User.ID, "UserName:", i.Member.User.Username)
+
+	user, ok := appData.Resolved.Users[appData.TargetID]
+	if !ok {
+		sendError := interaction.RespondWithText(s, i, "**Error:** while getting the user:\n`user not found`", true)
+		if sendError != nil {
+			Log.Error("\nWelcomeVoiceMessage:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "welcome-voice-message" command:`, sendError.Error())
+		}
+		return
+	}
+
+	if user.Bot {
+		sendError := interaction.RespondWithText(s, i, "**Error:** Cannot set welcome message for bots", true)
+		if sendError != nil {
+			Log.Error("\nWelcomeVoiceMessage:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "welcome-voice-message" command:`, sendError.Error())
+		}
+		return
+	}
+
+	guildData, err := firebase.GetGuildData(i.GuildID)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `getting guild firebase data for "welcome-voice-message" command:`, err.Error())
+		sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while getting this guild data from firebase:\n`%s`", err.Error()), true)
+		if sendError != nil {
+			Log.Error("\nWelcomeVoiceMessage:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "welcome-voice-message" command:`, sendError.Error())
+		}
+		return
+	}
+
+	currentItem, notFoundErr := guildData.VoiceMessagesGetItem(user.ID)
+	if notFoundErr != nil {
+		// start with an empty message
+		currentItem = &firebase.VoiceWelcomeMessage{Id: user.ID, Lang: "en"}
+	}
+
+	openEditModal(s, i, user, currentItem)
+}
+
+// the payload is the ID of the user whose message is edited
+var editModalRoute = events.NewRoute[string]("welcome_message_edit", 1)
+
+// openEditModal opens a modal pre-filled with the current welcome message of a user
+func openEditModal(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, item *firebase.VoiceWelcomeMessage) {
+	sendError := interaction.RespondWithModal(s, i, editModalRoute.CustomID(user.ID), "Welcome message for "+user.Username,
+		components.NewRow(
+			components.NewTextInput().SetCustomID("message").SetLabel("Message").SetStyleParagraph().
+				SetValue(item.Message).SetRequired(true).SetMaxLength(200),
+		),
+		components.NewRow(
+			components.NewTextInput().SetCustomID("language").SetLabel("Language code, e.g. en, de, ar").SetStyleShort().
+				SetValue(item.Lang).SetRequired(false).SetMaxLength(5),
+		),
+	)
+	if sendError != nil {
+		Log.Error("\nWelcomeVoiceMessage:", sendError.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "welcome-voice-message" command:`, sendError.Error())
+	}
+}
+
+// onEditModalSubmit validates and saves the values of the edit modal
+func onEditModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.ModalSubmitInteractionData, userID string) {
+	newItem := firebase.VoiceWelcomeMessage{
+		Id:      userID,
+		Message: strings.TrimSpace(interaction.GetModalValue(data, "message")),
+		Lang:    strings.ToLower(strings.TrimSpace(interaction.GetModalValue(data, "language"))),
+	}
+
+	if newItem.Lang == "" {
+		newItem.Lang = "en"
+	}
+
+	err := ValidateMessage(newItem)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `validating "welcome-voice-message" message:`, err.Error())
+		sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while validating the welcome message:\n`%s`", err.Error()), true)
+		if sendError != nil {
+			Log.Error("\nWelcomeVoiceMessage:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "welcome-voice-message" command:`, sendError.Error())
+		}
+		return
+	}
+
+	guildData, err := firebase.GetGuildData(i.GuildID)
+	if err != nil {
+		Log.Debug(Log.Level.Error, `getting guild firebase data for "welcome-voice-message" command:`, err.Error())
+		sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while getting this guild data from firebase:\n`%s`", err.Error()), true)
+		if sendError != nil {
+			Log.Error("\nWelcomeVoiceMessage:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "welcome-voice-message" command:`, sendError.Error())
+		}
+		return
+	}
+
+	currentItem, notFoundErr := guildData.VoiceMessagesGetItem(userID)
+	exists := notFoundErr == nil
+	if exists {
+		guildData.VoiceMessagesUpdateItem(newItem)
+	} else {
+		guildData.VoiceMessagesAddItem(newItem)
+	}
+
+	voiceMessagesMap := guildData.VoiceMessagesToMap()
+	err = firebase.SetVoiceMessages(i.GuildID, &voiceMessagesMap)
+	if err != nil {
+		// revoke changes on error
+		if exists {
+			guildData.VoiceMessagesUpdateItem(*currentItem)
+		} else {
+			guildData.VoiceMessagesRemoveItem(newItem.Id)
+		}
+
+		Log.Debug(Log.Level.Error, `uploading "welcome-voice-message (edit)" data to firebase:`, err.Error())
+		sendError := interaction.RespondWithText(s, i, fmt.Sprintf("**Error:** while uploading **welcome-voice-message (edit)** data to firebase:\n`%s`", err.Error()), true)
+		if sendError != nil {
+			Log.Error("\nWelcomeVoiceMessage:", sendError.Error())
+			Log.Debug(Log.Level.Error, `sending a respond for "welcome-voice-message" command:`, sendError.Error())
+		}
+		return
+	}
+
+	sendError := interaction.RespondWithText(s, i, "**Success:** Welcome message updated", true)
+	if sendError != nil {
+		Log.Error("\nWelcomeVoiceMessage:", sendError.Error())
+		Log.Debug(Log.Level.Error, `sending a respond for "welcome-voice-message" command:`, sendError.Error())
+	}
+
+	change := audit.Change{
+		GuildID: i.GuildID,
+		UserID:  utils.GetInteractionAuthor(i.Interaction).ID,
+		Action:  audit.ActionWelcomeSet,
+		Target:  "<@" + userID + ">",
+		After:   DescribeMessage(newItem),
+	}
+	if exists {
+		change.Before = DescribeMessage(*currentItem)
+	}
+	audit.Record(s, change)
+}
+
+// DescribeMessage formats a welcome message for the audit log, like "[en] Hello"
+func DescribeMessage(item firebase.VoiceWelcomeMessage) string {
+	return fmt.Sprintf("[%s] %s", item.Lang, item.Message)
+}
+
+// ValidateMessage checks the message is not empty and the language is supported
+func ValidateMessage(item firebase.VoiceWelcomeMessage) error {
+	if item.Message == "" {
+		return fmt.Errorf("please enter a message")
+	}
+
+	for _, choice := range languageChoices {
+		if choice.Value == item.Lang {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported language: %s", item.Lang)
+}
@@ -5,6 +5,9 @@ import (
 	"discord-bot/discord/components"
 	"discord-bot/discord/events"
 	"discord-bot/discord/interaction"
+	torrentCommand "discord-bot/discord/slashCommands/torrent"
+	"discord-bot/ratelimit"
+	"discord-bot/torrentClient"
 	"discord-bot/utils"
 	"encoding/json"
 	"fmt"
@@ -34,7 +37,9 @@ var command = common.SlashCommand{
 		},
 	},
 
-	Handler: cmdHandler,
+	Feature:  common.FeatureYts,
+	Cooldown: 5 * time.Second,
+	Handler:  cmdHandler,
 }
 
 type SearchTmp struct {
@@ -47,9 +52,11 @@ type SearchTmp struct {
 // the key is [guildID+channelID]
 var searchTmp = map[string]*SearchTmp{}
 
+var moviesRoute = events.NewRoute[events.NoPayload]("yts", 1)
+
 func init() {
 	events.RegisterSlashCommand(&command)
-	events.RegisterComponentReactionEvent(ytsOnSelect)
+	events.HandleComponent(moviesRoute, ytsOnSelect)
 }
 
 type cmdOptions struct {
@@ -140,7 +147,11 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 	searchTmp[i.GuildID+i.ChannelID] = &SearchTmp{Movies: ytsResponse.Data.Movies, ChannelID: i.ChannelID, MsgID: msg.ID}
 
 	go func() {
-		<-time.After(time.Minute)
+		defer events.RecoverEvent("yts:expiry")
+
+		if !events.Sleep(time.Minute) {
+			return
+		}
 
 		search, ok := searchTmp[i.GuildID+i.ChannelID]
 		if !ok {
@@ -167,11 +178,7 @@ func cmdHandler(s *discordgo.Session, i *discordgo.InteractionCreate, appData *d
 }
 
 // ytsOnSelect is called when the user selects a movie from the select menu
-func ytsOnSelect(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.MessageComponentInteractionData) {
-	if data.CustomID != "yts" {
-		return
-	}
-
+func ytsOnSelect(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.MessageComponentInteractionData, _ events.NoPayload) {
 	selectedValue := data.Values[0]
 
 	movieIndex, err := strconv.Atoi(selectedValue)
@@ -184,11 +191,7 @@ func ytsOnSelect(s *discordgo.Session, i *discordgo.InteractionCreate, data *dis
 	search, ok := searchTmp[i.GuildID+i.ChannelID]
 
 	if !ok || search.Movies == nil || movieIndex < 0 || movieIndex >= len(search.Movies) {
-		sendErr := interaction.RespondWithText(s, i, "**Error:** No results found", true)
-		if sendErr != nil {
-			Log.Error("\nYTS:", sendErr.Error())
-			Log.Debug(Log.Level.Error, `sending a respond for "yts" command:`, sendErr.Error())
-		}
+		events.RespondExpired(s, i)
 		return
 	}
 
@@ -199,6 +202,9 @@ func ytsOnSelect(s *discordgo.Session, i *discordgo.InteractionCreate, data *dis
 		for _, torrent := range selectedMovie.Torrents {
 			label := fmt.Sprintf("%s.%s(%s)", torrent.Quality, torrent.Type, torrent.Size)
 			options = append(options, components.NewMenuOption().SetLabel(label).SetValue(torrent.URL))
+
+			// used to organise the downloaded files into the media library
+			torrentClient.SetMediaInfo(torrent.URL, torrentClient.MediaInfo{Title: selectedMovie.Title, Year: selectedMovie.Year})
 		}
 		return options
 	}
@@ -236,7 +242,7 @@ func ytsOnSelect(s *discordgo.Session, i *discordgo.InteractionCreate, data *dis
 			Embeds:  &[]*discordgo.MessageEmbed{createMovieEmbed(selectedMovie)},
 			Components: components.AddMessageComponents(
 				components.NewRow(
-					components.NewSelectMenu().SetStringType().SetCustomID("yts_torrents").
+					components.NewSelectMenu().SetStringType().SetCustomID(torrentCommand.YtsTorrentsRoute.CustomID(events.NoPayload{})).
 						SetPlaceholder("Select a torrent to download").
 						SetOptions(generateLinksOptions()...),
 				),
@@ -258,7 +264,7 @@ func ytsOnSelect(s *discordgo.Session, i *discordgo.InteractionCreate, data *dis
 			Embeds:  []*discordgo.MessageEmbed{createMovieEmbed(selectedMovie)},
 			Components: *components.AddMessageComponents(
 				components.NewRow(
-					components.NewSelectMenu().SetStringType().SetCustomID("yts_torrents").
+					components.NewSelectMenu().SetStringType().SetCustomID(torrentCommand.YtsTorrentsRoute.CustomID(events.NoPayload{})).
 						SetPlaceholder("Select a torrent to download").
 						SetOptions(generateLinksOptions()...),
 				),
@@ -290,6 +296,12 @@ func fetchYtsMovies(movieName string) (*common.YtsResponse, error) {
 
 	apiURL := fmt.Sprintf("https://yts.mx/api/v2/list_movies.json?query_term=%s", url.QueryEscape(movieName))
 
+	err := ratelimit.Scraping.Wait(events.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer ratelimit.Scraping.Release()
+
 	resp, err := http.Get(apiURL)
 	if err != nil {
 		return nil, err
@@ -330,7 +342,7 @@ func createSelectMenu(s *discordgo.Session, i *discordgo.InteractionCreate, ytsD
 			components.NewRow(
 				components.NewSelectMenu().
 					SetPlaceholder("Select a movie").
-					SetCustomID("yts").
+					SetCustomID(moviesRoute.CustomID(events.NoPayload{})).
 					SetStringType().
 					SetOptions(menuOptions...),
 			),
@@ -2,8 +2,13 @@ package firebase
 
 import (
 	"context"
+	"discord-bot/health"
+	"discord-bot/metrics"
 	"discord-bot/utils"
 	"os"
+	"slices"
+	"sync"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	firebase "firebase.google.com/go"
@@ -13,36 +18,61 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-var Log = utils.Log
+var Log = &utils.Log
 
 var client *firestore.Client
 var ctx = context.Background()
 
 var cache = make(map[string]*FirebaseData)
 
-func Initialize() *firestore.Client {
-	var err error
+var (
+	usersCache      = make(map[string]*UserData)
+	usersCacheMutex sync.Mutex
+)
 
-	app, err := firebase.NewApp(ctx, nil, option.WithCredentialsJSON([]byte(os.Getenv("SERVICE_ACCOUNT_KEY"))))
+// Start connects to Firestore
+func Start(startCtx context.Context) error {
+	app, err := firebase.NewApp(startCtx, nil, option.WithCredentialsJSON([]byte(os.Getenv("SERVICE_ACCOUNT_KEY"))))
 	if err != nil {
-		Log.Fatal("\nFirebase:", err.Error())
-		Log.Debug(Log.Level.Fatal, err.Error())
+		return err
 	}
 
 	client, err = app.Firestore(ctx)
 	if err != nil {
-		Log.Fatal("\nFirebase:", err.Error())
-		Log.Debug(Log.Level.Fatal, err.Error())
+		return err
 	}
 
-	return client
+	health.Register(health.Check{
+		Name:     "firestore",
+		CacheFor: 30 * time.Second, // every check is a billed read, once per probe interval is enough
+		Run: func(ctx context.Context) (string, error) {
+			// any answer from the server, even not found, means it's reachable
+			_, err := client.Collection("Guilds").Doc("healthcheck").Get(ctx)
+			if err != nil && status.Code(err) != codes.NotFound {
+				return "", err
+			}
+			return "", nil
+		},
+	})
+
+	return nil
+}
+
+// Stop closes the Firestore client
+func Stop(stopCtx context.Context) error {
+	if client == nil {
+		return nil
+	}
+	return client.Close()
 }
 
 func GetGuildData(guildId string) (*FirebaseData, error) {
 	// try cache first
 	if data, ok := cache[guildId]; ok {
+		metrics.GuildCacheHit()
 		return data, nil
 	}
+	metrics.GuildCacheMiss()
 
 	data := FirebaseData{}
 	data.SetDefaults() // set default values
@@ -56,6 +86,8 @@ func GetGuildData(guildId string) (*FirebaseData, error) {
 			"voiceMessages":  []interface{}{},
 			"customCommands": []interface{}{},
 			"savedList":      []interface{}{},
+			"reminders":      []interface{}{},
+			"schedules":      []interface{}{},
 			"prefix":         "!",
 		})
 
@@ -84,6 +116,44 @@ func GetGuildData(guildId string) (*FirebaseData, error) {
 	return &data, nil
 }
 
+func GetUserData(userId string) (*UserData, error) {
+	// held until the data is cached, so the same user is not fetched twice into different structs
+	usersCacheMutex.Lock()
+	defer usersCacheMutex.Unlock()
+
+	// try cache first
+	if data, ok := usersCache[userId]; ok {
+		return data, nil
+	}
+
+	data := UserData{SavedList: []SavedItem{}}
+
+	dsnap, err := client.Collection("Users").Doc(userId).Get(ctx)
+
+	// the user has no data yet, it's created on the first update
+	if status.Code(err) == codes.NotFound {
+		usersCache[userId] = &data
+		return &data, nil
+	}
+
+	if err != nil {
+		return &data, err
+	}
+
+	if savedList, ok := dsnap.Data()["savedList"].([]interface{}); ok {
+		data.SavedList = SavedListFromMap(savedList)
+	}
+
+	if timezone, ok := dsnap.Data()["timezone"].(string); ok {
+		data.Timezone = timezone
+	}
+
+	// add to cash
+	usersCache[userId] = &data
+
+	return &data, nil
+}
+
 func GetBotActivity() (BotActivity, error) {
 	data := BotActivity{
 		Activity:     "/",
@@ -122,7 +192,7 @@ func SetCustomCommand(guildId string, newCommands *[]map[string]interface{}) err
 	return err
 }
 
-func SetSavedList(guildId string, newSavedList []string) error {
+func SetSavedList(guildId string, newSavedList *[]map[string]interface{}) error {
 	_, err := client.Collection("Guilds").Doc(guildId).Update(ctx, []firestore.Update{
 		{
 			Path:  "savedList",
@@ -132,6 +202,58 @@ func SetSavedList(guildId string, newSavedList []string) error {
 	return err
 }
 
+func SetUserSavedList(userId string, newSavedList *[]map[string]interface{}) error {
+	_, err := client.Collection("Users").Doc(userId).
+		Set(ctx,
+			map[string]interface{}{"savedList": newSavedList},
+			firestore.MergeAll,
+		)
+
+	return err
+}
+
+func SetUserTimezone(userId string, timezone string) error {
+	currentData, err := GetUserData(userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Collection("Users").Doc(userId).
+		Set(ctx,
+			map[string]interface{}{"timezone": timezone},
+			firestore.MergeAll,
+		)
+
+	if err != nil {
+		return err
+	}
+
+	// update cache
+	currentData.Timezone = timezone
+
+	return nil
+}
+
+func SetReminders(guildId string, newReminders *[]map[string]interface{}) error {
+	_, err := client.Collection("Guilds").Doc(guildId).
+		Set(ctx,
+			map[string]interface{}{"reminders": newReminders},
+			firestore.MergeAll,
+		)
+
+	return err
+}
+
+func SetSchedules(guildId string, newSchedules *[]map[string]interface{}) error {
+	_, err := client.Collection("Guilds").Doc(guildId).
+		Set(ctx,
+			map[string]interface{}{"schedules": newSchedules},
+			firestore.MergeAll,
+		)
+
+	return err
+}
+
 func SetVoiceMessages(guildId string, newVoiceMessages *[]map[string]interface{}) error {
 	_, err := client.Collection("Guilds").Doc(guildId).
 		Set(ctx,
@@ -167,6 +289,133 @@ func SetCommandPrefix(guildId string, newPrefix string) error {
 	return nil
 }
 
+// SetCommandCooldown overrides the cooldown of a command in a guild, nil removes the override
+func SetCommandCooldown(guildId string, command string, seconds *int) error {
+	// get current data
+	currentData, err := GetGuildData(guildId)
+	if err != nil {
+		return err
+	}
+
+	var value interface{} = firestore.Delete
+	if seconds != nil {
+		value = *seconds
+	}
+
+	// the command names can have dashes, so the path is not parsed
+	_, err = client.Collection("Guilds").Doc(guildId).Update(ctx, []firestore.Update{
+		{
+			FieldPath: firestore.FieldPath{"cooldowns", command},
+			Value:     value,
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// update cache
+	if seconds == nil {
+		delete(currentData.Cooldowns, command)
+	} else {
+		currentData.Cooldowns[command] = *seconds
+	}
+
+	return nil
+}
+
+var (
+	// the audit log of each guild is read, appended to and written back, so the entries of a guild are added
+	// one at a time, the key is the guild ID
+	auditLocks      = map[string]*sync.Mutex{}
+	auditLocksMutex sync.Mutex
+)
+
+// lockAuditLog waits until the audit log of a guild can be read or changed, the returned function must be called
+// when it's done
+func lockAuditLog(guildId string) func() {
+	auditLocksMutex.Lock()
+	lock, ok := auditLocks[guildId]
+	if !ok {
+		lock = &sync.Mutex{}
+		auditLocks[guildId] = lock
+	}
+	auditLocksMutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// GetAuditLog returns a copy of the audit log of a guild, the oldest entry first
+func GetAuditLog(guildId string) ([]AuditEntry, error) {
+	unlock := lockAuditLog(guildId)
+	defer unlock()
+
+	currentData, err := GetGuildData(guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Clone(currentData.AuditLog), nil
+}
+
+// AddAuditEntry adds an entry to the audit log of a guild, only the last MaxAuditEntries entries are kept
+func AddAuditEntry(guildId string, entry AuditEntry) error {
+	unlock := lockAuditLog(guildId)
+	defer unlock()
+
+	// get current data
+	currentData, err := GetGuildData(guildId)
+	if err != nil {
+		return err
+	}
+
+	auditLog := append(slices.Clone(currentData.AuditLog), entry)
+	if len(auditLog) > MaxAuditEntries {
+		auditLog = auditLog[len(auditLog)-MaxAuditEntries:]
+	}
+
+	_, err = client.Collection("Guilds").Doc(guildId).
+		Set(ctx,
+			map[string]interface{}{"auditLog": AuditLogToMap(auditLog)},
+			firestore.MergeAll,
+		)
+
+	if err != nil {
+		return err
+	}
+
+	// update cache
+	currentData.AuditLog = auditLog
+
+	return nil
+}
+
+// SetModLogChannel sets the channel the audit log is mirrored to, an empty channelID disables it
+func SetModLogChannel(guildId string, channelID string) error {
+	// get current data
+	currentData, err := GetGuildData(guildId)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Collection("Guilds").Doc(guildId).Update(ctx, []firestore.Update{
+		{
+			Path:  "modLogChannel",
+			Value: channelID,
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// update cache
+	currentData.ModLogChannel = channelID
+
+	return nil
+}
+
 func SetBotActivity(newActivity BotActivity) error {
 	_, err := client.Collection("Shared").Doc("bot").Set(ctx, map[string]interface{}{
 		"botActivity": map[string]interface{}{
@@ -13,20 +13,83 @@ type (
 		Lang    string
 	}
 	CustomCommand struct {
-		When string
-		Say  string
+		When          string
+		Say           string
+		Counter       int      // how many times the command was used, only counted when "Say" uses `{count}`
+		Match         string   // how "When" is matched, defaults to "exact"
+		Cooldown      int      // seconds between two uses of the command
+		AllowChannels []string // only run in these channels, empty for all channels
+		DenyChannels  []string // never run in these channels
+		Type          string   // "text", "embed", "reaction", "file" or "tts", defaults to "text"
+		Title         string   // the embed title for "embed"
+		File          string   // the stored file path for "file", relative to the files directory
+		Lang          string   // the language for "tts"
+	}
+	SavedItem struct {
+		ID          string // the ID of the saved message
+		Content     string
+		AuthorID    string
+		AuthorName  string
+		Link        string   // the link to the saved message
+		Attachments []string // the URLs of the attachments
+		Embeds      []string // the text of the embeds
+		SavedBy     string   // the ID of the user who saved it
+		SavedAt     int64    // unix timestamp
+		Tags        []string
+	}
+	Reminder struct {
+		ID        string
+		UserID    string
+		ChannelID string
+		Message   string
+		At        int64  // unix timestamp
+		TTS       bool   // also speak it in the voice channel of the user
+		Lang      string // the TTS language
+	}
+	ScheduledMessage struct {
+		ID             string
+		CreatedBy      string
+		ChannelID      string
+		VoiceChannelID string // speak the message in this voice channel instead of sending it
+		Message        string
+		Cron           string
+		Timezone       string // the timezone of the cron expression
+		Lang           string // the TTS language
 	}
 	BotActivity struct {
 		Activity     string
 		ActivityType discordgo.ActivityType
 	}
+	AuditEntry struct {
+		ID     string
+		UserID string // who made the change
+		Action string // what was done, like "prefix.set"
+		Target string // what was changed, like the trigger of a custom command, empty when there's only one
+		Before string
+		After  string
+		At     int64 // unix timestamp
+	}
 )
 
+// the max number of audit log entries kept per guild, the oldest are dropped
+const MaxAuditEntries = 200
+
 type FirebaseData struct {
 	VoiceMessages  []VoiceWelcomeMessage
 	CustomCommands []CustomCommand
-	SavedList      []string
+	SavedList      []SavedItem
+	Reminders      []Reminder
+	Schedules      []ScheduledMessage
 	Prefix         string
+	Cooldowns      map[string]int // seconds by command name, overrides the default cooldown of the command
+	AuditLog       []AuditEntry   // the oldest first
+	ModLogChannel  string         // the channel the audit log is mirrored to, empty for none
+}
+
+// UserData is the data of a user shared between all guilds
+type UserData struct {
+	SavedList []SavedItem // the personal saved list
+	Timezone  string      // used by the reminders, empty for UTC
 }
 
 // * MARK: Voice Messages
@@ -89,11 +152,14 @@ func (data *FirebaseData) VoiceMessagesFromMap(mapArr []interface{}) []VoiceWelc
 	voiceMessagesArr := make([]VoiceWelcomeMessage, len(mapArr))
 
 	for i, v := range mapArr {
-		voiceMessagesArr[i] = VoiceWelcomeMessage{
-			Id:      v.(map[string]interface{})["id"].(string),
-			Message: v.(map[string]interface{})["message"].(string),
-			Lang:    v.(map[string]interface{})["lang"].(string),
+		itemMap, ok := v.(map[string]interface{})
+		if !ok {
+			continue
 		}
+
+		voiceMessagesArr[i].Id, _ = itemMap["id"].(string)
+		voiceMessagesArr[i].Message, _ = itemMap["message"].(string)
+		voiceMessagesArr[i].Lang, _ = itemMap["lang"].(string)
 	}
 
 	return voiceMessagesArr
@@ -136,8 +202,26 @@ func (data *FirebaseData) CustomCommandsUpdateItem(item CustomCommand) {
 	for i, v := range data.CustomCommands {
 		if v.When == item.When {
 			data.CustomCommands[i].Say = item.Say
+			data.CustomCommands[i].Match = item.Match
+			data.CustomCommands[i].Cooldown = item.Cooldown
+			data.CustomCommands[i].AllowChannels = item.AllowChannels
+			data.CustomCommands[i].DenyChannels = item.DenyChannels
+			data.CustomCommands[i].Type = item.Type
+			data.CustomCommands[i].Title = item.Title
+			data.CustomCommands[i].File = item.File
+			data.CustomCommands[i].Lang = item.Lang
+		}
+	}
+}
+
+func (data *FirebaseData) CustomCommandsIncrementCounter(trigger string) int {
+	for i, v := range data.CustomCommands {
+		if v.When == trigger {
+			data.CustomCommands[i].Counter++
+			return data.CustomCommands[i].Counter
 		}
 	}
+	return 0
 }
 
 func (data *FirebaseData) CustomCommandsToMap() []map[string]interface{} {
@@ -145,8 +229,17 @@ func (data *FirebaseData) CustomCommandsToMap() []map[string]interface{} {
 
 	for i, v := range data.CustomCommands {
 		customCommandsMap[i] = map[string]interface{}{
-			"when": v.When,
-			"say":  v.Say,
+			"when":          v.When,
+			"say":           v.Say,
+			"counter":       v.Counter,
+			"match":         v.Match,
+			"cooldown":      v.Cooldown,
+			"allowChannels": v.AllowChannels,
+			"denyChannels":  v.DenyChannels,
+			"type":          v.Type,
+			"title":         v.Title,
+			"file":          v.File,
+			"lang":          v.Lang,
 		}
 	}
 
@@ -157,71 +250,325 @@ func (data *FirebaseData) CustomCommandsFromMap(mapArr []interface{}) []CustomCo
 	customCommandsArr := make([]CustomCommand, len(mapArr))
 
 	for i, v := range mapArr {
-		customCommandsArr[i] = CustomCommand{
-			When: v.(map[string]interface{})["when"].(string),
-			Say:  v.(map[string]interface{})["say"].(string),
+		itemMap, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		customCommandsArr[i].When, _ = itemMap["when"].(string)
+		customCommandsArr[i].Say, _ = itemMap["say"].(string)
+
+		// older commands don't have a counter
+		if counter, ok := itemMap["counter"].(int64); ok {
+			customCommandsArr[i].Counter = int(counter)
+		}
+		if match, ok := itemMap["match"].(string); ok {
+			customCommandsArr[i].Match = match
+		}
+		if cooldown, ok := itemMap["cooldown"].(int64); ok {
+			customCommandsArr[i].Cooldown = int(cooldown)
+		}
+		if responseType, ok := itemMap["type"].(string); ok {
+			customCommandsArr[i].Type = responseType
 		}
+		if title, ok := itemMap["title"].(string); ok {
+			customCommandsArr[i].Title = title
+		}
+		if file, ok := itemMap["file"].(string); ok {
+			customCommandsArr[i].File = file
+		}
+		if lang, ok := itemMap["lang"].(string); ok {
+			customCommandsArr[i].Lang = lang
+		}
+		customCommandsArr[i].AllowChannels = stringsFromMap(itemMap["allowChannels"])
+		customCommandsArr[i].DenyChannels = stringsFromMap(itemMap["denyChannels"])
 	}
 
 	return customCommandsArr
 }
 
-// * MARK: Saved List
+// stringsFromMap converts a firestore array to a string slice, nil if it's missing
+func stringsFromMap(value interface{}) []string {
+	arr, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
 
-func (data *FirebaseData) SavedListIsInList(item string) bool {
-	for _, v := range data.SavedList {
-		if v == item {
-			return true
+	results := make([]string, 0, len(arr))
+	for _, v := range arr {
+		if str, ok := v.(string); ok {
+			results = append(results, str)
 		}
 	}
-	return false
+	return results
 }
 
-func (data *FirebaseData) SavedListAddItem(item string) {
-	data.SavedList = append(data.SavedList, item)
+// * MARK: Saved List
+
+// SavedListIndexOf returns the index of a saved message in a list, or -1 if it's not in the list
+func SavedListIndexOf(list []SavedItem, item SavedItem) int {
+	for i, v := range list {
+		// older items only have the content
+		if (v.ID != "" && v.ID == item.ID) || (v.ID == "" && v.Content == item.Content) {
+			return i
+		}
+	}
+	return -1
 }
 
-func (data *FirebaseData) SavedListRemoveItem(item string) {
-	for i, v := range data.SavedList {
-		if v == item {
-			data.SavedList = append(data.SavedList[:i], data.SavedList[i+1:]...)
+func SavedListToMap(list []SavedItem) []map[string]interface{} {
+	savedListMap := make([]map[string]interface{}, len(list))
+
+	for i, v := range list {
+		savedListMap[i] = map[string]interface{}{
+			"id":          v.ID,
+			"content":     v.Content,
+			"authorId":    v.AuthorID,
+			"authorName":  v.AuthorName,
+			"link":        v.Link,
+			"attachments": v.Attachments,
+			"embeds":      v.Embeds,
+			"savedBy":     v.SavedBy,
+			"savedAt":     v.SavedAt,
+			"tags":        v.Tags,
 		}
 	}
+
+	return savedListMap
 }
 
-func (data *FirebaseData) SavedListFromMap(mapArr []interface{}) []string {
-	savedListArr := make([]string, len(mapArr))
+func SavedListFromMap(mapArr []interface{}) []SavedItem {
+	savedListArr := make([]SavedItem, len(mapArr))
 
 	for i, v := range mapArr {
-		savedListArr[i] = v.(string)
+		// older items are saved as plain strings
+		if content, ok := v.(string); ok {
+			savedListArr[i] = SavedItem{Content: content}
+			continue
+		}
+
+		itemMap, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		savedListArr[i].ID, _ = itemMap["id"].(string)
+		savedListArr[i].Content, _ = itemMap["content"].(string)
+		savedListArr[i].AuthorID, _ = itemMap["authorId"].(string)
+		savedListArr[i].AuthorName, _ = itemMap["authorName"].(string)
+		savedListArr[i].Link, _ = itemMap["link"].(string)
+		savedListArr[i].SavedBy, _ = itemMap["savedBy"].(string)
+		savedListArr[i].SavedAt, _ = itemMap["savedAt"].(int64)
+		savedListArr[i].Attachments = stringsFromMap(itemMap["attachments"])
+		savedListArr[i].Embeds = stringsFromMap(itemMap["embeds"])
+		savedListArr[i].Tags = stringsFromMap(itemMap["tags"])
 	}
 
 	return savedListArr
 }
 
+// * MARK: Reminders
+
+func (data *FirebaseData) RemindersRemoveItem(id string) {
+	for i, v := range data.Reminders {
+		if v.ID == id {
+			data.Reminders = append(data.Reminders[:i:i], data.Reminders[i+1:]...)
+			return
+		}
+	}
+}
+
+func (data *FirebaseData) RemindersToMap() []map[string]interface{} {
+	remindersMap := make([]map[string]interface{}, len(data.Reminders))
+
+	for i, v := range data.Reminders {
+		remindersMap[i] = map[string]interface{}{
+			"id":        v.ID,
+			"userId":    v.UserID,
+			"channelId": v.ChannelID,
+			"message":   v.Message,
+			"at":        v.At,
+			"tts":       v.TTS,
+			"lang":      v.Lang,
+		}
+	}
+
+	return remindersMap
+}
+
+func (data *FirebaseData) RemindersFromMap(mapArr []interface{}) []Reminder {
+	remindersArr := make([]Reminder, len(mapArr))
+
+	for i, v := range mapArr {
+		itemMap, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		remindersArr[i].ID, _ = itemMap["id"].(string)
+		remindersArr[i].UserID, _ = itemMap["userId"].(string)
+		remindersArr[i].ChannelID, _ = itemMap["channelId"].(string)
+		remindersArr[i].Message, _ = itemMap["message"].(string)
+		remindersArr[i].At, _ = itemMap["at"].(int64)
+		remindersArr[i].TTS, _ = itemMap["tts"].(bool)
+		remindersArr[i].Lang, _ = itemMap["lang"].(string)
+	}
+
+	return remindersArr
+}
+
+// * MARK: Schedules
+
+func (data *FirebaseData) SchedulesRemoveItem(id string) {
+	for i, v := range data.Schedules {
+		if v.ID == id {
+			data.Schedules = append(data.Schedules[:i:i], data.Schedules[i+1:]...)
+			return
+		}
+	}
+}
+
+func (data *FirebaseData) SchedulesToMap() []map[string]interface{} {
+	schedulesMap := make([]map[string]interface{}, len(data.Schedules))
+
+	for i, v := range data.Schedules {
+		schedulesMap[i] = map[string]interface{}{
+			"id":             v.ID,
+			"createdBy":      v.CreatedBy,
+			"channelId":      v.ChannelID,
+			"voiceChannelId": v.VoiceChannelID,
+			"message":        v.Message,
+			"cron":           v.Cron,
+			"timezone":       v.Timezone,
+			"lang":           v.Lang,
+		}
+	}
+
+	return schedulesMap
+}
+
+func (data *FirebaseData) SchedulesFromMap(mapArr []interface{}) []ScheduledMessage {
+	schedulesArr := make([]ScheduledMessage, len(mapArr))
+
+	for i, v := range mapArr {
+		itemMap, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		schedulesArr[i].ID, _ = itemMap["id"].(string)
+		schedulesArr[i].CreatedBy, _ = itemMap["createdBy"].(string)
+		schedulesArr[i].ChannelID, _ = itemMap["channelId"].(string)
+		schedulesArr[i].VoiceChannelID, _ = itemMap["voiceChannelId"].(string)
+		schedulesArr[i].Message, _ = itemMap["message"].(string)
+		schedulesArr[i].Cron, _ = itemMap["cron"].(string)
+		schedulesArr[i].Timezone, _ = itemMap["timezone"].(string)
+		schedulesArr[i].Lang, _ = itemMap["lang"].(string)
+	}
+
+	return schedulesArr
+}
+
+// * MARK: Cooldowns
+
+func CooldownsFromMap(mapData map[string]interface{}) map[string]int {
+	cooldowns := make(map[string]int, len(mapData))
+	for command, v := range mapData {
+		if seconds, ok := v.(int64); ok {
+			cooldowns[command] = int(seconds)
+		}
+	}
+	return cooldowns
+}
+
+// * MARK: Audit Log
+
+func AuditLogToMap(list []AuditEntry) []map[string]interface{} {
+	auditMap := make([]map[string]interface{}, len(list))
+
+	for i, v := range list {
+		auditMap[i] = map[string]interface{}{
+			"id":     v.ID,
+			"userId": v.UserID,
+			"action": v.Action,
+			"target": v.Target,
+			"before": v.Before,
+			"after":  v.After,
+			"at":     v.At,
+		}
+	}
+
+	return auditMap
+}
+
+func AuditLogFromMap(mapArr []interface{}) []AuditEntry {
+	auditArr := make([]AuditEntry, len(mapArr))
+
+	for i, v := range mapArr {
+		itemMap, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		auditArr[i].ID, _ = itemMap["id"].(string)
+		auditArr[i].UserID, _ = itemMap["userId"].(string)
+		auditArr[i].Action, _ = itemMap["action"].(string)
+		auditArr[i].Target, _ = itemMap["target"].(string)
+		auditArr[i].Before, _ = itemMap["before"].(string)
+		auditArr[i].After, _ = itemMap["after"].(string)
+		auditArr[i].At, _ = itemMap["at"].(int64)
+	}
+
+	return auditArr
+}
+
 // * MARK: Data
 
 func (data *FirebaseData) SetDefaults() {
 	data.VoiceMessages = []VoiceWelcomeMessage{}
 	data.CustomCommands = []CustomCommand{}
-	data.SavedList = []string{}
+	data.SavedList = []SavedItem{}
+	data.Reminders = []Reminder{}
+	data.Schedules = []ScheduledMessage{}
 	data.Prefix = "!"
+	data.Cooldowns = map[string]int{}
+	data.AuditLog = []AuditEntry{}
 }
 
 func (data *FirebaseData) CreateFromMap(mapData map[string]interface{}) {
-	if voiceMessages, ok := mapData["voiceMessages"]; ok {
-		data.VoiceMessages = data.VoiceMessagesFromMap(voiceMessages.([]interface{}))
+	if voiceMessages, ok := mapData["voiceMessages"].([]interface{}); ok {
+		data.VoiceMessages = data.VoiceMessagesFromMap(voiceMessages)
+	}
+
+	if customCommands, ok := mapData["customCommands"].([]interface{}); ok {
+		data.CustomCommands = data.CustomCommandsFromMap(customCommands)
+	}
+
+	if savedList, ok := mapData["savedList"].([]interface{}); ok {
+		data.SavedList = SavedListFromMap(savedList)
+	}
+
+	if reminders, ok := mapData["reminders"].([]interface{}); ok {
+		data.Reminders = data.RemindersFromMap(reminders)
+	}
+
+	if schedules, ok := mapData["schedules"].([]interface{}); ok {
+		data.Schedules = data.SchedulesFromMap(schedules)
+	}
+
+	if cooldowns, ok := mapData["cooldowns"].(map[string]interface{}); ok {
+		data.Cooldowns = CooldownsFromMap(cooldowns)
 	}
 
-	if customCommands, ok := mapData["customCommands"]; ok {
-		data.CustomCommands = data.CustomCommandsFromMap(customCommands.([]interface{}))
+	if auditLog, ok := mapData["auditLog"].([]interface{}); ok {
+		data.AuditLog = AuditLogFromMap(auditLog)
 	}
 
-	if savedList, ok := mapData["savedList"]; ok {
-		data.SavedList = data.SavedListFromMap(savedList.([]interface{}))
+	if modLogChannel, ok := mapData["modLogChannel"].(string); ok {
+		data.ModLogChannel = modLogChannel
 	}
 
-	if prefix, ok := mapData["prefix"]; ok {
-		data.Prefix = prefix.(string)
+	if prefix, ok := mapData["prefix"].(string); ok {
+		data.Prefix = prefix
 	}
 }
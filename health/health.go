@@ -0,0 +1,174 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// the max time of a single check
+const checkTimeout = 5 * time.Second
+
+// Check reports the state of a subsystem, the returned string is an optional detail like "12 GB free"
+type Check struct {
+	Name string
+	// the check also runs on "/healthz", only for process-local checks, a remote service that is down must not
+	// restart the bot
+	Critical bool
+	CacheFor time.Duration // reuse the result for this long, for the checks that cost something like a billed read
+	Run      func(ctx context.Context) (string, error)
+}
+
+type cachedResult struct {
+	result    checkResult
+	checkedAt time.Time
+}
+
+type checkResult struct {
+	Status   string `json:"status"` // "ok" or "failing"
+	Critical bool   `json:"critical"`
+	Detail   string `json:"detail,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type report struct {
+	Status string                 `json:"status"` // "ok" or "failing"
+	Ready  bool                   `json:"ready"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+var (
+	mutex  sync.RWMutex
+	checks []Check
+	ready  atomic.Bool
+
+	// the last results of the checks with CacheFor, the key is the check name
+	cache      = map[string]cachedResult{}
+	cacheMutex sync.Mutex
+)
+
+// Register adds a check, the subsystems register their checks when they are initialized
+func Register(check Check) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	checks = append(checks, check)
+}
+
+// SetReady marks the startup as done, or the shutdown as started
+func SetReady(value bool) {
+	ready.Store(value)
+}
+
+// runChecks runs the checks at the same time, all of them or only the critical ones
+func runChecks(ctx context.Context, onlyCritical bool) map[string]checkResult {
+	mutex.RLock()
+	current := []Check{}
+	for _, check := range checks {
+		if check.Critical || !onlyCritical {
+			current = append(current, check)
+		}
+	}
+	mutex.RUnlock()
+
+	results := make(map[string]checkResult, len(current))
+	var resultsMutex sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range current {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			result := runCheck(ctx, check)
+
+			resultsMutex.Lock()
+			results[check.Name] = result
+			resultsMutex.Unlock()
+		}(check)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// runCheck runs a check, or returns its cached result if it's recent enough
+func runCheck(ctx context.Context, check Check) checkResult {
+	if check.CacheFor > 0 {
+		cacheMutex.Lock()
+		cached, ok := cache[check.Name]
+		cacheMutex.Unlock()
+
+		if ok && time.Since(cached.checkedAt) < check.CacheFor {
+			return cached.result
+		}
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	result := checkResult{Status: "ok", Critical: check.Critical}
+
+	detail, err := check.Run(checkCtx)
+	result.Detail = detail
+	if err != nil {
+		result.Status = "failing"
+		result.Error = err.Error()
+	}
+
+	if check.CacheFor > 0 {
+		cacheMutex.Lock()
+		cache[check.Name] = cachedResult{result: result, checkedAt: time.Now()}
+		cacheMutex.Unlock()
+	}
+
+	return result
+}
+
+func writeReport(w http.ResponseWriter, rep report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if rep.Status == "ok" {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(rep)
+}
+
+// LivenessHandler serves "/healthz", it only runs the critical checks and fails when one of them fails
+func LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	rep := report{Status: "ok", Ready: ready.Load(), Checks: runChecks(r.Context(), true)}
+
+	for _, result := range rep.Checks {
+		if result.Critical && result.Status != "ok" {
+			rep.Status = "failing"
+		}
+	}
+
+	writeReport(w, rep)
+}
+
+// ReadinessHandler serves "/readyz", it fails until the startup is done and when any check fails
+func ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	rep := report{Status: "ok", Ready: ready.Load(), Checks: runChecks(r.Context(), false)}
+
+	if !rep.Ready {
+		rep.Status = "failing"
+	}
+
+	for _, result := range rep.Checks {
+		if result.Status != "ok" {
+			rep.Status = "failing"
+		}
+	}
+
+	writeReport(w, rep)
+}
@@ -2,26 +2,25 @@ package httpServer
 
 import (
 	"context"
+	"discord-bot/health"
+	"discord-bot/lifecycle"
+	"discord-bot/metrics"
 	"discord-bot/utils"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
-	"sync"
-	"time"
 )
 
-var Log = utils.Log
+var Log = &utils.Log
 
-var (
-	server   *http.Server
-	wg       sync.WaitGroup
-	shutdown = make(chan struct{})
-)
+var server *http.Server
 
-// Initialize initializes and starts the HTTP server in a non-blocking way.
-func Initialize() {
+// Start listens on the configured address and serves in the background, errors while serving are reported
+// to the lifecycle manager
+func Start(ctx context.Context) error {
 	config := utils.GetAppConfig()
 
 	mux := http.NewServeMux()
@@ -29,37 +28,39 @@ func Initialize() {
 	// Define your handlers
 	mux.HandleFunc("GET "+config.Http.Routes.Video+"{fileName}", videoHandler)
 
+	mux.Handle("GET "+config.Http.Routes.Metrics, metrics.Handler())
+
+	mux.HandleFunc("GET /healthz", health.LivenessHandler)
+	mux.HandleFunc("GET /readyz", health.ReadinessHandler)
+
 	// Create the server
 	server = &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", config.Http.Host, config.Http.Port),
 		Handler: mux,
 	}
 
-	// Start the server in a goroutine so that it doesn't block
-	wg.Add(1)
+	// listen first, so that errors like a used port are returned
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
 
 	go func() {
-		defer wg.Done()
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			Log.Fatal("\nError starting the http server:", err.Error())
-			Log.Debug(Log.Level.Fatal, err.Error())
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			lifecycle.Fail(ctx, fmt.Errorf("http server: %w", err))
 		}
 	}()
-}
 
-// Close shuts down the HTTP server gracefully without shutting down the whole program.
-func Close() {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return nil
+}
 
-	if server != nil {
-		if err := server.Shutdown(ctx); err != nil {
-			Log.Error("\nServer Shutdown Failed:", err.Error())
-			Log.Debug(Log.Level.Error, err.Error())
-			return
-		}
-		close(shutdown)
+// Stop shuts down the HTTP server gracefully, it waits for the open requests until the deadline of ctx
+func Stop(ctx context.Context) error {
+	if server == nil {
+		return nil
 	}
+	return server.Shutdown(ctx)
 }
 
 func videoHandler(w http.ResponseWriter, r *http.Request) {
@@ -95,5 +96,16 @@ func videoHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileInfo.Name()))
-	http.ServeContent(w, r, fileInfo.Name(), fileInfo.ModTime(), file)
+	http.ServeContent(&countingWriter{w}, r, fileInfo.Name(), fileInfo.ModTime(), file)
+}
+
+// countingWriter adds the bytes written to the served video bytes metric
+type countingWriter struct {
+	http.ResponseWriter
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	metrics.AddVideoBytesServed(n)
+	return n, err
 }
@@ -0,0 +1,222 @@
+package lifecycle
+
+import (
+	"context"
+	"discord-bot/utils"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var Log = &utils.Log
+
+// Service is a subsystem of the bot like the HTTP server or the Discord session
+type Service struct {
+	Name      string
+	DependsOn []string // the names of the services that must be started first and stopped last
+
+	// Start returns when the service is ready, ctx is canceled when the manager is stopped so the background work
+	// of the service should stop with it, errors after Start returned are reported with Fail
+	Start func(ctx context.Context) error
+
+	// Stop releases the service, ctx has the shutdown deadline
+	Stop func(ctx context.Context) error
+}
+
+// Manager starts the services in the order of their dependencies and stops them in the reverse order
+type Manager struct {
+	mutex       sync.Mutex
+	services    []Service
+	started     []Service
+	ctx         context.Context
+	cancel      context.CancelFunc
+	errors      chan error
+	stopTimeout time.Duration // the deadline of stopping the started services when the startup fails
+}
+
+type managerKey struct{}
+
+func New(stopTimeout time.Duration) *Manager {
+	return &Manager{errors: make(chan error, 1), stopTimeout: stopTimeout}
+}
+
+func (m *Manager) Add(services ...Service) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.services = append(m.services, services...)
+}
+
+// Errors receives the errors of the running services, the first one should stop the bot
+func (m *Manager) Errors() <-chan error {
+	return m.errors
+}
+
+// Fail reports an error of a running service, ctx is the one passed to its Start
+func Fail(ctx context.Context, err error) {
+	manager, ok := ctx.Value(managerKey{}).(*Manager)
+	if !ok {
+		Log.Error("\nLifecycle:", err.Error())
+		Log.Debug(Log.Level.Error, "Lifecycle:", err.Error())
+		return
+	}
+
+	select {
+	case manager.errors <- err:
+	default: // an error is already waiting
+		Log.Error("\nLifecycle:", err.Error())
+		Log.Debug(Log.Level.Error, "Lifecycle:", err.Error())
+	}
+}
+
+// order sorts the services so that every service comes after its dependencies, keeping the order they were added in
+func order(services []Service) ([]Service, error) {
+	byName := make(map[string]Service, len(services))
+	for _, service := range services {
+		if _, ok := byName[service.Name]; ok {
+			return nil, fmt.Errorf("service \"%s\" is added twice", service.Name)
+		}
+		byName[service.Name] = service
+	}
+
+	sorted := make([]Service, 0, len(services))
+	state := map[string]int{} // 1 visiting, 2 done
+
+	var visit func(service Service) error
+	visit = func(service Service) error {
+		switch state[service.Name] {
+		case 1:
+			return fmt.Errorf("service \"%s\" is in a dependency cycle", service.Name)
+		case 2:
+			return nil
+		}
+
+		state[service.Name] = 1
+		for _, name := range service.DependsOn {
+			dependency, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("service \"%s\" depends on an unknown service \"%s\"", service.Name, name)
+			}
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		state[service.Name] = 2
+
+		sorted = append(sorted, service)
+		return nil
+	}
+
+	for _, service := range services {
+		if err := visit(service); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// Start starts the services, if one fails the started ones are stopped and its error is returned. Canceling ctx
+// cancels the startup, the services keep running after Start returns until Stop is called.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	sorted, err := order(m.services)
+	if err != nil {
+		return err
+	}
+
+	// the context of the running services, canceled by Stop, or by ctx until the startup is done so a blocking
+	// start is canceled too
+	m.ctx, m.cancel = context.WithCancel(context.WithValue(context.Background(), managerKey{}, m))
+	stopCancelingOnCtx := context.AfterFunc(ctx, m.cancel)
+	defer stopCancelingOnCtx()
+
+	for _, service := range sorted {
+		if err := ctx.Err(); err != nil {
+			m.stopAfterFailedStart()
+			return err
+		}
+
+		Log.Info("\nStarting " + service.Name + "...")
+		Log.Debug(Log.Level.Info, "Starting", service.Name+"...")
+
+		err := service.Start(m.ctx)
+		if err != nil {
+			m.stopAfterFailedStart()
+			return fmt.Errorf("starting %s: %w", service.Name, err)
+		}
+
+		m.started = append(m.started, service)
+	}
+
+	// the startup is done, ctx doesn't cancel the services anymore, unless it was canceled while the last one was starting
+	if !stopCancelingOnCtx() {
+		m.stopAfterFailedStart()
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// stopAfterFailedStart stops the started services with the stop timeout, so a service that hangs doesn't block the exit
+func (m *Manager) stopAfterFailedStart() {
+	stopCtx, cancel := context.WithTimeout(context.Background(), m.stopTimeout)
+	defer cancel()
+
+	m.stopStarted(stopCtx)
+}
+
+// Stop cancels the context of the services and stops them in the reverse order, a service that doesn't stop
+// before the deadline of ctx is skipped
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.stopStarted(ctx)
+}
+
+func (m *Manager) stopStarted(ctx context.Context) error {
+	if m.cancel != nil {
+		m.cancel()
+	}
+
+	var errs []error
+	for i := len(m.started) - 1; i >= 0; i-- {
+		service := m.started[i]
+
+		Log.Info("\nStopping " + service.Name + "...")
+		Log.Debug(Log.Level.Info, "Stopping", service.Name+"...")
+
+		err := stopWithDeadline(ctx, service)
+		if err != nil {
+			Log.Error("\nStopping "+service.Name+":", err.Error())
+			Log.Debug(Log.Level.Error, "Stopping", service.Name+":", err.Error())
+			errs = append(errs, fmt.Errorf("stopping %s: %w", service.Name, err))
+		}
+	}
+
+	m.started = nil
+	return errors.Join(errs...)
+}
+
+// stopWithDeadline stops a service, it returns when the deadline of ctx is exceeded even if the service is still stopping
+func stopWithDeadline(ctx context.Context, service Service) error {
+	if service.Stop == nil {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- service.Stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,23 +1,32 @@
 package main
 
 import (
+	"context"
 	"discord-bot/discord"
 	"discord-bot/firebase"
+	"discord-bot/health"
 	"discord-bot/httpServer"
+	"discord-bot/lifecycle"
+	"discord-bot/ratelimit"
 	"discord-bot/torrentClient"
 	"discord-bot/utils"
 	"flag"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 var Log = &utils.Log
 
+// the max time the subsystems have to stop
+const shutdownTimeout = 15 * time.Second
+
 func main() {
-	configPath := flag.String("config", ".config.json", "The path to the config file")
+	configPath := flag.String("config", ".config.json", "The path to the config file, json, yaml or toml")
 	envPath := flag.String("env", ".env", "the Path to the .env file")
 	generateConfigTemplateCmd := flag.Bool("config-template", false, "Generate config json template")
 	generateEnvFileTemplate := flag.Bool("env-template", false, "Generate .env file template")
@@ -51,8 +60,12 @@ func main() {
 	}
 
 	// set log settings
-	Log.SetLogToFile(config.Log.Enabled)
-	Log.SetLogFilePath(config.Log.Path)
+	err = Log.Configure(config.Log)
+	if err != nil {
+		Log.Fatal("\nError in the log settings", err.Error())
+	}
+
+	ratelimit.Configure(config.Limits)
 
 	// load .env file
 	Log.Info("\nLoading \".env\" file...")
@@ -63,49 +76,109 @@ func main() {
 		Log.Debug(Log.Level.Warning, `Error loading ".env" file:`, err.Error())
 	}
 
-	// firebase
-	Log.Info("\nInitializing Firebase...")
-	Log.Debug(Log.Level.Info, `Initializing Firebase...`)
-	firebaseClient := firebase.Initialize()
+	// the subsystems, started in the order of their dependencies and stopped in the reverse order
+	manager := lifecycle.New(shutdownTimeout)
+	manager.Add(
+		lifecycle.Service{Name: "Firebase", Start: firebase.Start, Stop: firebase.Stop},
+		lifecycle.Service{Name: "HTTP Server", Start: httpServer.Start, Stop: httpServer.Stop},
+		lifecycle.Service{Name: "Torrent Client", Start: torrentClient.StartSession, Stop: torrentClient.CloseSession},
+		lifecycle.Service{
+			Name:      "Discord session",
+			DependsOn: []string{"Firebase", "HTTP Server", "Torrent Client"},
+			Start:     discord.Start,
+			Stop:      discord.Stop,
+		},
+	)
+
+	// stop the startup on CTRL-C too
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 
-	// http server
-	Log.Info("\nInitializing HTTP Server...")
-	Log.Debug(Log.Level.Info, `Initializing HTTP Server...`)
-	httpServer.Initialize()
+	startCtx, cancelStart := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-sc:
+			cancelStart()
+		case <-startCtx.Done():
+		}
+	}()
 
-	// torrent client
-	Log.Info("\nInitializing Torrent Client...")
-	Log.Debug(Log.Level.Info, `Initializing Torrent Client...`)
-	torrentClient.Initialize()
+	err = manager.Start(startCtx)
+	cancelStart()
+	if err != nil {
+		Log.Error("\nStartup failed:", err.Error())
+		Log.Debug(Log.Level.Fatal, "Startup failed:", err.Error())
+		Log.Close()
+		os.Exit(1)
+	}
 
-	// discord
-	Log.Info("\nStarting Discord session...")
-	Log.Debug(Log.Level.Info, `Starting Discord session...`)
-	dg := discord.StartDiscordBotSession()
+	// all the subsystems are started
+	health.SetReady(true)
 
-	// Wait here until CTRL-C or other term signal is received.
+	// reload the config on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	// Wait here until CTRL-C or other term signal is received, or a subsystem fails.
 	Log.Tip("\nPress CTRL-C to exit.")
-	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
-	<-sc
-
-	// Cleanly close down the Discord session.
-	Log.Info("\nClosing Discord session...")
-	Log.Debug(Log.Level.Info, `Closing Discord session...`)
-	dg.Close()
-
-	// close firebase
-	Log.Info("\nClosing Firebase...")
-	Log.Debug(Log.Level.Info, `Closing Firebase...`)
-	firebaseClient.Close()
-
-	// close http server
-	Log.Info("\nClosing HTTP Server...")
-	Log.Debug(Log.Level.Info, `Closing HTTP Server...`)
-	httpServer.Close()
-
-	// close torrent client
-	Log.Info("\nClosing Torrent Client...")
-	Log.Debug(Log.Level.Info, `Closing Torrent Client...`)
-	torrentClient.CloseSession()
+	exitCode := 0
+wait:
+	for {
+		select {
+		case <-sc:
+			break wait
+		case err := <-manager.Errors():
+			Log.Error("\nShutting down after an error:", err.Error())
+			Log.Debug(Log.Level.Error, "Shutting down after an error:", err.Error())
+			exitCode = 1
+			break wait
+		case <-hup:
+			reloadConfig(*configPath)
+		}
+	}
+
+	health.SetReady(false)
+
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), shutdownTimeout)
+	err = manager.Stop(stopCtx)
+	cancelStop()
+	if err != nil {
+		exitCode = 1
+	}
+
+	// close the log file
+	Log.Close()
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// reloadConfig applies the settings that can change while running, the old config is kept if the new one is invalid
+func reloadConfig(path string) {
+	Log.Info("\nReloading the config...")
+	Log.Debug(Log.Level.Info, "Reloading the config...")
+
+	config, needRestart, err := utils.ReloadAppConfig(path)
+	if err != nil {
+		Log.Error("\nReloading the config:", err.Error())
+		Log.Debug(Log.Level.Error, "Reloading the config:", err.Error())
+		return
+	}
+
+	err = Log.Configure(config.Log)
+	if err != nil {
+		Log.Error("\nReloading the log settings:", err.Error())
+		Log.Debug(Log.Level.Error, "Reloading the log settings:", err.Error())
+	}
+
+	ratelimit.Configure(config.Limits)
+
+	if len(needRestart) > 0 {
+		Log.Warning("\nThese settings need a restart:", strings.Join(needRestart, ", "))
+		Log.Debug(Log.Level.Warning, "These settings need a restart:", strings.Join(needRestart, ", "))
+	}
+
+	Log.Success("\nConfig reloaded")
+	Log.Debug(Log.Level.Info, "Config reloaded")
 }
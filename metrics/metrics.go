@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "discordbot"
+
+// Registry holds all the metrics of the bot, it's served by the "/metrics" route
+var Registry = prometheus.NewRegistry()
+
+var (
+	commandInvocations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "command_invocations_total",
+		Help:      "The number of slash command invocations.",
+	}, []string{"command"})
+
+	commandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "command_duration_seconds",
+		Help:      "The time a slash command handler takes.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"command"})
+
+	discordAPIErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "discord_api_errors_total",
+		Help:      "The number of failed Discord API requests by status code, \"error\" when there's no response.",
+	}, []string{"status"})
+
+	videoBytesServed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "video_bytes_served_total",
+		Help:      "The number of bytes sent by the video route.",
+	})
+
+	ttsDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tts_synthesis_duration_seconds",
+		Help:      "The time the TTS audio takes to be generated.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 5, 10},
+	})
+
+	ttsFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tts_failures_total",
+		Help:      "The number of failed TTS generations.",
+	})
+
+	handlerPanics = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "handler_panics_total",
+		Help:      "The number of recovered panics by handler.",
+	}, []string{"handler"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "The number of commands refused by a cooldown or the guild rate limit.",
+	}, []string{"command"})
+
+	guildCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "guild_cache_requests_total",
+		Help:      "The number of guild data requests by result, \"hit\" or \"miss\".",
+	}, []string{"result"})
+)
+
+// used for the hit ratio gauge
+var guildCacheHits, guildCacheMisses atomic.Int64
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		commandInvocations,
+		commandDuration,
+		discordAPIErrors,
+		videoBytesServed,
+		ttsDuration,
+		ttsFailures,
+		handlerPanics,
+		rateLimited,
+		guildCacheRequests,
+	)
+
+	RegisterGaugeFunc("guild_cache_hit_ratio", "The ratio of guild data requests served from the cache.", func() float64 {
+		hits, misses := guildCacheHits.Load(), guildCacheMisses.Load()
+		if hits+misses == 0 {
+			return 0
+		}
+		return float64(hits) / float64(hits+misses)
+	})
+}
+
+// Handler serves the metrics in the Prometheus format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// RegisterGaugeFunc adds a gauge that calls fn on each scrape
+func RegisterGaugeFunc(name string, help string, fn func() float64) {
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// ObserveCommand counts a slash command invocation and the time since start
+func ObserveCommand(command string, start time.Time) {
+	commandInvocations.WithLabelValues(command).Inc()
+	commandDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+}
+
+// ObserveTTS records the time since start of a TTS generation, or a failure if err isn't nil
+func ObserveTTS(start time.Time, err error) {
+	if err != nil {
+		ttsFailures.Inc()
+		return
+	}
+	ttsDuration.Observe(time.Since(start).Seconds())
+}
+
+func HandlerPanic(handler string) {
+	handlerPanics.WithLabelValues(handler).Inc()
+}
+
+func CommandRateLimited(command string) {
+	rateLimited.WithLabelValues(command).Inc()
+}
+
+func AddVideoBytesServed(bytes int) {
+	videoBytesServed.Add(float64(bytes))
+}
+
+func GuildCacheHit() {
+	guildCacheHits.Add(1)
+	guildCacheRequests.WithLabelValues("hit").Inc()
+}
+
+func GuildCacheMiss() {
+	guildCacheMisses.Add(1)
+	guildCacheRequests.WithLabelValues("miss").Inc()
+}
+
+// discordTransport counts the failed requests to the Discord API
+type discordTransport struct {
+	next http.RoundTripper
+}
+
+// NewDiscordTransport wraps the transport of the Discord session client, nil for the default one
+func NewDiscordTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &discordTransport{next: next}
+}
+
+func (t *discordTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		discordAPIErrors.WithLabelValues("error").Inc()
+		return resp, err
+	}
+
+	if resp.StatusCode >= 400 {
+		discordAPIErrors.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// how long an operation waits for a free slot before giving up
+const waitTimeout = 30 * time.Second
+
+// ErrBusy is returned by Wait when no slot was freed in time
+var ErrBusy = errors.New("the bot is busy right now, try again in a minute")
+
+// the global concurrency caps of the expensive operations
+var (
+	Scraping = NewPool("scraping", 2)
+	FFmpeg   = NewPool("ffmpeg", 2)
+	Images   = NewPool("images", 2)
+)
+
+// Pool caps how many operations run at the same time, the size can be changed while it's used
+type Pool struct {
+	Name string
+
+	mutex  sync.Mutex
+	size   int
+	active int
+	// closed and replaced when a slot is released, so the waiting goroutines check again
+	free chan struct{}
+}
+
+func NewPool(name string, size int) *Pool {
+	return &Pool{Name: name, size: size, free: make(chan struct{})}
+}
+
+// Resize changes the number of operations that can run at the same time, a size under 1 is ignored
+func (p *Pool) Resize(size int) {
+	if size < 1 {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.size = size
+	p.notify()
+}
+
+// Acquire waits for a free slot until the context is done, Release must be called when the operation is done
+func (p *Pool) Acquire(ctx context.Context) error {
+	for {
+		p.mutex.Lock()
+		if p.active < p.size {
+			p.active++
+			p.mutex.Unlock()
+			return nil
+		}
+		free := p.free
+		p.mutex.Unlock()
+
+		select {
+		case <-free:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Wait is Acquire with a timeout, it returns ErrBusy when no slot was freed in time
+func (p *Pool) Wait(ctx context.Context) error {
+	waitCtx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+
+	err := p.Acquire(waitCtx)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return ErrBusy
+	}
+	return err
+}
+
+func (p *Pool) Release() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.active--
+	p.notify()
+}
+
+func (p *Pool) notify() {
+	close(p.free)
+	p.free = make(chan struct{})
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"discord-bot/common"
+	"discord-bot/metrics"
+	"sync"
+	"time"
+)
+
+var (
+	mutex sync.Mutex
+
+	// the time a user can use a command again, the key is "guildID:userID:command"
+	cooldowns = map[string]time.Time{}
+
+	// the commands used in the current minute of each guild
+	guildWindows = map[string]*window{}
+
+	// the commands a guild can use in a minute, -1 for no limit
+	guildCommandsPerMinute = 60
+)
+
+type window struct {
+	start time.Time
+	count int
+}
+
+func init() {
+	go cleanup()
+}
+
+// Configure applies the limits of the config, it can be called again to reload them
+func Configure(config common.LimitsConfig) {
+	mutex.Lock()
+	guildCommandsPerMinute = config.GuildCommandsPerMinute
+	mutex.Unlock()
+
+	Scraping.Resize(config.Concurrency.Scraping)
+	FFmpeg.Resize(config.Concurrency.FFmpeg)
+	Images.Resize(config.Concurrency.Images)
+}
+
+// Allow checks the guild limit and the cooldown of the user for a command, if it's allowed the cooldown starts,
+// otherwise it returns the time to wait
+func Allow(guildID string, userID string, command string, cooldown time.Duration) (time.Duration, bool) {
+	now := time.Now()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	key := guildID + ":" + userID + ":" + command
+	if until, ok := cooldowns[key]; ok && now.Before(until) {
+		metrics.CommandRateLimited(command)
+		return until.Sub(now), false
+	}
+
+	if guildID != "" && guildCommandsPerMinute > 0 {
+		w, ok := guildWindows[guildID]
+		if !ok || now.Sub(w.start) >= time.Minute {
+			w = &window{start: now}
+			guildWindows[guildID] = w
+		}
+
+		if w.count >= guildCommandsPerMinute {
+			metrics.CommandRateLimited(command)
+			return w.start.Add(time.Minute).Sub(now), false
+		}
+		w.count++
+	}
+
+	if cooldown > 0 {
+		cooldowns[key] = now.Add(cooldown)
+	}
+
+	return 0, true
+}
+
+// cleanup removes the ended cooldowns and windows so the maps don't grow forever
+func cleanup() {
+	for range time.Tick(10 * time.Minute) {
+		now := time.Now()
+
+		mutex.Lock()
+		for key, until := range cooldowns {
+			if now.After(until) {
+				delete(cooldowns, key)
+			}
+		}
+		for guildID, w := range guildWindows {
+			if now.Sub(w.start) >= time.Minute {
+				delete(guildWindows, guildID)
+			}
+		}
+		mutex.Unlock()
+	}
+}
+
+// FormatWait formats the time to wait for the "try again" responses, rounded up to seconds like "5s" or "1m3s"
+func FormatWait(wait time.Duration) string {
+	return (wait + time.Second - 1).Truncate(time.Second).String()
+}
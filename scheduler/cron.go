@@ -0,0 +1,216 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron expression: "minute hour day-of-month month day-of-week"
+type Cron struct {
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool // "*" for the day of month, only the day of week is used
+	dowStar bool // "*" for the day of week, only the day of month is used
+}
+
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is also sunday
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a standard 5 fields cron expression, names like "mon" and "jan" and macros like "@daily" are supported
+func ParseCron(expression string) (*Cron, error) {
+	expression = strings.TrimSpace(expression)
+	if macro, ok := cronMacros[strings.ToLower(expression)]; ok {
+		expression = macro
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+
+	var err error
+	cron := &Cron{}
+
+	if cron.minute, err = parseCronField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if cron.hour, err = parseCronField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if cron.dom, err = parseCronField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if cron.month, err = parseCronField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if cron.dow, err = parseCronField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+
+	// sunday can be 0 or 7
+	if cron.dow&(1<<7) != 0 {
+		cron.dow |= 1
+	}
+
+	cron.domStar = strings.HasPrefix(fields[2], "*")
+	cron.dowStar = strings.HasPrefix(fields[4], "*")
+
+	if cron.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("the expression never matches a date")
+	}
+
+	return cron, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps like "1,5-10,*/15" to a bitset
+func parseCronField(text string, field cronField) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(text, ",") {
+		rangeText, stepText, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepText)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step \"%s\" in the %s field", stepText, field.name)
+			}
+		}
+
+		start, end := field.min, field.max
+		if rangeText != "*" {
+			startText, endText, isRange := strings.Cut(rangeText, "-")
+
+			var err error
+			start, err = parseCronValue(startText, field)
+			if err != nil {
+				return 0, err
+			}
+
+			end = start
+			if isRange {
+				end, err = parseCronValue(endText, field)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				end = field.max // "5/10" means from 5 to the max every 10
+			}
+
+			if start > end {
+				return 0, fmt.Errorf("invalid range \"%s\" in the %s field", rangeText, field.name)
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << value
+		}
+	}
+
+	return bits, nil
+}
+
+func parseCronValue(text string, field cronField) (int, error) {
+	if value, ok := field.names[strings.ToLower(text)]; ok {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil || value < field.min || value > field.max {
+		return 0, fmt.Errorf("invalid value \"%s\" in the %s field, expected %d-%d", text, field.name, field.min, field.max)
+	}
+
+	return value, nil
+}
+
+func hasBit(bits uint64, value int) bool {
+	return bits&(1<<value) != 0
+}
+
+// dayMatches follows the cron rule: if both days are restricted, matching any of them is enough
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := hasBit(c.dom, t.Day())
+	dowMatch := hasBit(c.dow, int(t.Weekday()))
+
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns the first time after the given time that matches the expression, in the location of the given time,
+// it returns a zero time if nothing matches in the next 5 years
+func (c *Cron) Next(after time.Time) time.Time {
+	location := after.Location()
+	t := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), 0, 0, location).Add(time.Minute)
+	yearLimit := t.Year() + 5
+
+	// each field skips to the next matching value, and starts again when a bigger field wraps around
+search:
+	for t.Year() <= yearLimit {
+		for !hasBit(c.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+			if t.Month() == time.January {
+				continue search
+			}
+		}
+
+		for !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+			if t.Day() == 1 {
+				continue search
+			}
+		}
+
+		for !hasBit(c.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+			if t.Hour() == 0 {
+				continue search
+			}
+		}
+
+		for !hasBit(c.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			if t.Minute() == 0 {
+				continue search
+			}
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
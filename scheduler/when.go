@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // the timezones database, in case the system doesn't have one
+)
+
+var (
+	relativeRegex     = regexp.MustCompile(`^(\d+\s*[a-z]+[\s,]*)+$`)
+	relativePartRegex = regexp.MustCompile(`(\d+)\s*([a-z]+)`)
+	clockRegex        = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+)
+
+var relativeUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// LoadTimezone returns the location of a timezone name like "Europe/Berlin", empty for UTC
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone \"%s\", use a name like Europe/Berlin or America/New_York", name)
+	}
+	return location, nil
+}
+
+// parseRelative sums the parts of a relative time, it fails on unknown units like the "am" of "9am"
+func parseRelative(text string) (time.Duration, bool) {
+	var duration time.Duration
+	for _, part := range relativePartRegex.FindAllStringSubmatch(text, -1) {
+		unit, ok := relativeUnits[part[2]]
+		if !ok {
+			return 0, false
+		}
+		value, _ := strconv.Atoi(part[1])
+		duration += time.Duration(value) * unit
+	}
+	return duration, true
+}
+
+// ParseWhen parses a relative time like "in 1h 30m" or "2 days", or an absolute time like "15:30", "tomorrow 9am"
+// or "2024-12-31 18:00", absolute times use the location of now
+func ParseWhen(text string, now time.Time) (time.Time, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	text = strings.TrimPrefix(text, "in ")
+	text = strings.ReplaceAll(text, " and ", " ")
+
+	if relativeRegex.MatchString(text) {
+		if duration, ok := parseRelative(text); ok {
+			return now.Add(duration), nil
+		}
+	}
+
+	location := now.Location()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	hasDay := false
+
+	dayText, clockText, _ := strings.Cut(text, " ")
+	switch {
+	case dayText == "today":
+		hasDay = true
+	case dayText == "tomorrow":
+		day = day.AddDate(0, 0, 1)
+		hasDay = true
+	default:
+		if date, err := time.ParseInLocation("2006-01-02", dayText, location); err == nil {
+			day = date
+			hasDay = true
+		} else {
+			clockText = text
+		}
+	}
+
+	// 9am by default when only the day is given
+	hour, minute := 9, 0
+	if clockText = strings.TrimSpace(clockText); clockText != "" {
+		match := clockRegex.FindStringSubmatch(clockText)
+		if match == nil {
+			return time.Time{}, fmt.Errorf("invalid time \"%s\", use something like 10m, 2h 30m, 15:30, tomorrow 9am or 2024-12-31 18:00", text)
+		}
+
+		hour, _ = strconv.Atoi(match[1])
+		if match[2] != "" {
+			minute, _ = strconv.Atoi(match[2])
+		}
+
+		// 12 hours clock
+		if match[3] != "" && (hour < 1 || hour > 12) {
+			return time.Time{}, fmt.Errorf("invalid time \"%s\"", clockText)
+		}
+
+		switch match[3] {
+		case "am":
+			if hour == 12 {
+				hour = 0
+			}
+		case "pm":
+			if hour < 12 {
+				hour += 12
+			}
+		}
+
+		if hour > 23 || minute > 59 {
+			return time.Time{}, fmt.Errorf("invalid time \"%s\"", clockText)
+		}
+	}
+
+	result := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, location)
+
+	// a time without a day is the next time it happens
+	if !hasDay && !result.After(now) {
+		result = result.AddDate(0, 0, 1)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,131 @@
+package torrentClient
+
+import (
+	"fmt"
+
+	"github.com/cenkalti/rain/torrent"
+)
+
+type TrackerDetails struct {
+	URL      string
+	Status   string
+	Seeders  int
+	Leechers int
+	Error    string
+}
+
+type PeerDetails struct {
+	Addr          string
+	Client        string
+	DownloadSpeed int64
+	UploadSpeed   int64
+}
+
+type FileDetails struct {
+	Path           string
+	BytesTotal     int64
+	BytesCompleted int64
+}
+
+// TorrentDetails holds the raw (unformatted) state of a torrent
+type TorrentDetails struct {
+	ID              string
+	Name            string
+	InfoHash        string
+	Status          string
+	Private         bool
+	Ratio           float64
+	BytesTotal      int64
+	BytesCompleted  int64
+	BytesUploaded   int64
+	PiecesHave      uint32
+	PiecesAvailable uint32
+	PiecesTotal     uint32
+	Trackers        []TrackerDetails
+	Peers           []PeerDetails
+	Files           []FileDetails
+}
+
+var trackerStatuses = map[torrent.TrackerStatus]string{
+	torrent.NotContactedYet: "Not contacted yet",
+	torrent.Contacting:      "Contacting",
+	torrent.Working:         "Working",
+	torrent.NotWorking:      "Not working",
+}
+
+// GetTorrentDetails returns the trackers, peers, pieces and files of a torrent
+func GetTorrentDetails(id string) (*TorrentDetails, error) {
+	t := session.GetTorrent(id)
+	if t == nil {
+		return nil, fmt.Errorf("torrent %s not found", id)
+	}
+
+	s := t.Stats()
+
+	details := &TorrentDetails{
+		ID:              t.ID(),
+		Name:            s.Name,
+		InfoHash:        s.InfoHash.String(),
+		Status:          s.Status.String(),
+		Private:         s.Private,
+		Ratio:           ratio(s),
+		BytesTotal:      s.Bytes.Total,
+		BytesCompleted:  s.Bytes.Completed,
+		BytesUploaded:   s.Bytes.Uploaded,
+		PiecesHave:      s.Pieces.Have,
+		PiecesAvailable: s.Pieces.Available,
+		PiecesTotal:     s.Pieces.Total,
+	}
+
+	for _, tracker := range t.Trackers() {
+		item := TrackerDetails{
+			URL:      tracker.URL,
+			Status:   trackerStatuses[tracker.Status],
+			Seeders:  tracker.Seeders,
+			Leechers: tracker.Leechers,
+		}
+		if tracker.Error != nil {
+			item.Error = tracker.Error.Error()
+		}
+		details.Trackers = append(details.Trackers, item)
+	}
+
+	for _, peer := range t.Peers() {
+		details.Peers = append(details.Peers, PeerDetails{
+			Addr:          peer.Addr.String(),
+			Client:        peer.Client,
+			DownloadSpeed: int64(peer.DownloadSpeed),
+			UploadSpeed:   int64(peer.UploadSpeed),
+		})
+	}
+
+	// files are not available until the metadata is downloaded
+	files, err := t.Files()
+	if err == nil {
+		for _, file := range files {
+			details.Files = append(details.Files, FileDetails{
+				Path:           file.Path(),
+				BytesTotal:     file.Stats().BytesTotal,
+				BytesCompleted: file.Stats().BytesCompleted,
+			})
+		}
+	}
+
+	return details, nil
+}
+
+// ratio is the uploaded bytes divided by the downloaded bytes
+func ratio(s torrent.Stats) float64 {
+	downloaded := s.Bytes.Downloaded
+
+	// torrents loaded from disk didn't download anything in this session
+	if downloaded == 0 {
+		downloaded = s.Bytes.Completed
+	}
+
+	if downloaded == 0 {
+		return 0
+	}
+
+	return float64(s.Bytes.Uploaded) / float64(downloaded)
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package torrentClient
+
+import (
+	"errors"
+	"syscall"
+)
+
+var errFreeSpaceUnsupported = errors.New("free space is not supported on this system")
+
+// freeSpace returns the bytes available to the bot in the file system of a path
+func freeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
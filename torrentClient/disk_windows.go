@@ -0,0 +1,12 @@
+//go:build windows
+
+package torrentClient
+
+import "errors"
+
+var errFreeSpaceUnsupported = errors.New("free space is not supported on this system")
+
+// freeSpace isn't implemented on windows, the disk check is skipped
+func freeSpace(path string) (uint64, error) {
+	return 0, errFreeSpaceUnsupported
+}
@@ -0,0 +1,53 @@
+package torrentClient
+
+import (
+	"context"
+	"discord-bot/health"
+	"discord-bot/utils"
+	"fmt"
+)
+
+// the default min free space of the download directory in megabytes
+const defaultMinFreeSpace = 1024
+
+func registerHealthChecks() {
+	health.Register(health.Check{
+		Name:     "torrent",
+		Critical: true,
+		Run: func(ctx context.Context) (string, error) {
+			if session == nil {
+				return "", fmt.Errorf("the torrent session is not initialized")
+			}
+
+			stats := session.Stats()
+			return fmt.Sprintf("%d torrents, %d peers", stats.Torrents, stats.Peers), nil
+		},
+	})
+
+	health.Register(health.Check{
+		Name: "disk",
+		Run: func(ctx context.Context) (string, error) {
+			config := utils.GetAppConfig()
+
+			free, err := freeSpace(config.Torrent.DownloadDir)
+			if err == errFreeSpaceUnsupported {
+				return "unknown free space", nil
+			}
+			if err != nil {
+				return "", err
+			}
+
+			minFree := config.Torrent.MinFreeSpace
+			if minFree <= 0 {
+				minFree = defaultMinFreeSpace
+			}
+
+			detail := FormatBytes(int64(free)) + " free"
+			if free < uint64(minFree)*1024*1024 {
+				return detail, fmt.Errorf("less than %d MB free in the download directory", minFree)
+			}
+
+			return detail, nil
+		},
+	})
+}
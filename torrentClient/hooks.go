@@ -0,0 +1,330 @@
+package torrentClient
+
+import (
+	"bytes"
+	"context"
+	"discord-bot/common"
+	"discord-bot/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// max time for the post download command and the rescan webhook
+const (
+	hookCommandTimeout = 10 * time.Minute
+	hookRescanTimeout  = 30 * time.Second
+)
+
+// HookResult is the outcome of a single post download hook
+type HookResult struct {
+	Name  string // "library", "command" or "rescan"
+	Info  string
+	Error error
+}
+
+// hooksRun is the post download hooks of a torrent that are running, done is closed with the results set
+type hooksRun struct {
+	done    chan struct{}
+	results []HookResult
+}
+
+var (
+	// the torrents whose post download hooks are done, the key is the torrent ID
+	hooksDone = map[string]bool{}
+
+	// the hooks that are running, the key is the torrent ID
+	hooksRuns = map[string]*hooksRun{}
+
+	// guards hooksDone and hooksRuns
+	hooksMutex sync.Mutex
+)
+
+// WaitPostDownloadHooks starts the post download hooks of a completed torrent if they didn't run yet and waits for
+// their results, false when they already ran before or ctx is done
+func WaitPostDownloadHooks(ctx context.Context, id string) ([]HookResult, bool) {
+	run := startPostDownloadHooks(id)
+	if run == nil {
+		return nil, false
+	}
+
+	select {
+	case <-run.done:
+		return run.results, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// startCompletedHooks starts the hooks of the completed torrents that didn't run yet, like the torrents completed
+// in the background or before a restart
+func startCompletedHooks() {
+	for _, tor := range session.ListTorrents() {
+		if GetTorrentState(tor) == StateCompleted {
+			startPostDownloadHooks(tor.ID())
+		}
+	}
+}
+
+// startPostDownloadHooks runs the hooks of a completed torrent in the background once, nil when they are done
+func startPostDownloadHooks(id string) *hooksRun {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	if run, ok := hooksRuns[id]; ok {
+		return run
+	}
+	if hooksDone[id] {
+		return nil
+	}
+
+	run := &hooksRun{done: make(chan struct{})}
+	hooksRuns[id] = run
+
+	go func() {
+		run.results = runPostDownloadHooks(id)
+
+		hooksMutex.Lock()
+		delete(hooksRuns, id)
+		hooksDone[id] = true
+		err := saveHooksDone()
+		hooksMutex.Unlock()
+
+		if err != nil {
+			Log.Error("\nSaving post download hooks:", err.Error())
+			Log.Debug(Log.Level.Error, "Saving post download hooks:", err.Error())
+		}
+
+		close(run.done)
+	}()
+
+	return run
+}
+
+// removeHooksDone forgets a removed torrent, the file is only saved if its hooks were done
+func removeHooksDone(id string) error {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	if !hooksDone[id] {
+		return nil
+	}
+	delete(hooksDone, id)
+
+	return saveHooksDone()
+}
+
+func hooksDonePath() string {
+	return filepath.Join(utils.GetAppConfig().Torrent.DownloadDir, "hooks.json")
+}
+
+// loadHooksDone loads the torrents whose hooks are done, without the file all the completed torrents are
+// considered done so the old downloads are not organised again
+func loadHooksDone() error {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	data, err := os.ReadFile(hooksDonePath())
+	if os.IsNotExist(err) {
+		for _, tor := range session.ListTorrents() {
+			if GetTorrentState(tor) == StateCompleted {
+				hooksDone[tor.ID()] = true
+			}
+		}
+		return saveHooksDone()
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &hooksDone)
+}
+
+// saveHooksDone writes the torrents whose hooks are done to the download directory, the caller must hold hooksMutex
+func saveHooksDone() error {
+	data, err := json.MarshalIndent(hooksDone, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(hooksDonePath(), data, 0644)
+}
+
+// runPostDownloadHooks organises the files of a completed torrent into the library, runs the user command
+// and triggers the library rescan webhook. Disabled hooks are not included in the results.
+func runPostDownloadHooks(id string) []HookResult {
+	hooks := utils.GetAppConfig().Torrent.Hooks
+	results := []HookResult{}
+
+	tor, err := GetTorrentByID(id)
+	if err != nil {
+		return append(results, HookResult{Name: "library", Error: err})
+	}
+
+	paths, err := tor.FilePaths()
+	if err != nil {
+		return append(results, HookResult{Name: "library", Error: err})
+	}
+
+	downloadDir := utils.GetAppConfig().Torrent.DownloadDir
+	for index, path := range paths {
+		paths[index] = filepath.Join(downloadDir, path)
+	}
+
+	var libraryFiles []string
+
+	// * Library
+	if hooks.LibraryDir != "" {
+		var result HookResult
+		libraryFiles, result = organiseLibrary(id, tor.Name(), paths, hooks)
+		results = append(results, result)
+	}
+
+	// * Command
+	if hooks.Command != "" {
+		result := HookResult{Name: "command", Info: "done"}
+		result.Error = runHookCommand(hooks.Command, id, tor.Name(), paths, libraryFiles)
+		results = append(results, result)
+	}
+
+	// * Rescan
+	if hooks.RescanURL != "" {
+		result := HookResult{Name: "rescan", Info: "triggered"}
+		result.Error = triggerRescan(hooks, tor.Name(), libraryFiles)
+		results = append(results, result)
+	}
+
+	for _, result := range results {
+		if result.Error != nil {
+			Log.With(utils.FieldTorrent, id).Error("post download hook failed", "hook", result.Name, "name", tor.Name(), utils.FieldError, result.Error.Error())
+		}
+	}
+
+	return results
+}
+
+func organiseLibrary(id string, torrentName string, paths []string, hooks common.PostDownloadHooks) ([]string, HookResult) {
+	result := HookResult{Name: "library"}
+
+	mode := hooks.LibraryMode
+	if mode == "" {
+		mode = common.LibraryHardlink
+	}
+
+	// moving the files breaks seeding
+	if mode == common.LibraryMove && GetSeedingPolicy(id).Mode != common.SeedingNone {
+		mode = common.LibraryHardlink
+	}
+
+	media := getMediaInfo(id)
+
+	var placed, errors []string
+	for _, path := range paths {
+		relativePath, ok := libraryPath(path, torrentName, media)
+		if !ok {
+			continue
+		}
+
+		dst := filepath.Join(hooks.LibraryDir, relativePath)
+		if utils.FileExists(dst) {
+			placed = append(placed, dst)
+			continue
+		}
+
+		err := placeFile(path, dst, mode)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %s", filepath.Base(path), err.Error()))
+			continue
+		}
+
+		placed = append(placed, dst)
+	}
+
+	result.Info = fmt.Sprintf("%d files (%s)", len(placed), mode)
+	if len(placed) > 0 {
+		result.Info += " into " + filepath.Dir(placed[0])
+	}
+	if len(errors) > 0 {
+		result.Error = fmt.Errorf("%s", strings.Join(errors, ", "))
+	}
+
+	return placed, result
+}
+
+// runHookCommand runs the user command with "sh -c", or "cmd /C" on Windows, the torrent info is passed as
+// environment variables
+func runHookCommand(command string, id string, name string, paths []string, libraryFiles []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookCommandTimeout)
+	defer cancel()
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	if _, err := exec.LookPath(shell); err != nil {
+		return fmt.Errorf("the command needs \"%s\" to run, it was not found: %w", shell, err)
+	}
+
+	cmd := exec.CommandContext(ctx, shell, flag, command)
+	cmd.Env = append(os.Environ(),
+		"TORRENT_ID="+id,
+		"TORRENT_NAME="+name,
+		"TORRENT_DIR="+utils.GetAppConfig().Torrent.DownloadDir,
+		"TORRENT_FILES="+strings.Join(paths, "\n"),
+		"LIBRARY_FILES="+strings.Join(libraryFiles, "\n"),
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		Log.Debug(Log.Level.Error, "Post download command output:", string(output))
+		return err
+	}
+
+	return nil
+}
+
+// triggerRescan calls the library rescan webhook with the new files as a json body
+func triggerRescan(hooks common.PostDownloadHooks, name string, libraryFiles []string) error {
+	method := strings.ToUpper(hooks.RescanMethod)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"event": "download_complete",
+		"name":  name,
+		"files": libraryFiles,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookRescanTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, hooks.RescanURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("rescan webhook responded with status: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,269 @@
+package torrentClient
+
+import (
+	"discord-bot/common"
+	"discord-bot/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MediaInfo is the known metadata of a movie torrent, e.g. from yts
+type MediaInfo struct {
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+}
+
+// how long media info waits for its torrent to be added, most of the yts menus are never used
+const pendingMediaInfoTTL = time.Hour
+
+type pendingMedia struct {
+	info    MediaInfo
+	addedAt time.Time
+}
+
+var (
+	// media info waiting for its torrent to be added, the key is the torrent uri
+	pendingMediaInfo = map[string]pendingMedia{}
+
+	// media info of the added torrents, the key is the torrent ID
+	mediaInfo = map[string]MediaInfo{}
+
+	// guards pendingMediaInfo and mediaInfo
+	mediaInfoMutex sync.Mutex
+)
+
+// the files that are organised into the library
+var libraryExtensions = map[string]bool{
+	".mkv": true, ".mp4": true, ".avi": true, ".mov": true, ".wmv": true, ".m4v": true, ".webm": true, ".ts": true,
+	".srt": true, ".sub": true, ".ass": true, ".ssa": true, ".vtt": true, ".idx": true,
+}
+
+var (
+	episodeRegex    = regexp.MustCompile(`(?i)^(.*?)[ ._\-\[(]*s(\d{1,2})[ ._\-]*e\d{1,3}`)
+	episodeAltRegex = regexp.MustCompile(`(?i)^(.*?)[ ._\-]+(\d{1,2})x\d{2}\b`)
+	movieRegex      = regexp.MustCompile(`^(.*)[ ._\-\[(]+((?:19|20)\d{2})(?:[ ._\-\])]|$)`)
+	spacesRegex     = regexp.MustCompile(`\s+`)
+	invalidChars    = regexp.MustCompile(`[<>:"/\\|?*]`)
+)
+
+// SetMediaInfo attaches movie metadata to a torrent uri before it's added
+func SetMediaInfo(uri string, info MediaInfo) {
+	mediaInfoMutex.Lock()
+	defer mediaInfoMutex.Unlock()
+
+	now := time.Now()
+	for pendingURI, pending := range pendingMediaInfo {
+		if now.Sub(pending.addedAt) > pendingMediaInfoTTL {
+			delete(pendingMediaInfo, pendingURI)
+		}
+	}
+
+	pendingMediaInfo[uri] = pendingMedia{info: info, addedAt: now}
+}
+
+// attachMediaInfo moves the pending media info of a uri to the added torrent
+func attachMediaInfo(uri string, id string) {
+	mediaInfoMutex.Lock()
+	defer mediaInfoMutex.Unlock()
+
+	pending, ok := pendingMediaInfo[uri]
+	if !ok {
+		return
+	}
+	delete(pendingMediaInfo, uri)
+
+	mediaInfo[id] = pending.info
+	err := saveMediaInfo()
+	if err != nil {
+		Log.Error("\nSaving media info:", err.Error())
+		Log.Debug(Log.Level.Error, "Saving media info:", err.Error())
+	}
+}
+
+// getMediaInfo returns the media info of a torrent, nil if it has none
+func getMediaInfo(id string) *MediaInfo {
+	mediaInfoMutex.Lock()
+	defer mediaInfoMutex.Unlock()
+
+	info, ok := mediaInfo[id]
+	if !ok {
+		return nil
+	}
+	return &info
+}
+
+// removeMediaInfo removes the media info of a removed torrent, the file is only saved if it had one
+func removeMediaInfo(id string) error {
+	mediaInfoMutex.Lock()
+	defer mediaInfoMutex.Unlock()
+
+	if _, ok := mediaInfo[id]; !ok {
+		return nil
+	}
+	delete(mediaInfo, id)
+
+	return saveMediaInfo()
+}
+
+func mediaInfoPath() string {
+	return filepath.Join(utils.GetAppConfig().Torrent.DownloadDir, "media.json")
+}
+
+func loadMediaInfo() error {
+	data, err := os.ReadFile(mediaInfoPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	mediaInfoMutex.Lock()
+	defer mediaInfoMutex.Unlock()
+
+	return json.Unmarshal(data, &mediaInfo)
+}
+
+// saveMediaInfo writes the media info to the download directory, the caller must hold mediaInfoMutex
+func saveMediaInfo() error {
+	data, err := json.MarshalIndent(mediaInfo, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(mediaInfoPath(), data, 0644)
+}
+
+// cleanName turns a release name like "The.Movie_Name" into "The Movie Name"
+func cleanName(name string) string {
+	name = strings.NewReplacer(".", " ", "_", " ").Replace(name)
+	name = invalidChars.ReplaceAllString(name, "")
+	name = spacesRegex.ReplaceAllString(name, " ")
+	return strings.Trim(name, " -[]()")
+}
+
+// parseEpisode extracts the show name and the season number from a file name like "Show.Name.S01E02.mkv"
+func parseEpisode(name string) (show string, season int, ok bool) {
+	matches := episodeRegex.FindStringSubmatch(name)
+	if matches == nil {
+		matches = episodeAltRegex.FindStringSubmatch(name)
+	}
+	if matches == nil {
+		return "", 0, false
+	}
+
+	season, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return cleanName(matches[1]), season, true
+}
+
+// parseMovie extracts the title and the year from a release name like "Movie.Name.2010.1080p"
+func parseMovie(name string) (title string, year int, ok bool) {
+	matches := movieRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return "", 0, false
+	}
+
+	year, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return "", 0, false
+	}
+
+	title = cleanName(matches[1])
+	if title == "" {
+		return "", 0, false
+	}
+
+	return title, year, true
+}
+
+// libraryPath returns where a torrent file belongs inside the library, false if it can't be recognized
+func libraryPath(filePath string, torrentName string, media *MediaInfo) (string, bool) {
+	base := filepath.Base(filePath)
+
+	if !libraryExtensions[strings.ToLower(filepath.Ext(base))] || strings.Contains(strings.ToLower(base), "sample") {
+		return "", false
+	}
+
+	// * TV episode
+	if show, season, ok := parseEpisode(base); ok {
+		// the file name may only be "S01E02.mkv"
+		if show == "" {
+			show, _, _ = parseEpisode(torrentName)
+		}
+		if show == "" {
+			show = cleanName(torrentName)
+		}
+		return filepath.Join("TV", show, fmt.Sprintf("Season %d", season), base), true
+	}
+
+	// * Movie
+	if media != nil {
+		return filepath.Join("Movies", fmt.Sprintf("%s (%d)", cleanName(media.Title), media.Year), base), true
+	}
+
+	for _, name := range []string{torrentName, strings.TrimSuffix(base, filepath.Ext(base))} {
+		if title, year, ok := parseMovie(name); ok {
+			return filepath.Join("Movies", fmt.Sprintf("%s (%d)", title, year), base), true
+		}
+	}
+
+	return "", false
+}
+
+// placeFile hardlinks or moves a file, it falls back to copying when the library is on another device
+func placeFile(src, dst string, mode common.LibraryMode) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	if mode == common.LibraryMove {
+		if os.Rename(src, dst) == nil {
+			return nil
+		}
+		err = copyFile(src, dst)
+		if err != nil {
+			return err
+		}
+		return os.Remove(src)
+	}
+
+	if os.Link(src, dst) == nil {
+		return nil
+	}
+	return copyFile(src, dst)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,29 @@
+package torrentClient
+
+import (
+	"discord-bot/metrics"
+
+	"github.com/cenkalti/rain/torrent"
+)
+
+// registerMetrics adds the torrent gauges, they are read from the session on each scrape
+func registerMetrics() {
+	metrics.RegisterGaugeFunc("torrents_active", "The number of torrents that are downloading or seeding.", func() float64 {
+		active := 0
+		for _, tor := range session.ListTorrents() {
+			s := tor.Stats()
+			if s.Error == nil && s.Status != torrent.Stopped && s.Status != torrent.Stopping {
+				active++
+			}
+		}
+		return float64(active)
+	})
+
+	metrics.RegisterGaugeFunc("torrent_download_bytes_per_second", "The download speed of all the torrents.", func() float64 {
+		return float64(session.Stats().SpeedDownload)
+	})
+
+	metrics.RegisterGaugeFunc("torrent_upload_bytes_per_second", "The upload speed of all the torrents.", func() float64 {
+		return float64(session.Stats().SpeedUpload)
+	})
+}
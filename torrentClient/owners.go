@@ -0,0 +1,87 @@
+package torrentClient
+
+import (
+	"discord-bot/utils"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cenkalti/rain/torrent"
+)
+
+// the user who added each torrent, the key is the torrent ID
+var (
+	owners      = map[string]string{}
+	ownersMutex sync.RWMutex
+)
+
+// SetOwner saves the user who added a torrent
+func SetOwner(id string, userID string) {
+	ownersMutex.Lock()
+	defer ownersMutex.Unlock()
+
+	owners[id] = userID
+
+	err := saveOwners()
+	if err != nil {
+		Log.Error("\nSaving torrent owners:", err.Error())
+		Log.Debug(Log.Level.Error, "Saving torrent owners:", err.Error())
+	}
+}
+
+// FilterTorrentsByOwner returns the torrents added by a user, newest first
+func FilterTorrentsByOwner(userID string) []*torrent.Torrent {
+	ownersMutex.RLock()
+	defer ownersMutex.RUnlock()
+
+	var results []*torrent.Torrent
+	for _, tor := range FilterTorrents(StateAll, "") {
+		if owners[tor.ID()] == userID {
+			results = append(results, tor)
+		}
+	}
+	return results
+}
+
+func ownersPath() string {
+	return filepath.Join(utils.GetAppConfig().Torrent.DownloadDir, "owners.json")
+}
+
+func loadOwners() error {
+	data, err := os.ReadFile(ownersPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ownersMutex.Lock()
+	defer ownersMutex.Unlock()
+
+	return json.Unmarshal(data, &owners)
+}
+
+// removeOwner forgets the owner of a removed torrent, the file is only saved if it had one
+func removeOwner(id string) error {
+	ownersMutex.Lock()
+	defer ownersMutex.Unlock()
+
+	if _, ok := owners[id]; !ok {
+		return nil
+	}
+	delete(owners, id)
+
+	return saveOwners()
+}
+
+// saveOwners writes the owners to the download directory, the caller must hold ownersMutex
+func saveOwners() error {
+	data, err := json.MarshalIndent(owners, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(ownersPath(), data, 0644)
+}
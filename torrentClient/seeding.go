@@ -0,0 +1,185 @@
+package torrentClient
+
+import (
+	"context"
+	"discord-bot/common"
+	"discord-bot/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/rain/torrent"
+)
+
+// how often the seeding supervisor checks the seeding torrents
+const seedingCheckInterval = time.Minute
+
+// per torrent seeding policies, the key is the torrent ID
+var (
+	seedingPolicies      = map[string]common.SeedingPolicy{}
+	seedingPoliciesMutex sync.RWMutex
+)
+
+// GetGlobalSeedingPolicy returns the seeding policy from the config, defaults to no seeding
+func GetGlobalSeedingPolicy() common.SeedingPolicy {
+	policy := utils.GetAppConfig().Torrent.Seeding
+	if policy.Mode == "" {
+		policy.Mode = common.SeedingNone
+	}
+	return policy
+}
+
+// GetSeedingPolicy returns the seeding policy of a torrent, or the global policy if it doesn't have one
+func GetSeedingPolicy(id string) common.SeedingPolicy {
+	seedingPoliciesMutex.RLock()
+	policy, ok := seedingPolicies[id]
+	seedingPoliciesMutex.RUnlock()
+
+	if ok {
+		return policy
+	}
+	return GetGlobalSeedingPolicy()
+}
+
+// SetSeedingPolicy sets the seeding policy of a torrent, nil removes it so the global policy is used
+func SetSeedingPolicy(id string, policy *common.SeedingPolicy) error {
+	seedingPoliciesMutex.Lock()
+	defer seedingPoliciesMutex.Unlock()
+
+	if policy == nil {
+		delete(seedingPolicies, id)
+	} else {
+		seedingPolicies[id] = *policy
+	}
+
+	return saveSeedingPolicies()
+}
+
+// removeSeedingPolicy removes the seeding policy of a removed torrent, the file is only saved if it had one
+func removeSeedingPolicy(id string) error {
+	seedingPoliciesMutex.Lock()
+	defer seedingPoliciesMutex.Unlock()
+
+	if _, ok := seedingPolicies[id]; !ok {
+		return nil
+	}
+	delete(seedingPolicies, id)
+
+	return saveSeedingPolicies()
+}
+
+// ValidateSeedingPolicy checks that the policy has the required values for its mode
+func ValidateSeedingPolicy(policy common.SeedingPolicy) error {
+	switch policy.Mode {
+	case common.SeedingNone:
+		return nil
+	case common.SeedingRatio:
+		if policy.Ratio <= 0 {
+			return fmt.Errorf("seeding ratio should be greater than 0")
+		}
+		return nil
+	case common.SeedingTime:
+		if policy.Hours <= 0 {
+			return fmt.Errorf("seeding hours should be greater than 0")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown seeding mode: %s", policy.Mode)
+}
+
+// FormatSeedingPolicy returns a human readable description of a seeding policy
+func FormatSeedingPolicy(policy common.SeedingPolicy) string {
+	switch policy.Mode {
+	case common.SeedingRatio:
+		return fmt.Sprintf("until ratio %.2f", policy.Ratio)
+	case common.SeedingTime:
+		return fmt.Sprintf("for %.1f hours", policy.Hours)
+	}
+	return "no seeding"
+}
+
+// GetRatio returns the current upload ratio of a torrent
+func GetRatio(tor *torrent.Torrent) float64 {
+	return ratio(tor.Stats())
+}
+
+func seedingPoliciesPath() string {
+	return filepath.Join(utils.GetAppConfig().Torrent.DownloadDir, "seeding.json")
+}
+
+func loadSeedingPolicies() error {
+	data, err := os.ReadFile(seedingPoliciesPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	seedingPoliciesMutex.Lock()
+	defer seedingPoliciesMutex.Unlock()
+
+	return json.Unmarshal(data, &seedingPolicies)
+}
+
+// saveSeedingPolicies writes the policies to the download directory, the caller must hold seedingPoliciesMutex
+func saveSeedingPolicies() error {
+	data, err := json.MarshalIndent(seedingPolicies, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(seedingPoliciesPath(), data, 0644)
+}
+
+// shouldStopSeeding checks if a seeding torrent has reached the limit of its seeding policy
+func shouldStopSeeding(s torrent.Stats, policy common.SeedingPolicy) bool {
+	switch policy.Mode {
+	case common.SeedingRatio:
+		return ratio(s) >= policy.Ratio
+	case common.SeedingTime:
+		return s.SeededFor.Hours() >= policy.Hours
+	}
+	return true
+}
+
+// runSeedingSupervisor stops the seeding torrents that reached their seeding policy limits, it also starts the
+// post download hooks of the torrents that completed since the last check
+func runSeedingSupervisor(ctx context.Context) {
+	ticker := time.NewTicker(seedingCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		startCompletedHooks()
+
+		for _, tor := range session.ListTorrents() {
+			s := tor.Stats()
+			if s.Status != torrent.Seeding {
+				continue
+			}
+
+			policy := GetSeedingPolicy(tor.ID())
+			if !shouldStopSeeding(s, policy) {
+				continue
+			}
+
+			Log.With(utils.FieldTorrent, tor.ID()).Info("seeding supervisor: stopping", "name", tor.Name(), "policy", FormatSeedingPolicy(policy), "ratio", ratio(s))
+
+			err := tor.Stop()
+			if err != nil {
+				Log.Error("\nSeeding supervisor:", err.Error())
+				Log.Debug(Log.Level.Error, "Seeding supervisor:", err.Error())
+			}
+		}
+	}
+}
@@ -1,18 +1,26 @@
 package torrentClient
 
 import (
+	"bytes"
+	"context"
+	"discord-bot/common"
 	"discord-bot/utils"
+	"errors"
 	"fmt"
 	"math"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/cenkalti/rain/torrent"
+	"github.com/zeebo/bencode"
 )
 
-var Log = utils.Log
+var Log = &utils.Log
 
 type TorrentInfo struct {
+	ID            string
 	Name          string
 	Status        string
 	Downloaded    string
@@ -22,16 +30,26 @@ type TorrentInfo struct {
 	DownloadSpeed string
 	UploadSpeed   string
 	Peers         string
+	Ratio         string
 	ETA           string
 	Completed     bool
 	Stopped       bool
 	Error         error
 }
 
+// TorrentFile is a parsed and validated .torrent metainfo file
+type TorrentFile struct {
+	Name      string
+	TotalSize string
+	FileCount int
+	Data      []byte
+}
+
 var session *torrent.Session
 var currentTorID string = ""
 
-func Initialize() {
+// StartSession opens the torrent session, the seeding supervisor runs until ctx is canceled
+func StartSession(ctx context.Context) error {
 	var err error
 
 	config := utils.GetAppConfig()
@@ -43,34 +61,187 @@ func Initialize() {
 	sessionConfig.Database = filepath.Join(config.Torrent.DownloadDir, "torrents.db")
 	sessionConfig.DataDirIncludesTorrentID = false
 	sessionConfig.ResumeOnStartup = false
+	sessionConfig.SpeedLimitDownload = config.Torrent.SpeedLimit.Download
+	sessionConfig.SpeedLimitUpload = config.Torrent.SpeedLimit.Upload
 
 	session, err = torrent.NewSession(sessionConfig)
+	if err != nil {
+		return err
+	}
 
+	err = loadSeedingPolicies()
 	if err != nil {
-		Log.Debug(Log.Level.Fatal, err.Error())
-		Log.Fatal("\nInitialize Torrent Session:", err.Error())
+		Log.Error("\nLoading seeding policies:", err.Error())
+		Log.Debug(Log.Level.Error, "Loading seeding policies:", err.Error())
+	}
+
+	err = loadMediaInfo()
+	if err != nil {
+		Log.Error("\nLoading media info:", err.Error())
+		Log.Debug(Log.Level.Error, "Loading media info:", err.Error())
+	}
+
+	err = loadHooksDone()
+	if err != nil {
+		Log.Error("\nLoading post download hooks:", err.Error())
+		Log.Debug(Log.Level.Error, "Loading post download hooks:", err.Error())
+	}
+
+	err = loadOwners()
+	if err != nil {
+		Log.Error("\nLoading torrent owners:", err.Error())
+		Log.Debug(Log.Level.Error, "Loading torrent owners:", err.Error())
 	}
-}
 
-func CloseSession() {
-	session.Close()
+	registerMetrics()
+	registerHealthChecks()
+
+	go runSeedingSupervisor(ctx)
+
+	return nil
 }
 
-func Download(uri string) (func() TorrentInfo, error) {
-	currentTor := session.GetTorrent(currentTorID)
-	if currentTor != nil {
-		currentTor.Stop()
+// CloseSession closes the torrent session
+func CloseSession(ctx context.Context) error {
+	if session == nil {
+		return nil
 	}
+	return session.Close()
+}
+
+// Download adds a torrent from a magnet link or a url, a nil policy uses the global seeding policy
+func Download(uri string, policy *common.SeedingPolicy) (func() TorrentInfo, error) {
+	stopCurrentDownload()
 
 	// Add magnet link
-	tor, err := session.AddURI(uri, &torrent.AddTorrentOptions{StopAfterDownload: true})
+	tor, err := session.AddURI(uri, addTorrentOptions(policy))
 	if err != nil {
 		return nil, err
 	}
 
+	attachMediaInfo(uri, tor.ID())
+
+	return startTracking(tor, policy), nil
+}
+
+// DownloadFromFile adds a torrent from the content of a .torrent file, a nil policy uses the global seeding policy
+func DownloadFromFile(file *TorrentFile, policy *common.SeedingPolicy) (func() TorrentInfo, error) {
+	stopCurrentDownload()
+
+	tor, err := session.AddTorrent(bytes.NewReader(file.Data), addTorrentOptions(policy))
+	if err != nil {
+		return nil, err
+	}
+
+	return startTracking(tor, policy), nil
+}
+
+// FinishDownload releases a completed torrent if it's still the current one, it keeps seeding if its seeding policy
+// allows it
+func FinishDownload(id string) {
+	if id == "" || id != currentTorID {
+		return
+	}
+
+	if GetSeedingPolicy(id).Mode == common.SeedingNone {
+		Stop(false)
+		return
+	}
+
+	currentTorID = ""
+}
+
+// stopCurrentDownload stops the current torrent unless it's seeding
+func stopCurrentDownload() {
+	currentTor := session.GetTorrent(currentTorID)
+	if currentTor != nil && currentTor.Stats().Status != torrent.Seeding {
+		currentTor.Stop()
+	}
+}
+
+func addTorrentOptions(policy *common.SeedingPolicy) *torrent.AddTorrentOptions {
+	mode := GetGlobalSeedingPolicy().Mode
+	if policy != nil {
+		mode = policy.Mode
+	}
+
+	return &torrent.AddTorrentOptions{StopAfterDownload: mode == common.SeedingNone}
+}
+
+// startTracking makes the torrent the current one and saves its seeding policy
+func startTracking(tor *torrent.Torrent, policy *common.SeedingPolicy) func() TorrentInfo {
 	currentTorID = tor.ID()
+	Log.With(utils.FieldTorrent, tor.ID()).Info("torrent download started", "name", tor.Name())
 
-	return generateStatsHandler(tor), nil
+	if policy != nil {
+		err := SetSeedingPolicy(tor.ID(), policy)
+		if err != nil {
+			Log.Error("\nSaving seeding policy:", err.Error())
+			Log.Debug(Log.Level.Error, "Saving seeding policy:", err.Error())
+		}
+	}
+
+	return generateStatsHandler(tor)
+}
+
+// ParseTorrentFile validates the metainfo of a .torrent file and extracts its name, size and file count
+func ParseTorrentFile(data []byte) (*TorrentFile, error) {
+	var metaInfo struct {
+		Info struct {
+			Name        string `bencode:"name"`
+			PieceLength int64  `bencode:"piece length"`
+			Pieces      string `bencode:"pieces"`
+			Length      int64  `bencode:"length"`
+			Files       []struct {
+				Length int64    `bencode:"length"`
+				Path   []string `bencode:"path"`
+			} `bencode:"files"`
+		} `bencode:"info"`
+	}
+
+	err := bencode.DecodeBytes(data, &metaInfo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid torrent file: %s", err.Error())
+	}
+
+	info := metaInfo.Info
+
+	if info.Name == "" {
+		return nil, errors.New("invalid torrent file: missing name")
+	}
+	if info.PieceLength <= 0 {
+		return nil, errors.New("invalid torrent file: invalid piece length")
+	}
+	if len(info.Pieces) == 0 || len(info.Pieces)%20 != 0 {
+		return nil, errors.New("invalid torrent file: invalid pieces hash")
+	}
+
+	// single file torrent
+	size := info.Length
+	fileCount := 1
+
+	// multi file torrent
+	if len(info.Files) > 0 {
+		size = 0
+		fileCount = len(info.Files)
+		for _, file := range info.Files {
+			if file.Length < 0 || len(file.Path) == 0 {
+				return nil, errors.New("invalid torrent file: invalid file entry")
+			}
+			size += file.Length
+		}
+	}
+
+	if size <= 0 {
+		return nil, errors.New("invalid torrent file: no files to download")
+	}
+
+	return &TorrentFile{
+		Name:      info.Name,
+		TotalSize: FormatBytes(size),
+		FileCount: fileCount,
+		Data:      data,
+	}, nil
 }
 
 func Stop(remove bool) {
@@ -78,18 +249,39 @@ func Stop(remove bool) {
 	if currentTor != nil {
 		currentTor.Stop()
 		if remove {
-			session.RemoveTorrent(currentTorID)
+			err := Remove(currentTorID)
+			if err != nil {
+				Log.Error("\nRemoving torrent:", err.Error())
+				Log.Debug(Log.Level.Error, "Removing torrent:", err.Error())
+			}
 		}
 		currentTorID = ""
 	}
 }
 
+// StopCurrent stops the current torrent if it's the one with the ID, false if it's not the current one anymore
+func StopCurrent(id string, remove bool) bool {
+	if id == "" || id != currentTorID {
+		return false
+	}
+
+	Stop(remove)
+	return true
+}
+
 func GetAllTorrents() []*torrent.Torrent {
 	return session.ListTorrents()
 }
 
+// Remove deletes a torrent and everything saved about it, every step runs even if a previous one failed
 func Remove(id string) error {
-	return session.RemoveTorrent(id)
+	return errors.Join(
+		session.RemoveTorrent(id),
+		removeMediaInfo(id),
+		removeHooksDone(id),
+		removeOwner(id),
+		removeSeedingPolicy(id),
+	)
 }
 
 func Exists(id string) bool {
@@ -97,10 +289,7 @@ func Exists(id string) bool {
 }
 
 func Resume(tor *torrent.Torrent) (func() TorrentInfo, error) {
-	currentTor := session.GetTorrent(currentTorID)
-	if currentTor != nil {
-		currentTor.Stop()
-	}
+	stopCurrentDownload()
 
 	err := tor.Start()
 	if err != nil {
@@ -153,15 +342,17 @@ func generateStatsHandler(tor *torrent.Torrent) func() TorrentInfo {
 		isSeeding := s.Status == torrent.Seeding
 
 		return TorrentInfo{
+			ID:            tor.ID(),
 			Name:          s.Name,
 			Status:        s.Status.String(),
-			Downloaded:    formatBytes(s.Bytes.Completed),
-			Uploaded:      formatBytes(s.Bytes.Uploaded),
-			TotalSize:     formatBytes(s.Bytes.Total),
+			Downloaded:    FormatBytes(s.Bytes.Completed),
+			Uploaded:      FormatBytes(s.Bytes.Uploaded),
+			TotalSize:     FormatBytes(s.Bytes.Total),
 			Progress:      fmt.Sprintf("%.2f%%", progress),
-			DownloadSpeed: formatBytes(int64(s.Speed.Download)),
-			UploadSpeed:   formatBytes(int64(s.Speed.Upload)),
+			DownloadSpeed: FormatBytes(int64(s.Speed.Download)),
+			UploadSpeed:   FormatBytes(int64(s.Speed.Upload)),
 			Peers:         fmt.Sprintf("%d", s.Peers.Total),
+			Ratio:         fmt.Sprintf("%.2f", ratio(s)),
 			ETA:           eta,
 			Completed:     isSeeding || (s.Bytes.Total != 0 && s.Bytes.Completed == s.Bytes.Total),
 			Stopped:       s.Status == torrent.Stopped,
@@ -170,7 +361,8 @@ func generateStatsHandler(tor *torrent.Torrent) func() TorrentInfo {
 	}
 }
 
-func formatBytes(bytes int64) string {
+// FormatBytes formats a number of bytes into a human readable string
+func FormatBytes(bytes int64) string {
 	const unit = 1000
 	if bytes < unit {
 		return fmt.Sprintf("%d B", bytes)
@@ -210,3 +402,81 @@ func formatTime(d time.Duration) string {
 
 	return result
 }
+
+type TorrentState = string
+
+const (
+	StateAll         TorrentState = "all"
+	StateDownloading TorrentState = "downloading"
+	StateCompleted   TorrentState = "completed"
+	StateStopped     TorrentState = "stopped"
+	StateErrored     TorrentState = "errored"
+)
+
+// GetTorrentState returns the state of a torrent, it's one of downloading, completed, stopped or errored
+func GetTorrentState(tor *torrent.Torrent) TorrentState {
+	s := tor.Stats()
+
+	switch {
+	case s.Error != nil:
+		return StateErrored
+	case s.Status == torrent.Seeding || (s.Bytes.Total != 0 && s.Bytes.Completed == s.Bytes.Total):
+		return StateCompleted
+	case s.Status == torrent.Stopped || s.Status == torrent.Stopping:
+		return StateStopped
+	}
+
+	return StateDownloading
+}
+
+// FilterTorrents returns the torrents that match the state and contain the name (case insensitive), newest first
+func FilterTorrents(state TorrentState, name string) []*torrent.Torrent {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	var results []*torrent.Torrent
+	for _, tor := range session.ListTorrents() {
+		if state != "" && state != StateAll && GetTorrentState(tor) != state {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(tor.Name()), name) {
+			continue
+		}
+		results = append(results, tor)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].AddedAt().After(results[j].AddedAt())
+	})
+
+	return results
+}
+
+// GetProgress returns the completion percentage of a torrent
+func GetProgress(tor *torrent.Torrent) float64 {
+	s := tor.Stats()
+	if s.Bytes.Total == 0 {
+		return 0
+	}
+	return float64(s.Bytes.Completed) / float64(s.Bytes.Total) * 100
+}
+
+// StartByID starts a torrent in the background without making it the current download
+func StartByID(id string) error {
+	t := session.GetTorrent(id)
+	if t == nil {
+		return fmt.Errorf("torrent %s not found", id)
+	}
+	return t.Start()
+}
+
+// StopByID stops a torrent
+func StopByID(id string) error {
+	t := session.GetTorrent(id)
+	if t == nil {
+		return fmt.Errorf("torrent %s not found", id)
+	}
+	if id == currentTorID {
+		currentTorID = ""
+	}
+	return t.Stop()
+}
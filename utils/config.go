@@ -0,0 +1,423 @@
+package utils
+
+import (
+	"bytes"
+	"discord-bot/common"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// every setting can be overridden by an environment variable, e.g. BOT_HTTP_PORT or BOT_TORRENT_SEEDING_MODE
+const configEnvPrefix = "BOT_"
+
+var config atomic.Pointer[common.Config]
+
+// PrepareAppConfig loads the config file, it's used by GetAppConfig
+func PrepareAppConfig(path string) (*common.Config, error) {
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Store(loaded)
+	return loaded, nil
+}
+
+func GetAppConfig() *common.Config {
+	return config.Load()
+}
+
+// ReloadAppConfig loads the config file again and applies the settings that can change while running,
+// it returns the names of the changed settings that need a restart, they keep their old values
+func ReloadAppConfig(path string) (*common.Config, []string, error) {
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current := GetAppConfig()
+	needRestart := []string{}
+
+	keep := func(name string, newValue any, oldValue any) {
+		if !reflect.DeepEqual(newValue, oldValue) {
+			needRestart = append(needRestart, name)
+		}
+		reflect.ValueOf(newValue).Elem().Set(reflect.ValueOf(oldValue).Elem())
+	}
+
+	keep("torrent.downloadDir", &loaded.Torrent.DownloadDir, &current.Torrent.DownloadDir)
+	keep("torrent.zipDir", &loaded.Torrent.ZipDir, &current.Torrent.ZipDir)
+	// rain (v1.12.19) builds its rate limit buckets once in NewSession and has no setter, so unlike the other
+	// live settings the speed limits can't be reloaded, the session would have to be recreated under the handlers
+	keep("torrent.speedLimit", &loaded.Torrent.SpeedLimit, &current.Torrent.SpeedLimit)
+	// the commands are registered when the session is started
+	keep("commands", &loaded.Commands, &current.Commands)
+	keep("customCommands.filesDir", &loaded.CustomCommands.FilesDir, &current.CustomCommands.FilesDir)
+	keep("http.host", &loaded.Http.Host, &current.Http.Host)
+	keep("http.port", &loaded.Http.Port, &current.Http.Port)
+	keep("http.routes", &loaded.Http.Routes, &current.Http.Routes)
+
+	config.Store(loaded)
+	return loaded, needRestart, nil
+}
+
+// LoadConfig reads a json, yaml or toml config file, applies the environment overrides and the defaults and
+// validates it, a missing file is allowed so the bot can be configured with environment variables only
+func LoadConfig(path string) (*common.Config, error) {
+	loaded := &common.Config{}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		err = decodeConfig(path, data, loaded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config file \"%s\": %w", path, err)
+		}
+	} else {
+		Log.Warning("\nConfig file \""+path+"\" not found,", "using the defaults and the environment variables")
+	}
+
+	err = applyConfigEnv(reflect.ValueOf(loaded).Elem(), configEnvPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	applyConfigDefaults(loaded)
+
+	err = validateConfig(loaded)
+	if err != nil {
+		return nil, err
+	}
+
+	return loaded, nil
+}
+
+// decodeConfig decodes the file by its extension, yaml and toml are converted to json so the json names are used for all of them
+func decodeConfig(path string, data []byte, out *common.Config) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		values := map[string]any{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return err
+		}
+		data, _ = json.Marshal(values)
+
+	case ".toml":
+		values := map[string]any{}
+		if err := toml.Unmarshal(data, &values); err != nil {
+			return err
+		}
+		data, _ = json.Marshal(values)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(out)
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("%s: expected a %s, got %s", typeErr.Field, typeErr.Type.Kind(), typeErr.Value)
+	}
+	if err != nil && strings.HasPrefix(err.Error(), "json: unknown field ") {
+		return fmt.Errorf("unknown setting %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+	}
+
+	return err
+}
+
+// envName converts a json name like "downloadDir" to "DOWNLOAD_DIR"
+func envName(name string) string {
+	result := strings.Builder{}
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 {
+			result.WriteByte('_')
+		}
+		result.WriteRune(unicode.ToUpper(r))
+	}
+	return result.String()
+}
+
+// applyConfigEnv sets the fields that have an environment variable, the names are the prefix and the json path
+func applyConfigEnv(value reflect.Value, prefix string) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := prefix + envName(name)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyConfigEnv(field, key+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		text, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(text)
+
+		case reflect.Bool:
+			v, err := strconv.ParseBool(text)
+			if err != nil {
+				return fmt.Errorf("%s: expected true or false, got \"%s\"", key, text)
+			}
+			field.SetBool(v)
+
+		case reflect.Int, reflect.Int64:
+			v, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s: expected a whole number, got \"%s\"", key, text)
+			}
+			field.SetInt(v)
+
+		case reflect.Float64:
+			v, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return fmt.Errorf("%s: expected a number, got \"%s\"", key, text)
+			}
+			field.SetFloat(v)
+
+		// maps and lists are set with json, e.g. BOT_COMMANDS_FEATURES='{"torrent":{"guilds":["123"]}}'
+		case reflect.Map, reflect.Slice:
+			err := json.Unmarshal([]byte(text), field.Addr().Interface())
+			if err != nil {
+				return fmt.Errorf("%s: expected json, %s", key, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func applyConfigDefaults(c *common.Config) {
+	setDefault := func(value *string, defaultValue string) {
+		if *value == "" {
+			*value = defaultValue
+		}
+	}
+
+	setDefault(&c.Log.Path, "./discordBot.log")
+	setDefault(&c.Log.Level, "info")
+	setDefault(&c.Log.Format, "text")
+	setDefault(&c.Log.Console, "pretty")
+	if c.Log.MaxSize == 0 {
+		c.Log.MaxSize = 50
+	}
+
+	setDefault(&c.Torrent.DownloadDir, "./downloads")
+	setDefault(&c.Torrent.ZipDir, "./zips")
+	if c.Torrent.MinFreeSpace == 0 {
+		c.Torrent.MinFreeSpace = 1024
+	}
+	setDefault(&c.Torrent.Seeding.Mode, common.SeedingNone)
+	setDefault(&c.Torrent.Hooks.LibraryMode, common.LibraryHardlink)
+	setDefault(&c.Torrent.Hooks.RescanMethod, "POST")
+
+	setDefault(&c.CustomCommands.FilesDir, "./customCommandFiles")
+
+	if c.Limits.GuildCommandsPerMinute == 0 {
+		c.Limits.GuildCommandsPerMinute = 60
+	}
+	for _, value := range []*int{&c.Limits.Concurrency.Scraping, &c.Limits.Concurrency.FFmpeg, &c.Limits.Concurrency.Images} {
+		if *value == 0 {
+			*value = 2
+		}
+	}
+
+	if c.Http.Port == 0 {
+		c.Http.Port = 3000
+	}
+	setDefault(&c.Http.Domain, fmt.Sprintf("http://localhost:%d", c.Http.Port))
+	setDefault(&c.Http.Routes.Video, "/stream/")
+	setDefault(&c.Http.Routes.Zip, "/zip/")
+	setDefault(&c.Http.Routes.Metrics, "/metrics")
+}
+
+// checkDir makes sure that a directory exists, it's created if it's missing
+func checkDir(path string) error {
+	info, err := os.Stat(path)
+	if err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("\"%s\" is not a directory", path)
+		}
+		return nil
+	}
+
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	err = os.MkdirAll(path, 0755)
+	if err != nil {
+		return fmt.Errorf("can't create the directory \"%s\": %w", path, err)
+	}
+	return nil
+}
+
+// isSnowflake checks if an ID looks like a Discord ID
+func isSnowflake(id string) bool {
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err == nil
+}
+
+func validateConfig(c *common.Config) error {
+	problems := []string{}
+	add := func(field string, format string, args ...any) {
+		problems = append(problems, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	// * Log
+	if _, err := ParseLogLevel(c.Log.Level); err != nil {
+		add("log.level", "%s", err.Error())
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		add("log.format", "expected text or json, got \"%s\"", c.Log.Format)
+	}
+	if c.Log.Console != "pretty" && c.Log.Console != "json" {
+		add("log.console", "expected pretty or json, got \"%s\"", c.Log.Console)
+	}
+	if c.Log.MaxSize < 0 || c.Log.MaxAge < 0 || c.Log.MaxBackups < 0 {
+		add("log", "maxSize, maxAge and maxBackups can't be negative")
+	}
+	if c.Log.Enabled {
+		if err := checkDir(filepath.Dir(c.Log.Path)); err != nil {
+			add("log.path", "%s", err.Error())
+		}
+	}
+
+	// * Torrent
+	if err := checkDir(c.Torrent.DownloadDir); err != nil {
+		add("torrent.downloadDir", "%s", err.Error())
+	}
+	if err := checkDir(c.Torrent.ZipDir); err != nil {
+		add("torrent.zipDir", "%s", err.Error())
+	}
+	if c.Torrent.MinFreeSpace < 0 {
+		add("torrent.minFreeSpace", "can't be negative")
+	}
+	if c.Torrent.SpeedLimit.Download < 0 || c.Torrent.SpeedLimit.Upload < 0 {
+		add("torrent.speedLimit", "can't be negative, use 0 for no limit")
+	}
+
+	switch c.Torrent.Seeding.Mode {
+	case common.SeedingNone:
+	case common.SeedingRatio:
+		if c.Torrent.Seeding.Ratio <= 0 {
+			add("torrent.seeding.ratio", "should be greater than 0 for the ratio mode")
+		}
+	case common.SeedingTime:
+		if c.Torrent.Seeding.Hours <= 0 {
+			add("torrent.seeding.hours", "should be greater than 0 for the time mode")
+		}
+	default:
+		add("torrent.seeding.mode", "expected none, ratio or time, got \"%s\"", c.Torrent.Seeding.Mode)
+	}
+
+	hooks := c.Torrent.Hooks
+	if hooks.LibraryMode != common.LibraryHardlink && hooks.LibraryMode != common.LibraryMove {
+		add("torrent.hooks.libraryMode", "expected hardlink or move, got \"%s\"", hooks.LibraryMode)
+	}
+	if hooks.LibraryDir != "" {
+		if err := checkDir(hooks.LibraryDir); err != nil {
+			add("torrent.hooks.libraryDir", "%s", err.Error())
+		}
+	}
+	if hooks.RescanURL != "" {
+		if u, err := url.Parse(hooks.RescanURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("torrent.hooks.rescanUrl", "expected an http or https URL, got \"%s\"", hooks.RescanURL)
+		}
+	}
+	switch strings.ToUpper(hooks.RescanMethod) {
+	case "GET", "POST", "PUT":
+	default:
+		add("torrent.hooks.rescanMethod", "expected GET, POST or PUT, got \"%s\"", hooks.RescanMethod)
+	}
+
+	// * Commands
+	if c.Commands.DevGuild != "" && !isSnowflake(c.Commands.DevGuild) {
+		add("commands.devGuild", "expected a guild ID, got \"%s\"", c.Commands.DevGuild)
+	}
+	for feature, scope := range c.Commands.Features {
+		if !slices.Contains(common.Features, feature) {
+			add("commands.features", "unknown feature \"%s\", expected one of %s", feature, strings.Join(common.Features, ", "))
+		}
+		for _, guildID := range scope.Guilds {
+			if !isSnowflake(guildID) {
+				add("commands.features."+feature+".guilds", "expected a guild ID, got \"%s\"", guildID)
+			}
+		}
+	}
+
+	// * Custom commands
+	if err := checkDir(c.CustomCommands.FilesDir); err != nil {
+		add("customCommands.filesDir", "%s", err.Error())
+	}
+
+	// * Limits
+	if c.Limits.GuildCommandsPerMinute < -1 {
+		add("limits.guildCommandsPerMinute", "expected a number greater than 0, or -1 for no limit, got %d", c.Limits.GuildCommandsPerMinute)
+	}
+	concurrency := c.Limits.Concurrency
+	if concurrency.Scraping < 0 || concurrency.FFmpeg < 0 || concurrency.Images < 0 {
+		add("limits.concurrency", "can't be negative")
+	}
+
+	// * HTTP
+	if c.Http.Port < 1 || c.Http.Port > 65535 {
+		add("http.port", "expected a port between 1 and 65535, got %d", c.Http.Port)
+	}
+	if u, err := url.Parse(c.Http.Domain); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("http.domain", "expected a URL like https://example.com, got \"%s\"", c.Http.Domain)
+	}
+
+	routes := map[string]string{
+		"http.routes.video":   c.Http.Routes.Video,
+		"http.routes.zip":     c.Http.Routes.Zip,
+		"http.routes.metrics": c.Http.Routes.Metrics,
+	}
+	used := map[string]bool{"/healthz": true, "/readyz": true}
+	for _, field := range []string{"http.routes.video", "http.routes.zip", "http.routes.metrics"} {
+		route := routes[field]
+		if !strings.HasPrefix(route, "/") {
+			add(field, "should start with \"/\", got \"%s\"", route)
+		}
+		if field != "http.routes.metrics" && !strings.HasSuffix(route, "/") {
+			add(field, "should end with \"/\", got \"%s\"", route)
+		}
+		if used[route] {
+			add(field, "\"%s\" is already used", route)
+		}
+		used[route] = true
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config:\n- %s", strings.Join(problems, "\n- "))
+	}
+
+	return nil
+}
@@ -1,10 +1,11 @@
 package utils
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -20,6 +21,7 @@ type logStyles struct {
 }
 
 type logLevel struct {
+	Debug   string
 	Info    string
 	Success string
 	Warning string
@@ -27,11 +29,9 @@ type logLevel struct {
 	Fatal   string
 }
 type log struct {
-	Style         logStyles
-	Level         logLevel
-	LogToFile     bool
-	LogFilePath   string
-	LogFileWriter *os.File
+	Style logStyles
+	Level logLevel
+	state *logState // shared by the copies of the logger
 }
 
 var Log = log{
@@ -46,6 +46,7 @@ var Log = log{
 	},
 
 	Level: logLevel{
+		Debug:   "DEBUG",
 		Info:    "INFO",
 		Success: "SUCCESS",
 		Warning: "WARNING",
@@ -53,9 +54,7 @@ var Log = log{
 		Fatal:   "FATAL",
 	},
 
-	LogToFile:     false,
-	LogFilePath:   "",
-	LogFileWriter: nil,
+	state: newLogState(),
 }
 
 const titleWidth = 13
@@ -87,7 +86,17 @@ func formatTitle(title string, style lipgloss.Style) string {
 		style.Render("|")
 }
 
-func (l log) printLog(title string, style lipgloss.Style, strs ...string) {
+func (l *log) printLog(level slog.Level, title string, style lipgloss.Style, strs ...string) {
+	if !l.state.enabled(level) {
+		return
+	}
+
+	// JSON console output for log collectors
+	if console := l.state.consoleLogger(); console != nil {
+		console.Log(context.Background(), level, cleanMessage(strs))
+		return
+	}
+
 	title = formatTitle(title, style)
 
 	fullString := strings.Join(strs, " ")
@@ -109,75 +118,60 @@ func (l log) printLog(title string, style lipgloss.Style, strs ...string) {
 	fmt.Println(leadingNewlines+title, content+trailingNewlines)
 }
 
-func (l log) Success(strs ...string) {
-	l.printLog("SUCCESS", l.Style.Success, strs...)
+func (l *log) Success(strs ...string) {
+	l.printLog(slog.LevelInfo, "SUCCESS", l.Style.Success, strs...)
 }
 
-func (l log) Error(strs ...string) {
-	l.printLog("ERROR", l.Style.Error, strs...)
+func (l *log) Error(strs ...string) {
+	l.printLog(slog.LevelError, "ERROR", l.Style.Error, strs...)
 }
 
 // FATAL logs a fatal error and exits the program
-func (l log) Fatal(strs ...string) {
-	l.printLog("FATAL", l.Style.Error, strs...)
+func (l *log) Fatal(strs ...string) {
+	l.printLog(LevelFatal, "FATAL", l.Style.Error, strs...)
+	l.Close()
 	os.Exit(1)
 }
 
-func (l log) Info(strs ...string) {
-	l.printLog("INFO", l.Style.Info, strs...)
+func (l *log) Info(strs ...string) {
+	l.printLog(slog.LevelInfo, "INFO", l.Style.Info, strs...)
 }
 
-func (l log) Warning(strs ...string) {
-	l.printLog("WARNING", l.Style.Warning, strs...)
+func (l *log) Warning(strs ...string) {
+	l.printLog(slog.LevelWarn, "WARNING", l.Style.Warning, strs...)
 }
 
-func (l log) Tip(strs ...string) {
-	l.printLog("Tip", l.Style.Tip, strs...)
+func (l *log) Tip(strs ...string) {
+	l.printLog(slog.LevelInfo, "Tip", l.Style.Tip, strs...)
 }
 
-func (l log) Log(strs ...string) {
-	l.printLog("LOG", l.Style.Log, strs...)
+func (l *log) Log(strs ...string) {
+	l.printLog(slog.LevelInfo, "LOG", l.Style.Log, strs...)
 }
 
+// SetLogToFile enables the log file, use Configure to set all the log settings
 func (l *log) SetLogToFile(enabled bool) {
-	l.LogToFile = enabled
+	config := l.state.getConfig()
+	config.Enabled = enabled
+	l.Configure(config)
 }
 
+// SetLogFilePath sets the log file path, use Configure to set all the log settings
 func (l *log) SetLogFilePath(path string) {
-	l.LogFilePath = path
+	config := l.state.getConfig()
+	config.Path = path
+	l.Configure(config)
 }
 
-// Debug logs a debug message to the log file only (not to the console)
+// Debug logs a message to the log file only (not to the console), the level is one of Log.Level,
+// use Log.With for structured fields
 func (l *log) Debug(level string, strs ...string) {
-	if !l.LogToFile {
+	file := l.state.fileLogger()
+	if file == nil {
 		return
 	}
 
-	// Ensure the log file writer is initialized
-	if l.LogFileWriter == nil {
-		// Open the log file with append mode, create if it does not exist.
-		file, err := os.OpenFile(l.LogFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			Log.Error("\nLogger:", err.Error())
-			return
-		}
-		l.LogFileWriter = file
-
-		_, err = l.LogFileWriter.WriteString("\n--------------------\n\n")
-		if err != nil {
-			Log.Error("\nLogger:", err.Error())
-		}
-	}
-
-	recordTime := time.Now().Format("2006-01-02 15:04:05")
-	fullString := strings.Join(strs, " ")
-	_, msg, _ := splitOnNewline(fullString)
-	logMsg := fmt.Sprintf("[%s] [%s] %s", recordTime, level, msg)
-
-	_, err := l.LogFileWriter.WriteString(logMsg + "\n")
-	if err != nil {
-		Log.Error("\nLogger:", err.Error())
-	}
+	file.Log(context.Background(), parseLevel(level), cleanMessage(strs))
 }
 
 // FileExists checks if a file exists at the given path.
@@ -0,0 +1,254 @@
+package utils
+
+import (
+	"context"
+	"discord-bot/common"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LevelFatal is above the error level, it's always logged
+const LevelFatal = slog.Level(12)
+
+// the field names of the structured logs
+const (
+	FieldGuild   = "guild"
+	FieldChannel = "channel"
+	FieldUser    = "user"
+	FieldCommand = "command"
+	FieldTorrent = "torrent"
+	FieldError   = "error"
+	FieldErrorID = "errorId"
+)
+
+// logState holds the settings and the outputs of the logger, it's shared by the copies of Log
+type logState struct {
+	mutex   sync.RWMutex
+	config  common.LogConfig
+	level   slog.LevelVar
+	file    *slog.Logger // nil when the log file is disabled
+	console *slog.Logger // nil for the pretty console output
+	writer  io.Closer
+}
+
+func newLogState() *logState {
+	return &logState{}
+}
+
+func (s *logState) getConfig() common.LogConfig {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.config
+}
+
+func (s *logState) fileLogger() *slog.Logger {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.file
+}
+
+func (s *logState) consoleLogger() *slog.Logger {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.console
+}
+
+func (s *logState) enabled(level slog.Level) bool {
+	return level >= s.level.Level()
+}
+
+// ParseLogLevel parses a level name of the config: "debug", "info", "warning" or "error"
+func ParseLogLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level \"%s\", expected debug, info, warning or error", name)
+}
+
+// parseLevel converts one of Log.Level to a slog level
+func parseLevel(level string) slog.Level {
+	switch level {
+	case Log.Level.Debug:
+		return slog.LevelDebug
+	case Log.Level.Warning:
+		return slog.LevelWarn
+	case Log.Level.Error:
+		return slog.LevelError
+	case Log.Level.Fatal:
+		return LevelFatal
+	}
+	return slog.LevelInfo
+}
+
+// cleanMessage joins the strings of the old style log calls and trims the newlines used for the console
+func cleanMessage(strs []string) string {
+	_, msg, _ := splitOnNewline(strings.Join(strs, " "))
+	return strings.TrimSpace(msg)
+}
+
+func replaceLevel(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Key == slog.LevelKey && len(groups) == 0 {
+		if level, ok := attr.Value.Any().(slog.Level); ok && level >= LevelFatal {
+			attr.Value = slog.StringValue(Log.Level.Fatal)
+		}
+	}
+	return attr
+}
+
+// Configure applies the log settings, it can be called again to reload them
+func (l *log) Configure(config common.LogConfig) error {
+	level, err := ParseLogLevel(config.Level)
+	if err != nil {
+		return err
+	}
+
+	if config.Format != "" && config.Format != "text" && config.Format != "json" {
+		return fmt.Errorf("unknown log format \"%s\", expected text or json", config.Format)
+	}
+
+	if config.Console != "" && config.Console != "pretty" && config.Console != "json" {
+		return fmt.Errorf("unknown console log format \"%s\", expected pretty or json", config.Console)
+	}
+
+	state := l.state
+	options := &slog.HandlerOptions{Level: &state.level, ReplaceAttr: replaceLevel}
+
+	var file *slog.Logger
+	var writer *lumberjack.Logger
+	if config.Enabled {
+		path := config.Path
+		if path == "" {
+			path = "./discordBot.log"
+		}
+
+		maxSize := config.MaxSize
+		if maxSize <= 0 {
+			maxSize = 50
+		}
+
+		// the file is opened on the first write
+		writer = &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    maxSize,
+			MaxAge:     config.MaxAge,
+			MaxBackups: config.MaxBackups,
+			Compress:   config.Compress,
+			LocalTime:  true,
+		}
+
+		if config.Format == "json" {
+			file = slog.New(slog.NewJSONHandler(writer, options))
+		} else {
+			file = slog.New(slog.NewTextHandler(writer, options))
+		}
+	}
+
+	var console *slog.Logger
+	if config.Console == "json" {
+		console = slog.New(slog.NewJSONHandler(os.Stdout, options))
+	}
+
+	state.mutex.Lock()
+	oldWriter := state.writer
+	state.config = config
+	state.level.Set(level)
+	state.file = file
+	state.console = console
+	state.writer = nil
+	if writer != nil { // a nil *lumberjack.Logger is not a nil io.Closer
+		state.writer = writer
+	}
+	state.mutex.Unlock()
+
+	if oldWriter != nil {
+		oldWriter.Close()
+	}
+
+	return nil
+}
+
+// Close closes the log file
+func (l *log) Close() error {
+	state := l.state
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	state.file = nil
+	if state.writer == nil {
+		return nil
+	}
+
+	err := state.writer.Close()
+	state.writer = nil
+	return err
+}
+
+// With returns a structured logger with the given fields, e.g. Log.With(utils.FieldGuild, guildID),
+// it writes to the log file and to the console when its format is json
+func (l *log) With(args ...any) *slog.Logger {
+	handlers := multiHandler{}
+
+	if file := l.state.fileLogger(); file != nil {
+		handlers = append(handlers, file.Handler())
+	}
+	if console := l.state.consoleLogger(); console != nil {
+		handlers = append(handlers, console.Handler())
+	}
+
+	return slog.New(handlers).With(args...)
+}
+
+// multiHandler sends the records to many handlers, without handlers it drops them
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range m {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, record slog.Record) error {
+	var firstErr error
+	for _, handler := range m {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, record.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, handler := range m {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, handler := range m {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
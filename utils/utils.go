@@ -1,12 +1,12 @@
 package utils
 
 import (
-	"discord-bot/common"
-	"encoding/json"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
 	"os"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -52,6 +52,15 @@ func Contains(arr *[]string, target string) bool {
 	return false
 }
 
+// Truncate cuts a text to maxLength characters, it ends with "..." when it's cut. It counts runes, so a multi-byte
+// character is never cut in half, maxLength must be at least 3
+func Truncate(text string, maxLength int) string {
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+	return string([]rune(text)[:maxLength-3]) + "..."
+}
+
 // CheckOptionStringValue check if a discord option has a string value (not nil and not empty)
 func CheckOptionStringValue(options *discordgo.ApplicationCommandInteractionDataOption) (string, error) {
 	if options.Value == nil {
@@ -141,38 +150,88 @@ func IsVideoFile(filePath string) (bool, error) {
 	return mimeType[:6] == "video/", nil
 }
 
-var Config *common.Config
+// DownloadAttachment downloads a discord attachment into memory, it fails if the attachment is larger than maxSize bytes.
+func DownloadAttachment(attachment *discordgo.MessageAttachment, maxSize int64) ([]byte, error) {
+	if attachment.Size > int(maxSize) {
+		return nil, fmt.Errorf("attachment is too large, max size is %d bytes", maxSize)
+	}
 
-func PrepareAppConfig(path string) (*common.Config, error) {
-	file, err := os.Open(path)
+	resp, err := http.Get(attachment.URL)
 	if err != nil {
-		return Config, err
+		return nil, err
 	}
-	defer file.Close()
+	defer resp.Body.Close()
 
-	decoder := json.NewDecoder(file)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download attachment: %s", resp.Status)
+	}
 
-	err = decoder.Decode(&Config)
+	// read one more byte to detect oversized bodies
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
 	if err != nil {
-		return Config, err
+		return nil, err
 	}
 
-	return Config, nil
-}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("attachment is too large, max size is %d bytes", maxSize)
+	}
 
-func GetAppConfig() *common.Config {
-	return Config
+	return data, nil
 }
 
 func GenerateConfigJsonTemplate() error {
 	template := `{
   "log": {
     "enabled": true,
-    "path": "./discordBot.log"
+    "path": "./discordBot.log",
+    "level": "info",
+    "format": "text",
+    "console": "pretty",
+    "maxSize": 50,
+    "maxAge": 30,
+    "maxBackups": 5,
+    "compress": true
   },
   "torrent": {
     "downloadDir": "./downloads",
-    "zipDir": "./zips"
+    "zipDir": "./zips",
+    "minFreeSpace": 1024,
+    "speedLimit": {
+      "download": 0,
+      "upload": 0
+    },
+    "seeding": {
+      "mode": "none",
+      "ratio": 1,
+      "hours": 24
+    },
+    "hooks": {
+      "libraryDir": "",
+      "libraryMode": "hardlink",
+      "command": "",
+      "rescanUrl": "",
+      "rescanMethod": "POST"
+    }
+  },
+  "commands": {
+    "devGuild": "",
+    "features": {
+      "torrent": {
+        "disabled": false,
+        "guilds": []
+      }
+    }
+  },
+  "customCommands": {
+    "filesDir": "./customCommandFiles"
+  },
+  "limits": {
+    "guildCommandsPerMinute": 60,
+    "concurrency": {
+      "scraping": 2,
+      "ffmpeg": 2,
+      "images": 2
+    }
   },
   "http": {
 	"domain": "http://localhost:3000",
@@ -180,7 +239,8 @@ func GenerateConfigJsonTemplate() error {
     "port": 3000,
     "routes": {
       "video": "/stream/",
-      "zip": "/zip/"
+      "zip": "/zip/",
+      "metrics": "/metrics"
     }
   }
 }